@@ -2,37 +2,129 @@ package cmd
 
 import (
 	"strings"
+	"time"
 
 	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/dates"
+	"github.com/flyme2mars/jotcli/internal/markdown"
+	"github.com/flyme2mars/jotcli/internal/recur"
 	"github.com/spf13/cobra"
 )
 
 var (
-	tag      string
-	priority string
+	tags      []string
+	priority  string
+	due       string
+	status    string
+	every     string
+	journal   bool
+	noteTmpl  string
+	noteTitle string
 )
 
 var addCmd = &cobra.Command{
 	Use:   "add [note]",
 	Short: "Add a new note",
-	Args:  cobra.MinimumNArgs(1),
+	Example: `  jotcli add "Check out the new #Go release" --tag dev --priority high
+  jotcli add "Send the invoice" --due "friday 5pm" --status open
+  jotcli add "Weekly review" --due "friday 4pm" --every week
+  jotcli add --journal "Shipped the release"
+  jotcli add --template meeting "Sprint planning" --tag work
+  jotcli add "Agenda: budget, hiring" --title "Board meeting"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		// With a template the arguments are an optional title
+		if noteTmpl != "" {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		note := strings.Join(args, " ")
 		// Convert literal \n to actual newlines
 		note = strings.ReplaceAll(note, "\\n", "\n")
-		
-		err := database.AddNote(note, tag, priority)
+		summary := note
+
+		if journal {
+			if len(tags) > 0 || status != "" || due != "" || every != "" || noteTmpl != "" || noteTitle != "" {
+				cmd.Println("Error: --journal can't be combined with --tag, --status, --due, --every, --template or --title")
+				return
+			}
+			addToJournal(cmd, note)
+			return
+		}
+		n := database.NewNote{Content: note, Tags: tags, Priority: priority, Title: noteTitle}
+		if status != "" {
+			s, err := parseStatusArg(status)
+			if err != nil {
+				cmd.Printf("Error: --status: %v\n", err)
+				return
+			}
+			n.Status = s
+		}
+		if due != "" {
+			t, err := dates.ParseTime(due, time.Now())
+			if err != nil {
+				cmd.Printf("Error: --due: %v\n", err)
+				return
+			}
+			n.DueAt = &t
+		}
+		if every != "" {
+			if n.DueAt == nil {
+				cmd.Println("Error: --every needs a first due date (--due)")
+				return
+			}
+			rule, err := recur.Parse(every)
+			if err != nil {
+				cmd.Printf("Error: --every: %v\n", err)
+				return
+			}
+			n.Recurrence = rule.String()
+			if n.Status == "" {
+				n.Status = database.StatusOpen
+			}
+		}
+
+		// The editor comes last so nothing written in it is lost to a bad flag
+		if noteTmpl != "" {
+			content, ok := composeNote(cmd, noteTmpl, note)
+			if !ok {
+				return
+			}
+			n.Content, summary = content, markdown.FirstLine(content)
+		}
+
+		store := noteStore(cmd)
+		id, err := store.Add(cmd.Context(), n)
 		if err != nil {
 			cmd.Printf("Error: %v\n", err)
 			return
 		}
 
-		cmd.Printf("✅ Note saved: %s\n", note)
+		cmd.Printf("✅ Note saved: %s\n", summary)
+		if n.Title != "" {
+			if saved, err := store.Get(cmd.Context(), id); err == nil && saved != nil {
+				cmd.Printf("🔖 %s (slug %s)\n", saved.Title, saved.Slug)
+			}
+		}
+		if n.DueAt != nil {
+			cmd.Printf("⏰ Due %s\n", formatDue(*n.DueAt, time.Now()))
+		}
+		if n.Recurrence != "" {
+			rule, _ := recur.Parse(n.Recurrence)
+			cmd.Printf("🔁 Repeats %s\n", rule.Describe())
+		}
 	},
 }
 
 func init() {
-	addCmd.Flags().StringVarP(&tag, "tag", "t", "", "Tag for the note")
+	addCmd.Flags().StringSliceVarP(&tags, "tag", "t", nil, "Tags for the note (repeat or comma-separate; #hashtags in the note are added too)")
 	addCmd.Flags().StringVarP(&priority, "priority", "p", "low", "Priority level (low, medium, high)")
+	addCmd.Flags().StringVar(&status, "status", "", "Make the note a task with this status (open, doing, done or cancelled)")
+	addCmd.Flags().StringVar(&due, "due", "", "When the note is due, e.g. \"friday 5pm\", tomorrow or 2026-03-20")
+	addCmd.Flags().StringVar(&every, "every", "", "Repeat the task: day, weekday, week, month, year, \"2 weeks\", \"mon,thu\" or an RRULE")
+	addCmd.Flags().StringVar(&noteTmpl, "template", "", "Write the note in your editor, starting from this template (see 'jotcli templates')")
+	addCmd.Flags().StringVar(&noteTitle, "title", "", "A title for the note; without one its first line is used")
+	addCmd.Flags().BoolVarP(&journal, "journal", "j", false, "Add the note to today's journal entry as a timestamped bullet")
 	rootCmd.AddCommand(addCmd)
 }
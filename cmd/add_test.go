@@ -2,215 +2,804 @@ package cmd
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/flyme2mars/jotcli/internal/database"
-	"github.com/spf13/cobra"
+	"github.com/flyme2mars/jotcli/internal/dates"
+	"github.com/spf13/viper"
 )
 
-func TestAddAndListIntegration(t *testing.T) {
-	// 1. Setup: Use a temporary database
-	tempDB := "test_integration.db"
-	defer os.Remove(tempDB)
+// testStore is a NoteStore the command tests run against.
+type testStore struct {
+	name  string
+	store database.NoteStore
+}
+
+// testStores returns a SQLite store on a throwaway database with the real
+// migrations applied, and an in-memory store, so every test checks that
+// the commands behave the same on both.
+func testStores(t *testing.T) []testStore {
+	t.Helper()
 
-	var err error
-	database.DB, err = database.OpenDB(tempDB)
+	db, err := database.OpenDB(filepath.Join(t.TempDir(), "jot.db"))
 	if err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
-	defer database.DB.Close()
+	t.Cleanup(func() { db.Close() })
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	return []testStore{
+		{"sqlite", database.NewSQLiteStore(db)},
+		{"memory", database.NewMemoryStore()},
+	}
+}
 
-	// Initialize tables
-	createTableSQL := `CREATE TABLE IF NOT EXISTS notes (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		content TEXT NOT NULL,
-		tag TEXT,
-		priority TEXT,
-		created_at DATETIME
-	);`
-	database.DB.Exec(createTableSQL)
+// execute runs the root command with args against store and returns its output.
+func execute(t *testing.T, store database.NoteStore, args ...string) string {
+	t.Helper()
 
-	// 2. Test "add" command
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
-	
-	// Reset flags to avoid contamination from previous tests
-	tag = ""
-	priority = "low"
-	
-	rootCmd.SetArgs([]string{"add", "Integration Test Note", "--tag", "test"})
-	
-	err = rootCmd.Execute()
-	if err != nil {
-		t.Errorf("add command failed: %v", err)
+	rootCmd.SetArgs(args)
+	if err := rootCmd.ExecuteContext(WithStore(context.Background(), store)); err != nil {
+		t.Fatalf("%v failed: %v", args, err)
 	}
+	return buf.String()
+}
 
-	output := buf.String()
-	if !strings.Contains(output, "✅ Note saved") {
-		t.Errorf("Expected success message in output, but didn't find it. Output: %q", output)
+// addNote seeds store with a note.
+func addNote(t *testing.T, store database.NoteStore, content string, tags []string, priority string) {
+	t.Helper()
+
+	n := database.NewNote{Content: content, Tags: tags, Priority: priority}
+	if _, err := store.Add(context.Background(), n); err != nil {
+		t.Fatalf("Add(%q) error = %v", content, err)
 	}
+}
 
-	// 3. Test "list" command
-	buf.Reset()
-	rootCmd.SetArgs([]string{"list"})
-	
-	err = rootCmd.Execute()
-	if err != nil {
-		t.Errorf("list command failed: %v", err)
+// resetAddFlags puts add's flags back to their defaults, before the test
+// and again once it is done, since cobra keeps flag values between runs.
+func resetAddFlags(t *testing.T) {
+	t.Helper()
+
+	reset := func() {
+		tags, priority, due, status, every = nil, "low", "", "", ""
+		journal, noteTmpl, noteTitle = false, "", ""
 	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestAddAndListIntegration(t *testing.T) {
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			resetAddFlags(t)
+			listFilter = noteFilterFlags{sort: database.SortCreated}
+
+			output := execute(t, ts.store, "add", "Integration Test Note", "--tag", "test")
+			if !strings.Contains(output, "✅ Note saved") {
+				t.Errorf("Expected success message in output, but didn't find it. Output: %q", output)
+			}
 
-	output = buf.String()
-	if !strings.Contains(output, "Integration Test Note") {
-		t.Errorf("List output missing added note. Output: %q", output)
+			output = execute(t, ts.store, "list")
+			if !strings.Contains(output, "Integration Test Note") {
+				t.Errorf("List output missing added note. Output: %q", output)
+			}
+
+			if !strings.Contains(output, "test") {
+				t.Errorf("List output missing tag. Output: %q", output)
+			}
+		})
 	}
-	
-		if !strings.Contains(output, "test") {
-	
-			t.Errorf("List output missing tag. Output: %q", output)
-	
-		}
-	
-	}
-	
-	
-	
-	func TestSearchIntegration(t *testing.T) {
-	
-		tempDB := "test_search.db"
-	
-		defer os.Remove(tempDB)
-	
-	
-	
-		var err error
-	
-		database.DB, err = database.OpenDB(tempDB)
-	
-		if err != nil {
-	
-			t.Fatalf("Failed to open test database: %v", err)
-	
-		}
-	
-		defer database.DB.Close()
-	
-	
-	
-		database.DB.Exec(`CREATE TABLE IF NOT EXISTS notes (
-	
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-	
-			content TEXT NOT NULL,
-	
-			tag TEXT,
-	
-			priority TEXT,
-	
-			created_at DATETIME
-	
-		);`)
-	
-	
-	
-			// 1. Add some notes
-	
-	
-	
-			database.AddNote("Apple pie recipe", "food", "low")
-	
-	
-	
-			database.AddNote("Banana bread", "food", "medium")
-	
-	
-	
-			database.AddNote("Buy a new computer", "work", "high")
-	
-	
-	
-		
-	
-	
-	
-			// Override the PersistentPreRun so it doesn't try to open ~/.jot.db
-	
-	
-	
-			rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {}
-	
-	
-	
-		
-	
-	
-	
-			// 2. Test searching for "Apple"
-	
-	
-	
-			buf := new(bytes.Buffer)
-	
-	
-	
-			rootCmd.SetOut(buf)
-	
-	
-	
-			rootCmd.SetErr(buf)
-	
-	
-	
-			rootCmd.SetArgs([]string{"search", "Apple"})
-	
-		
-	
-		err = rootCmd.Execute()
-	
-		if err != nil {
-	
-			t.Errorf("search command failed: %v", err)
-	
-		}
-	
-	
-	
-		output := buf.String()
-	
-		if !strings.Contains(output, "Apple pie recipe") {
-	
-			t.Errorf("Search failed to find 'Apple pie'. Output: %q", output)
-	
-		}
-	
-		if strings.Contains(output, "Banana bread") {
-	
-			t.Errorf("Search found 'Banana' when searching for 'Apple'. Output: %q", output)
-	
+}
+
+func TestSearchIntegration(t *testing.T) {
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			searchFilter = noteFilterFlags{sort: database.SortRelevance}
+
+			addNote(t, ts.store, "Apple pie recipe", []string{"food"}, "low")
+			addNote(t, ts.store, "Banana bread", []string{"food"}, "medium")
+			addNote(t, ts.store, "Buy a new computer", []string{"work"}, "high")
+
+			output := execute(t, ts.store, "search", "Apple")
+			if !strings.Contains(output, "Apple pie recipe") {
+				t.Errorf("Search failed to find 'Apple pie'. Output: %q", output)
+			}
+			if strings.Contains(output, "Banana bread") {
+				t.Errorf("Search found 'Banana' when searching for 'Apple'. Output: %q", output)
+			}
+
+			output = execute(t, ts.store, "search", "Zebra")
+			if !strings.Contains(output, "No notes found matching 'Zebra'") {
+				t.Errorf("Search should have returned no results. Output: %q", output)
+			}
+
+			output = execute(t, ts.store, "list", "--sort", "relevance")
+			listFilter = noteFilterFlags{sort: database.SortCreated}
+			if !strings.Contains(output, "relevance only applies to search") {
+				t.Errorf("list --sort relevance should be refused. Output: %q", output)
+			}
+
+			output = execute(t, ts.store, "search", "tag:work", "-o", "table")
+			outputFormat = ""
+			if !strings.Contains(output, "Buy a new computer") || strings.Contains(output, "Apple") {
+				t.Errorf("A search by fields alone should show the note's title. Output: %q", output)
+			}
+
+			// Text that only looks like a field is searched for as typed
+			addNote(t, ts.store, "Read https://example.com later, ref foo:bar", nil, "low")
+			for _, input := range []string{"https://example.com", "foo:bar"} {
+				output = execute(t, ts.store, "search", input)
+				if !strings.Contains(output, "example") || strings.Contains(output, "Error") {
+					t.Errorf("search %q should find the note. Output: %q", input, output)
+				}
+			}
+		})
+	}
+}
+
+func TestAddWithMultipleTags(t *testing.T) {
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			resetAddFlags(t)
+			execute(t, ts.store, "add", "Sprint review #demo", "-t", "work,meeting", "--tag", "q3")
+
+			notes, err := ts.store.List(context.Background(), database.NoteFilter{Tags: []string{"work", "meeting", "q3", "demo"}, MatchAll: true})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(notes) != 1 {
+				t.Fatalf("Expected the note to carry all four tags, got %d matching notes", len(notes))
+			}
+
+			// list --tag --all narrows to notes with every tag
+			listFilter = noteFilterFlags{sort: database.SortCreated}
+			addNote(t, ts.store, "Unrelated work item", []string{"work"}, "low")
+			output := execute(t, ts.store, "list", "--tag", "work", "--tag", "demo", "--all")
+			if !strings.Contains(output, "Sprint review") || strings.Contains(output, "Unrelated work item") {
+				t.Errorf("list --all returned the wrong notes. Output: %q", output)
+			}
+		})
+	}
+}
+
+func TestListOutputFormats(t *testing.T) {
+	defer func() { outputFormat = "" }()
+
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			addNote(t, ts.store, "First note", []string{"work", "meeting"}, "high")
+			addNote(t, ts.store, "Second\tnote\nwith lines", nil, "low")
+
+			listFilter = noteFilterFlags{sort: database.SortID}
+			outputFormat = ""
+			run := func(args ...string) string {
+				t.Helper()
+				return execute(t, ts.store, args...)
+			}
+
+			var notes []database.Note
+			if err := json.Unmarshal([]byte(run("list", "--sort", "id", "-o", "json")), &notes); err != nil {
+				t.Fatalf("list -o json did not produce valid JSON: %v", err)
+			}
+			if len(notes) != 2 || notes[0].Content != "First note" || strings.Join(notes[0].Tags, ",") != "meeting,work" {
+				t.Errorf("Unexpected JSON notes: %+v", notes)
+			}
+			if notes[1].Tags == nil {
+				t.Errorf("Notes without tags should serialize tags as [], got null")
+			}
+
+			if lines := strings.Split(strings.TrimSpace(run("list", "-o", "ndjson")), "\n"); len(lines) != 2 {
+				t.Errorf("Expected one JSON object per line, got %d lines", len(lines))
+			}
+
+			if got := run("list", "-o", "ids"); got != "1\n2\n" {
+				t.Errorf("list -o ids = %q, want %q", got, "1\n2\n")
+			}
+
+			records, err := csv.NewReader(strings.NewReader(run("list", "-o", "csv"))).ReadAll()
+			if err != nil {
+				t.Fatalf("list -o csv did not produce valid CSV: %v", err)
+			}
+			if len(records) != 3 || records[0][0] != "id" || records[2][1] != "Second\tnote\nwith lines" || records[0][7] != "status" || records[1][9] != "first-note" {
+				t.Errorf("Unexpected CSV records: %q", records)
+			}
+
+			tsv := run("list", "-o", "tsv")
+			if lines := strings.Split(strings.TrimSpace(tsv), "\n"); len(lines) != 3 || !strings.Contains(lines[2], `Second\tnote\nwith lines`) {
+				t.Errorf("TSV should escape tabs and newlines, got %q", tsv)
+			}
+
+			// Not a terminal, so the default is plain text rather than a table
+			outputFormat = ""
+			if plain := run("list"); strings.ContainsAny(plain, "│┌─") || !strings.Contains(plain, "First note") {
+				t.Errorf("Expected plain output when not writing to a terminal, got %q", plain)
+			}
+
+			outputFormat = ""
+			if got := run("search", "nothing-matches-this", "-o", "json"); strings.TrimSpace(got) != "[]" {
+				t.Errorf("Empty search in JSON should print [], got %q", got)
+			}
+		})
+	}
+}
+
+func TestQueryArguments(t *testing.T) {
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			listFilter = noteFilterFlags{sort: database.SortCreated}
+			searchFilter = noteFilterFlags{sort: database.SortRelevance}
+
+			addNote(t, ts.store, "Deploy the API", []string{"work"}, "high")
+			addNote(t, ts.store, "Deploy the blog", []string{"home"}, "low")
+			addNote(t, ts.store, "Water the plants", []string{"home"}, "medium")
+
+			for _, args := range [][]string{{"list", "tag:home -priority:low"}, {"list", "--", "tag:home", "-priority:low"}} {
+				output := execute(t, ts.store, args...)
+				if !strings.Contains(output, "Water the plants") || strings.Contains(output, "Deploy") {
+					t.Errorf("%v returned the wrong notes. Output: %q", args, output)
+				}
+			}
+
+			output := execute(t, ts.store, "search", "deploy", "tag:work")
+			if !strings.Contains(output, "API") || strings.Contains(output, "blog") {
+				t.Errorf("search with fields returned the wrong notes. Output: %q", output)
+			}
+
+			output = execute(t, ts.store, "list", "tag:home", "color:red")
+			if !strings.Contains(output, `Error: invalid query at column 10: unknown field "color"`) {
+				t.Errorf("Expected a parse error. Output: %q", output)
+			}
+		})
+	}
+}
+
+func TestSavedSearchCommands(t *testing.T) {
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			listFilter = noteFilterFlags{sort: database.SortCreated}
+			savedFilter = noteFilterFlags{sort: database.SortCreated}
+			savedForce = false
+
+			addNote(t, ts.store, "Reply to Sam", []string{"inbox"}, "high")
+			addNote(t, ts.store, "Read later", []string{"inbox"}, "low")
+			addNote(t, ts.store, "Ship the release", []string{"work"}, "high")
+
+			if out := execute(t, ts.store, "saved", "add", "inbox", "tag:inbox"); !strings.Contains(out, "✅ Saved search @inbox") {
+				t.Fatalf("saved add failed. Output: %q", out)
+			}
+			execute(t, ts.store, "saved", "add", "urgent", "@inbox priority:high")
+
+			if out := execute(t, ts.store, "saved", "add", "inbox", "tag:other"); !strings.Contains(out, "already exists") {
+				t.Errorf("saved add should refuse to replace without --force. Output: %q", out)
+			}
+			if out := execute(t, ts.store, "saved", "add", "inbox", "@urgent", "--force"); !strings.Contains(out, "refers to itself") {
+				t.Errorf("saved add should refuse a loop. Output: %q", out)
+			}
+			if out := execute(t, ts.store, "saved", "add", "bad", "tag:"); !strings.Contains(out, "needs a value") {
+				t.Errorf("saved add should check the query. Output: %q", out)
+			}
+
+			out := execute(t, ts.store, "saved", "list")
+			if !strings.Contains(out, "@inbox") || !strings.Contains(out, "@inbox priority:high") {
+				t.Errorf("saved list is missing searches. Output: %q", out)
+			}
+
+			out = execute(t, ts.store, "saved", "run", "urgent")
+			if !strings.Contains(out, "Reply to Sam") || strings.Contains(out, "Read later") || strings.Contains(out, "Ship") {
+				t.Errorf("saved run returned the wrong notes. Output: %q", out)
+			}
+
+			out = execute(t, ts.store, "list", "@inbox", "later")
+			if !strings.Contains(out, "Read later") || strings.Contains(out, "Reply to Sam") {
+				t.Errorf("list @inbox later returned the wrong notes. Output: %q", out)
+			}
+
+			execute(t, ts.store, "saved", "delete", "urgent")
+			if out := execute(t, ts.store, "list", "@urgent"); !strings.Contains(out, `no saved search named "urgent"`) {
+				t.Errorf("Expected an error for a deleted search. Output: %q", out)
+			}
+		})
+	}
+}
+
+func TestDateFilters(t *testing.T) {
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			listFilter = noteFilterFlags{sort: database.SortCreated}
+			outputFormat = ""
+
+			today := dates.StartOfDay(time.Now())
+			for _, n := range []database.NewNote{
+				{Content: "Old idea", CreatedAt: today.AddDate(0, 0, -30)},
+				{Content: "Yesterday's standup", Tags: []string{"work"}, CreatedAt: today.AddDate(0, 0, -1).Add(9 * time.Hour)},
+				{Content: "Morning coffee", CreatedAt: today.Add(time.Minute)},
+			} {
+				if _, err := ts.store.Add(context.Background(), n); err != nil {
+					t.Fatalf("Add() error = %v", err)
+				}
+			}
+
+			tests := []struct {
+				args    []string
+				want    []string
+				notWant []string
+			}{
+				{[]string{"list", "--on", "yesterday"}, []string{"standup"}, []string{"Old idea", "coffee"}},
+				{[]string{"list", "--since", "3d"}, []string{"standup", "coffee"}, []string{"Old idea"}},
+				{[]string{"list", "--until", "yesterday"}, []string{"Old idea", "standup"}, []string{"coffee"}},
+				{[]string{"today", "--list"}, []string{"Morning coffee"}, []string{"standup", "Old idea"}},
+				{[]string{"week", "tag:work"}, []string{today.AddDate(0, 0, -1).Format("Monday"), "#2  09:00  Yesterday's standup  [work]"}, []string{"coffee"}},
+			}
+			for _, tt := range tests {
+				listFilter = noteFilterFlags{sort: database.SortCreated}
+				output := execute(t, ts.store, tt.args...)
+				for _, s := range tt.want {
+					if !strings.Contains(output, s) {
+						t.Errorf("%v output is missing %q. Output: %q", tt.args, s, output)
+					}
+				}
+				for _, s := range tt.notWant {
+					if strings.Contains(output, s) {
+						t.Errorf("%v output should not contain %q. Output: %q", tt.args, s, output)
+					}
+				}
+			}
+
+			output := execute(t, ts.store, "list", "--since", "someday")
+			if !strings.Contains(output, `Error: --since: invalid date "someday"`) {
+				t.Errorf("Expected an invalid date error. Output: %q", output)
+			}
+		})
+	}
+}
+
+func TestDueDatesAndAgenda(t *testing.T) {
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			resetAddFlags(t)
+			dueClear, remindCheck, agendaDays = false, false, 7
+			outputFormat = ""
+
+			output := execute(t, ts.store, "add", "Send the invoice", "--due", "in 2 days")
+			if !strings.Contains(output, "⏰ Due") {
+				t.Errorf("Expected the due date to be confirmed. Output: %q", output)
+			}
+			due = ""
+			output = execute(t, ts.store, "add", "Pay rent", "--due", "whenever")
+			if !strings.Contains(output, `Error: --due: invalid time "whenever"`) {
+				t.Errorf("Expected an invalid time error. Output: %q", output)
+			}
+			due = ""
+			addNote(t, ts.store, "Renew passport", []string{"admin"}, "high")
+			addNote(t, ts.store, "No deadline", nil, "low")
+
+			output = execute(t, ts.store, "due", "2", "in 5m")
+			if !strings.Contains(output, "✅ Note 2 is due") {
+				t.Errorf("Expected the due date to be set. Output: %q", output)
+			}
+			past := time.Now().Add(-time.Hour)
+			if err := ts.store.SetDue(context.Background(), 2, &past); err != nil {
+				t.Fatalf("SetDue() error = %v", err)
+			}
+
+			output = execute(t, ts.store, "agenda")
+			overdue, upcoming := strings.Index(output, "Overdue"), strings.Index(output, "Upcoming")
+			if overdue < 0 || upcoming < overdue || !strings.Contains(output, "#2") || strings.Contains(output, "No deadline") {
+				t.Errorf("Expected an overdue and an upcoming section. Output: %q", output)
+			}
+			if strings.Index(output, "Renew passport") > upcoming || strings.Index(output, "Send the invoice") < upcoming {
+				t.Errorf("Notes are in the wrong sections. Output: %q", output)
+			}
+
+			output = execute(t, ts.store, "remind")
+			if !strings.Contains(output, "Renew passport") || strings.Contains(output, "invoice") {
+				t.Errorf("Expected the passport reminder only. Output: %q", output)
+			}
+			remindCheck = true
+			output = execute(t, ts.store, "remind", "--check")
+			if !strings.Contains(output, "⏰ #2") {
+				t.Errorf("Expected the reminder to be sent. Output: %q", output)
+			}
+			if output := execute(t, ts.store, "remind", "--check"); output != "" {
+				t.Errorf("Expected no output once notified. Output: %q", output)
+			}
+			remindCheck = false
+
+			dueClear = true
+			execute(t, ts.store, "due", "2", "--clear")
+			dueClear = false
+			if output := execute(t, ts.store, "agenda", "tag:admin"); !strings.Contains(output, "Nothing due") {
+				t.Errorf("Expected nothing due after clearing. Output: %q", output)
+			}
+		})
+	}
+}
+
+func TestTasks(t *testing.T) {
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			resetAddFlags(t)
+			todoFilter = noteFilterFlags{sort: database.SortPriority}
+			listFilter = noteFilterFlags{sort: database.SortCreated}
+			outputFormat = ""
+
+			execute(t, ts.store, "add", `Pack\n- [x] passport\n- [ ] charger\n- [ ] socks`, "--status", "open")
+			status = ""
+			addNote(t, ts.store, "Just a thought", nil, "low")
+			output := execute(t, ts.store, "add", "Plan trip", "--status", "someday")
+			if !strings.Contains(output, `Error: --status: invalid status "someday"`) {
+				t.Errorf("Expected an invalid status error. Output: %q", output)
+			}
+			status = ""
+
+			output = execute(t, ts.store, "todo")
+			if !strings.Contains(output, "#1  [ ]  Pack  1/3") || strings.Contains(output, "thought") {
+				t.Errorf("Expected the open task with its progress. Output: %q", output)
+			}
+
+			output = execute(t, ts.store, "status", "2", "doing")
+			if !strings.Contains(output, "✅ Note 2 is doing") {
+				t.Errorf("Expected the status to change. Output: %q", output)
+			}
+			output = execute(t, ts.store, "done", "1", "x")
+			if !strings.Contains(output, "✅ Note 1 done") || !strings.Contains(output, `Error: no note matches "x"`) {
+				t.Errorf("Expected note 1 to be done. Output: %q", output)
+			}
+
+			output = execute(t, ts.store, "todo")
+			if !strings.Contains(output, "#2  [~]  Just a thought") || strings.Contains(output, "Pack") {
+				t.Errorf("Expected only the task in progress. Output: %q", output)
+			}
+			output = execute(t, ts.store, "list", "status:done")
+			if !strings.Contains(output, "Pack") || strings.Contains(output, "thought") {
+				t.Errorf("Expected status:done to find the finished task. Output: %q", output)
+			}
+			output = execute(t, ts.store, "todo", "-o", "csv")
+			outputFormat = ""
+			if !strings.Contains(output, ",due_at,status") || !strings.Contains(output, ",doing") {
+				t.Errorf("Expected a status column in CSV. Output: %q", output)
+			}
+
+			execute(t, ts.store, "status", "2", "none")
+			if output := execute(t, ts.store, "todo"); !strings.Contains(output, "Nothing to do.") {
+				t.Errorf("Expected no open tasks. Output: %q", output)
+			}
+		})
+	}
+}
+
+func TestRecurringCommands(t *testing.T) {
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			resetAddFlags(t)
+			outputFormat = "plain"
+			defer func() { outputFormat = "" }()
+
+			output := execute(t, ts.store, "add", "Standup", "--every", "weekday")
+			if !strings.Contains(output, "Error: --every needs a first due date") {
+				t.Errorf("Expected --every to need --due. Output: %q", output)
+			}
+			output = execute(t, ts.store, "add", "Standup", "--due", "tomorrow 9:30", "--every", "weekday")
+			if !strings.Contains(output, "🔁 Repeats every weekday") {
+				t.Errorf("Expected the task to repeat. Output: %q", output)
+			}
+			due, every = "", ""
+			addNote(t, ts.store, "Pay rent", nil, "high")
+
+			output = execute(t, ts.store, "recurring", "set", "2", "month")
+			if !strings.Contains(output, "Error: Note 2 has no due date") {
+				t.Errorf("Expected recurring set to need a due date. Output: %q", output)
+			}
+			execute(t, ts.store, "due", "2", "next month")
+			output = execute(t, ts.store, "recurring", "set", "2", "FREQ=MONTHLY")
+			if !strings.Contains(output, "🔁 Note 2 repeats every month") {
+				t.Errorf("Expected the rent to repeat. Output: %q", output)
+			}
+
+			output = execute(t, ts.store, "recurring", "list")
+			if !strings.Contains(output, "Standup") || !strings.Contains(output, "Pay rent") {
+				t.Errorf("Expected both series. Output: %q", output)
+			}
+
+			output = execute(t, ts.store, "done", "1")
+			if !strings.Contains(output, "🔁 Next occurrence: note 3, due") {
+				t.Errorf("Expected the next standup. Output: %q", output)
+			}
+
+			output = execute(t, ts.store, "recurring", "cancel", "2", "1")
+			if !strings.Contains(output, "✅ Note 2 no longer repeats") || !strings.Contains(output, "Error: Note 1 isn't a recurring task") {
+				t.Errorf("Expected the rent series to be cancelled. Output: %q", output)
+			}
+			output = execute(t, ts.store, "recurring", "list")
+			if !strings.Contains(output, "Standup") || strings.Contains(output, "Pay rent") {
+				t.Errorf("Expected only the standup series. Output: %q", output)
+			}
+		})
+	}
+}
+
+func TestJournal(t *testing.T) {
+	// An editor that adds a line to whatever it opens
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\necho 'Wrote some code' >> \"$1\"\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write the test editor: %v", err)
+	}
+	t.Setenv("EDITOR", editor)
+
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			resetAddFlags(t)
+			todayList, journalDate, journalMonth = false, "", ""
+			outputFormat = "plain"
+			defer func() { outputFormat = "" }()
+			today := time.Now().Format("Monday, 2 January 2006")
+
+			output := execute(t, ts.store, "add", "--journal", "Shipped the release")
+			if !strings.Contains(output, "✅ Added to the journal for "+today) {
+				t.Errorf("Expected the bullet to be added. Output: %q", output)
+			}
+			execute(t, ts.store, "add", "--journal", "Lunch with the team")
+			journal = false
+
+			output = execute(t, ts.store, "today")
+			if !strings.Contains(output, "✅ Journal for "+today+" saved!") {
+				t.Errorf("Expected today's entry to be saved. Output: %q", output)
+			}
+			entry, err := ts.store.JournalEntry(context.Background(), time.Now().Format("2006-01-02"))
+			if err != nil || entry == nil {
+				t.Fatalf("JournalEntry() = %v, %v", entry, err)
+			}
+			lines := strings.Split(entry.Content, "\n")
+			if len(lines) < 5 || lines[0] != "# "+today || !strings.HasSuffix(lines[2], "Lunch with the team") ||
+				!strings.HasSuffix(lines[3], "Shipped the release") || lines[4] != "Wrote some code" {
+				t.Errorf("Unexpected journal entry: %q", entry.Content)
+			}
+			if !slices.Contains(entry.Tags, "journal") {
+				t.Errorf("Expected the entry to be tagged journal, got %v", entry.Tags)
+			}
+
+			output = execute(t, ts.store, "journal", "--date", "2026-10-01")
+			if !strings.Contains(output, "✅ Journal for Thursday, 1 October 2026 saved!") {
+				t.Errorf("Expected a past entry to be saved. Output: %q", output)
+			}
+			journalDate = ""
+			if past, _ := ts.store.JournalEntry(context.Background(), "2026-10-01"); past == nil || past.CreatedAt.Format("2006-01-02") != "2026-10-01" {
+				t.Errorf("Expected the past entry to be dated that day, got %+v", past)
+			}
+
+			output = execute(t, ts.store, "journal", "list", "--month", "2026-10")
+			for _, s := range []string{"October 2026", " Mo  Tu  We  Th  Fr  Sa  Su", "             [1]  2   3   4", "  01  #2  Wrote some code"} {
+				if !strings.Contains(output, s) {
+					t.Errorf("journal list output is missing %q. Output: %q", s, output)
+				}
+			}
+			journalMonth = ""
+
+			output = execute(t, ts.store, "today", "tag:work")
+			if !strings.Contains(output, "Error: A query needs --list") {
+				t.Errorf("Expected a query to need --list. Output: %q", output)
+			}
+		})
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	dir := t.TempDir()
+	viper.Set("templates_dir", dir)
+	defer viper.Set("templates_dir", "")
+	for name, text := range map[string]string{
+		"meeting.md": "# {{.Title}}\n\nDate: {{.Date}}\n",
+		"bug.md":     "# Bug: {{.Title}}\n\n## Steps\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
 		}
-	
-	
-	
-		// 3. Test searching for something that doesn't exist
-	
-		buf.Reset()
-	
-		rootCmd.SetArgs([]string{"search", "Zebra"})
-	
-		rootCmd.Execute()
-	
-		
-	
-		output = buf.String()
-	
-		if !strings.Contains(output, "No notes found matching 'Zebra'") {
-	
-			t.Errorf("Search should have returned no results. Output: %q", output)
-	
+	}
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\necho 'Filled in' >> \"$1\"\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write the test editor: %v", err)
+	}
+	t.Setenv("EDITOR", editor)
+
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			resetAddFlags(t)
+			newTemplate = ""
+			defer rootCmd.SetIn(nil)
+
+			output := execute(t, ts.store, "add", "--template", "meeting", "Sprint planning", "--tag", "work")
+			if !strings.Contains(output, "✅ Note saved: Sprint planning") {
+				t.Errorf("Expected the meeting note to be saved. Output: %q", output)
+			}
+			tags = nil
+			n, err := ts.store.Get(context.Background(), 1)
+			want := "# Sprint planning\n\nDate: " + time.Now().Format("2006-01-02") + "\nFilled in\n"
+			if err != nil || n == nil || n.Content != want || !slices.Contains(n.Tags, "work") {
+				t.Fatalf("Get(1) = %+v, %v, want content %q tagged work", n, err, want)
+			}
+			noteTmpl = ""
+
+			rootCmd.SetIn(strings.NewReader("Login fails\n"))
+			output = execute(t, ts.store, "new", "--template", "bug")
+			if !strings.Contains(output, "Title: ") || !strings.Contains(output, "✅ Note 2 saved: Bug: Login fails") {
+				t.Errorf("Expected a prompted title. Output: %q", output)
+			}
+
+			output = execute(t, ts.store, "new", "--template", "retro")
+			if !strings.Contains(output, `Error: no template named "retro"`) {
+				t.Errorf("Expected a missing template error. Output: %q", output)
+			}
+			newTemplate = ""
+
+			output = execute(t, ts.store, "templates")
+			if !strings.Contains(output, "  bug\n  meeting\n") {
+				t.Errorf("Expected both templates listed. Output: %q", output)
+			}
+		})
+	}
+}
+
+func TestLinkCommands(t *testing.T) {
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			outputFormat = "plain"
+			defer func() { outputFormat = "" }()
+
+			addNote(t, ts.store, "# Sprint planning\n\nGoals", nil, "low")
+			addNote(t, ts.store, "Agenda: [[Sprint planning]], [[Retro]]", []string{"work"}, "low")
+
+			output := execute(t, ts.store, "links", "2")
+			for _, s := range []string{"Links from note 2:", "→ #1  Sprint planning", "✗ [[Retro]] (broken)"} {
+				if !strings.Contains(output, s) {
+					t.Errorf("links output is missing %q. Output: %q", s, output)
+				}
+			}
+
+			output = execute(t, ts.store, "backlinks", "1")
+			if !strings.Contains(output, "Notes linking to note 1:") || !strings.Contains(output, "#2") || !strings.Contains(output, "[work]") {
+				t.Errorf("Expected note 2 to link to note 1. Output: %q", output)
+			}
+			output = execute(t, ts.store, "backlinks", "2")
+			if !strings.Contains(output, "No notes link to note 2.") {
+				t.Errorf("Expected no backlinks. Output: %q", output)
+			}
+
+			outputFormat = "json"
+			var notes []database.Note
+			if err := json.Unmarshal([]byte(execute(t, ts.store, "links", "2")), &notes); err != nil || len(notes) != 1 || notes[0].ID != 1 {
+				t.Errorf("links --format json = %+v, %v, want note 1", notes, err)
+			}
+
+			output = execute(t, ts.store, "links", "9")
+			if !strings.Contains(output, "Error: no note with ID 9") {
+				t.Errorf("Expected a missing note error. Output: %q", output)
+			}
+		})
+	}
+}
+
+func TestNoteReferences(t *testing.T) {
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			resetAddFlags(t)
+			outputFormat = "plain"
+			defer func() { outputFormat = "" }()
+
+			addNote(t, ts.store, "# Sprint planning\n\nGoals", nil, "low")
+			addNote(t, ts.store, "Sprint retro", nil, "low")
+			output := execute(t, ts.store, "add", "Budget, hiring", "--title", "Board meeting")
+			if !strings.Contains(output, "🔖 Board meeting (slug board-meeting)") {
+				t.Errorf("Expected the title and slug. Output: %q", output)
+			}
+
+			// An exact slug, an unambiguous title prefix, then an ambiguous one
+			output = execute(t, ts.store, "links", "board-meeting")
+			if !strings.Contains(output, "Note 3 has no links.") {
+				t.Errorf("Expected the slug to find note 3. Output: %q", output)
+			}
+			output = execute(t, ts.store, "done", "spr plan")
+			if !strings.Contains(output, "✅ Note 1 done") {
+				t.Errorf("Expected the title prefix to find note 1. Output: %q", output)
+			}
+			output = execute(t, ts.store, "delete", "sprint")
+			if !strings.Contains(output, `Error: "sprint" could be sprint-planning (#1), sprint-retro (#2)`) {
+				t.Errorf("Expected an ambiguous reference error. Output: %q", output)
+			}
+
+			output = execute(t, ts.store, "title", "sprint-retro", "Retro", "notes")
+			if !strings.Contains(output, `✅ Note 2 is now titled "Retro notes" (slug retro-notes)`) {
+				t.Errorf("Expected the new title. Output: %q", output)
+			}
+			output = execute(t, ts.store, "title", "retro")
+			if !strings.Contains(output, "Note 2: Retro notes") || !strings.Contains(output, "Slug: retro-notes") {
+				t.Errorf("Expected the title to be shown. Output: %q", output)
+			}
+			output = execute(t, ts.store, "title", "retro-notes", "--clear")
+			clearTitle = false
+			if !strings.Contains(output, "✅ Title of note 2 cleared (slug sprint-retro)") {
+				t.Errorf("Expected the title to be cleared. Output: %q", output)
+			}
+
+			output = execute(t, ts.store, "delete", "sprint-retro", "nothing-like-it")
+			if !strings.Contains(output, "Note 2 moved to trash") || !strings.Contains(output, `Error: no note matches "nothing-like-it"`) {
+				t.Errorf("Expected note 2 to be trashed. Output: %q", output)
+			}
+			output = execute(t, ts.store, "restore", "sprint-retro")
+			if !strings.Contains(output, "✅ Note 2 restored") {
+				t.Errorf("Expected note 2 to be restored by its slug. Output: %q", output)
+			}
+		})
+	}
+}
+
+func TestTagTrashAndImportCommands(t *testing.T) {
+	for _, ts := range testStores(t) {
+		t.Run(ts.name, func(t *testing.T) {
+			defer func() { deleteTaggedNotes, emptyTrashYes, importDryRun = false, false, false }()
+
+			addNote(t, ts.store, "Standup", []string{"work/daily"}, "low")
+			addNote(t, ts.store, "Retro", []string{"meetings"}, "low")
+			addNote(t, ts.store, "Groceries", []string{"home"}, "low")
+
+			output := execute(t, ts.store, "tags", "rename", "work", "job")
+			if !strings.Contains(output, "✅ Tag work renamed to job") {
+				t.Errorf("Expected the tag to be renamed. Output: %q", output)
+			}
+			output = execute(t, ts.store, "tags", "merge", "meetings", "into", "job")
+			if !strings.Contains(output, "✅ Merged meetings into job") {
+				t.Errorf("Expected the tags to be merged. Output: %q", output)
+			}
+			output = execute(t, ts.store, "tags", "list")
+			if !strings.Contains(output, "job/daily") || strings.Contains(output, "work") || strings.Contains(output, "meetings") {
+				t.Errorf("Expected only the renamed and merged tags. Output: %q", output)
+			}
+
+			output = execute(t, ts.store, "tags", "delete", "home", "--notes")
+			if !strings.Contains(output, "✅ Tag home deleted along with 1 note(s)") {
+				t.Errorf("Expected the tagged note to be deleted. Output: %q", output)
+			}
+			output = execute(t, ts.store, "trash", "list")
+			if !strings.Contains(output, "Groceries") {
+				t.Errorf("Expected the deleted note in the trash. Output: %q", output)
+			}
+			output = execute(t, ts.store, "trash", "empty", "--yes")
+			if !strings.Contains(output, "✅ Permanently deleted 1 note(s)") {
+				t.Errorf("Expected the trash to be emptied. Output: %q", output)
+			}
+
+			path := filepath.Join(t.TempDir(), "notes.md")
+			if err := os.WriteFile(path, []byte("Standup\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			output = execute(t, ts.store, "import", path, "--dry-run")
+			if !strings.Contains(output, "would import 0 note(s)") || !strings.Contains(output, "= Standup (duplicate)") {
+				t.Errorf("Expected the note to be a duplicate. Output: %q", output)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	for in, want := range map[string]string{
+		"short":         "short",
+		"exactly ten!":  "exactly...",
+		"café crème ☕☕": "café cr...",
+	} {
+		if got := truncate(in, 10); got != want {
+			t.Errorf("truncate(%q, 10) = %q, want %q", in, got, want)
 		}
-	
 	}
-	
-	
\ No newline at end of file
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/dates"
+	"github.com/spf13/cobra"
+)
+
+var (
+	agendaDays int
+	dueClear   bool
+)
+
+var agendaCmd = &cobra.Command{
+	Use:   "agenda [query]",
+	Short: "Show overdue notes and what's due today and coming up",
+	Long: `Show the notes with a due date in three sections: overdue, due today, and
+upcoming in the next few days. A query narrows them down, e.g.
+'jotcli agenda tag:work'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, err := resolveOutputFormat(cmd)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		f := noteFilterFlags{sort: database.SortDue}
+		filter, err := f.queryFilter(cmd, args)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		now := time.Now()
+		tomorrow := dates.StartOfDay(now).AddDate(0, 0, 1)
+		filter.DueBefore = tomorrow.AddDate(0, 0, agendaDays)
+		filter.Statuses = []string{"", database.StatusOpen, database.StatusDoing}
+
+		notes, err := noteStore(cmd).List(cmd.Context(), filter)
+		if err != nil {
+			cmd.Printf("Error retrieving notes: %v\n", err)
+			return
+		}
+
+		if !isHumanFormat(format) {
+			if err := writeNotes(cmd.OutOrStdout(), format, notes); err != nil {
+				cmd.Printf("Error writing output: %v\n", err)
+			}
+			return
+		}
+		if len(notes) == 0 {
+			cmd.Printf("Nothing due in the next %d days.\n", agendaDays)
+			return
+		}
+
+		var overdue, today, upcoming []database.Note
+		for _, n := range notes {
+			switch {
+			case n.DueAt.Before(now):
+				overdue = append(overdue, n)
+			case n.DueAt.Before(tomorrow):
+				today = append(today, n)
+			default:
+				upcoming = append(upcoming, n)
+			}
+		}
+
+		printed := false
+		section := func(title string, notes []database.Note, when func(time.Time) string) {
+			if len(notes) == 0 {
+				return
+			}
+			if printed {
+				cmd.Println()
+			}
+			cmd.Println(title)
+			for _, n := range notes {
+				cmd.Println(noteLine(n, when(*n.DueAt)))
+			}
+			printed = true
+		}
+		relative := func(t time.Time) string { return formatDue(t, now) }
+		section("Overdue", overdue, relative)
+		section("Today", today, func(t time.Time) string { return t.Format("15:04") })
+		section("Upcoming", upcoming, relative)
+	},
+}
+
+var dueCmd = &cobra.Command{
+	Use:   "due <note> [when]",
+	Short: "Set or clear the due date of a note",
+	Example: `  jotcli due 12 "friday 5pm"
+  jotcli due 12 "in 2h"
+  jotcli due 12 --clear`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := resolveNoteID(cmd, args[0])
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		when := strings.Join(args[1:], " ")
+		if dueClear == (when != "") {
+			cmd.Println("Error: Give either a due date or --clear")
+			return
+		}
+
+		var due *time.Time
+		if !dueClear {
+			t, err := dates.ParseTime(when, time.Now())
+			if err != nil {
+				cmd.Printf("Error: %v\n", err)
+				return
+			}
+			due = &t
+		}
+
+		if err := noteStore(cmd).SetDue(cmd.Context(), id, due); err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		if due == nil {
+			cmd.Printf("✅ Due date of note %d cleared\n", id)
+			return
+		}
+		cmd.Printf("✅ Note %d is due %s\n", id, formatDue(*due, time.Now()))
+	},
+}
+
+// formatDue shows a due date briefly, leaving out the year when it is the
+// current one.
+func formatDue(t, now time.Time) string {
+	if t.Year() != now.Year() {
+		return t.Format("Mon 2 Jan 2006 15:04")
+	}
+	return t.Format("Mon 2 Jan 15:04")
+}
+
+func init() {
+	agendaCmd.Flags().IntVarP(&agendaDays, "days", "d", 7, "How many days ahead to show after today")
+	dueCmd.Flags().BoolVar(&dueClear, "clear", false, "Remove the due date")
+	rootCmd.AddCommand(agendaCmd, dueCmd)
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/config"
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/spf13/cobra"
+)
+
+var backupKeep int
+
+var backupCmd = &cobra.Command{
+	Use:   "backup [dest]",
+	Short: "Save a consistent snapshot of the database",
+	Long: `Save a snapshot of the database that is safe to take while jot is in use.
+
+Without a destination, or when it is a directory, the backup gets a
+timestamped name (jot-YYYYMMDD-HHMMSS.db) and older backups beyond --keep
+are deleted. The default directory is backup_dir in ~/.jotcli.yaml
+(~/.jot-backups) and the default count is backup_keep (10).
+
+Any other destination is written as-is, without rotation. Bring a backup
+back with "jotcli restore <file>".`,
+	Example: `  jotcli backup
+  jotcli backup ~/Dropbox/jot-backups/
+  jotcli backup before-cleanup.db`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db := sqlDB(cmd)
+		if db == nil {
+			cmd.Println("Error: There is no database to back up")
+			return
+		}
+
+		dest := config.GetBackupDir()
+		if len(args) == 1 {
+			dest = args[0]
+		}
+
+		// A directory gets timestamped, rotated backups; a file name is used as-is
+		rotate := len(args) == 0 || strings.HasSuffix(dest, string(os.PathSeparator))
+		if info, err := os.Stat(dest); err == nil && info.IsDir() {
+			rotate = true
+		}
+		path := dest
+		if rotate {
+			path = filepath.Join(dest, database.BackupName(time.Now()))
+		}
+
+		if err := database.Backup(db, path); err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		cmd.Printf("✅ Backed up to %s\n", path)
+
+		if !rotate {
+			return
+		}
+		keep := backupKeep
+		if !cmd.Flags().Changed("keep") {
+			keep = config.GetBackupKeep()
+		}
+		removed, err := database.RotateBackups(dest, keep)
+		if err != nil {
+			cmd.Printf("Error removing old backups: %v\n", err)
+			return
+		}
+		if len(removed) > 0 {
+			cmd.Printf("Removed %d old backup(s), keeping the newest %d\n", len(removed), keep)
+		}
+	},
+}
+
+// isBackupFile reports whether restore was given a backup file rather than
+// note IDs.
+func isBackupFile(args []string) bool {
+	if len(args) != 1 {
+		return false
+	}
+	if _, err := strconv.Atoi(args[0]); err == nil {
+		return false
+	}
+	info, err := os.Stat(args[0])
+	return err == nil && info.Mode().IsRegular()
+}
+
+// restoreBackup replaces the database with a backup. It runs without the
+// database being opened first, so it also works when the current one is
+// too damaged to open.
+func restoreBackup(cmd *cobra.Command, path string) {
+	version, err := database.CheckBackup(path)
+	if err != nil {
+		cmd.Printf("Error: %v\n", err)
+		return
+	}
+
+	dbPath := config.GetDBPath()
+	if !restoreYes {
+		cmd.Printf("Replace the database at %s with %s? [y/N] ", dbPath, path)
+		answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			cmd.Println("Aborted.")
+			return
+		}
+	}
+
+	// Keep what is about to be replaced, as a proper snapshot if it still opens
+	saved := ""
+	if _, err := os.Stat(dbPath); err == nil {
+		saved = filepath.Join(config.GetBackupDir(), strings.TrimSuffix(database.BackupName(time.Now()), ".db")+"-pre-restore.db")
+		db, err := database.OpenDB(dbPath)
+		if err == nil {
+			err = database.Backup(db, saved)
+			db.Close()
+		}
+		if err != nil {
+			if err := database.CopyDatabaseFile(dbPath, saved); err != nil {
+				cmd.Printf("Error: could not save the current database first: %v\n", err)
+				return
+			}
+		}
+	}
+
+	if err := database.RestoreBackup(path, dbPath); err != nil {
+		cmd.Printf("Error: %v\n", err)
+		return
+	}
+	db, err := database.OpenDB(dbPath)
+	if err == nil {
+		err = database.Migrate(db)
+		db.Close()
+	}
+	if err != nil {
+		cmd.Printf("Error opening the restored database: %v\n", err)
+		return
+	}
+
+	cmd.Printf("✅ Restored %s (schema version %d)\n", path, version)
+	if saved != "" {
+		cmd.Printf("The previous database was saved to %s\n", saved)
+	}
+}
+
+func init() {
+	backupCmd.Flags().IntVar(&backupKeep, "keep", 10, "Number of timestamped backups to keep (0 keeps all)")
+	rootCmd.AddCommand(backupCmd)
+}
@@ -14,6 +14,14 @@ var configCmd = &cobra.Command{
 		fmt.Println("--- jotcli Configuration ---")
 		fmt.Printf("Database Path: %s\n", config.GetDBPath())
 		fmt.Printf("Editor:        %s\n", config.GetEditor())
+		fmt.Printf("Trash Kept:    %d days\n", int(config.GetTrashRetention().Hours()/24))
+		fmt.Printf("Backups:       %s (keeping %d)\n", config.GetBackupDir(), config.GetBackupKeep())
+		remind := config.GetRemindCommand()
+		if remind == "" {
+			remind = "(none, reminders are printed)"
+		}
+		fmt.Printf("Remind With:   %s\n", remind)
+		fmt.Printf("Templates:     %s\n", config.GetTemplatesDir())
 		fmt.Println("\nYou can override these by creating a ~/.jotcli.yaml file")
 		fmt.Println("or by setting JOT_DATABASE and EDITOR environment variables.")
 	},
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:     "delete [note...]",
+	Aliases: []string{"rm"},
+	Short:   "Move notes to the trash",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, arg := range args {
+			id, err := resolveNoteID(cmd, arg)
+			if err != nil {
+				cmd.Printf("Error: %v\n", err)
+				continue
+			}
+
+			if err := noteStore(cmd).Delete(cmd.Context(), id); err != nil {
+				cmd.Printf("Error: %v\n", err)
+				continue
+			}
+
+			cmd.Printf("🗑  Note %d moved to trash (undo with 'jotcli restore %d')\n", id, id)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/diff"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [note] [revA] [revB]",
+	Short: "Show what changed between revisions of a note",
+	Long: `Show a unified diff between two revisions of a note.
+
+With no revisions, compares the previous revision with the current one.
+With one revision, compares it with the current one.`,
+	Args: cobra.RangeArgs(1, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := resolveNoteID(cmd, args[0])
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		nums := make([]int, len(args))
+		for i, arg := range args[1:] {
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				cmd.Printf("Error: Invalid number %q\n", arg)
+				return
+			}
+			nums[i+1] = n
+		}
+
+		revisions, err := noteStore(cmd).Revisions(cmd.Context(), id)
+		if err != nil {
+			cmd.Printf("Error retrieving history: %v\n", err)
+			return
+		}
+		if len(revisions) == 0 {
+			cmd.Printf("No history found for note %d\n", id)
+			return
+		}
+
+		latest := revisions[len(revisions)-1].Rev
+		from, to := latest-1, latest
+		switch len(nums) {
+		case 2:
+			from = nums[1]
+		case 3:
+			from, to = nums[1], nums[2]
+		}
+		if from < 1 {
+			cmd.Printf("Note %d has only one revision\n", id)
+			return
+		}
+
+		a := findRevision(revisions, from)
+		if a == nil {
+			cmd.Printf("Error: Note %d has no revision %d\n", id, from)
+			return
+		}
+		b := findRevision(revisions, to)
+		if b == nil {
+			cmd.Printf("Error: Note %d has no revision %d\n", id, to)
+			return
+		}
+
+		out := diff.Unified(a.Content, b.Content,
+			fmt.Sprintf("note %d rev %d\t%s", id, a.Rev, a.CreatedAt.Format("2006-01-02 15:04")),
+			fmt.Sprintf("note %d rev %d\t%s", id, b.Rev, b.CreatedAt.Format("2006-01-02 15:04")),
+			3)
+		if out == "" {
+			cmd.Printf("Revisions %d and %d are identical\n", from, to)
+			return
+		}
+
+		addStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+		delStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+		hunkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+		headerStyle := lipgloss.NewStyle().Bold(true)
+
+		for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				line = headerStyle.Render(line)
+			case strings.HasPrefix(line, "@@"):
+				line = hunkStyle.Render(line)
+			case strings.HasPrefix(line, "+"):
+				line = addStyle.Render(line)
+			case strings.HasPrefix(line, "-"):
+				line = delStyle.Render(line)
+			}
+			cmd.Println(line)
+		}
+	},
+}
+
+// findRevision returns revision rev from a note's history, or nil.
+func findRevision(revisions []database.Revision, rev int) *database.Revision {
+	for i := range revisions {
+		if revisions[i].Rev == rev {
+			return &revisions[i]
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
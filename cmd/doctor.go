@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/spf13/cobra"
+)
+
+var doctorFix bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the database for corruption and inconsistencies",
+	Long: `Run SQLite's integrity check and look for inconsistencies jot can't see
+in normal use: tags and revisions left behind by missing notes, notes without
+history, a full-text index that is out of step with the notes, and [[links]]
+that don't lead to a note.
+
+With --fix, problems that can be repaired safely are repaired. Damage found
+by the integrity check can't be; restore a backup instead.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		db := sqlDB(cmd)
+		if db == nil {
+			cmd.Println("Error: There is no database to check")
+			return
+		}
+
+		if doctorFix {
+			repaired, err := database.Repair(db)
+			if err != nil {
+				cmd.Printf("Error: %v\n", err)
+				return
+			}
+			if len(repaired) > 0 {
+				cmd.Printf("🔧 Repaired: %s\n", strings.Join(repaired, ", "))
+			}
+		}
+
+		results, err := database.Diagnose(db)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		failed, fixable := 0, false
+		for _, r := range results {
+			if len(r.Problems) == 0 {
+				cmd.Printf("✅ %s\n", r.Name)
+				continue
+			}
+			failed++
+			fixable = fixable || r.Fixable
+			cmd.Printf("❌ %s\n", r.Name)
+			for _, p := range r.Problems {
+				cmd.Printf("   %s\n", p)
+			}
+		}
+
+		switch {
+		case failed == 0:
+			cmd.Println("No problems found.")
+		case fixable && !doctorFix:
+			cmd.Printf("%d check(s) failed. Run \"jotcli doctor --fix\" to repair what can be repaired.\n", failed)
+		default:
+			cmd.Printf("%d check(s) failed.\n", failed)
+		}
+	},
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Repair the problems that can be fixed safely")
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -4,25 +4,24 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"strconv"
 
 	"github.com/flyme2mars/jotcli/internal/config"
-	"github.com/flyme2mars/jotcli/internal/database"
 	"github.com/spf13/cobra"
 )
 
 var editCmd = &cobra.Command{
-	Use:   "edit [id]",
+	Use:   "edit [note]",
 	Short: "Edit a note in your default editor",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		id, err := strconv.Atoi(args[0])
+		id, err := resolveNoteID(cmd, args[0])
 		if err != nil {
-			fmt.Println("Error: Invalid note ID")
+			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
-		note, err := database.GetNoteByID(id)
+		store := noteStore(cmd)
+		note, err := store.Get(cmd.Context(), id)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			return
@@ -32,44 +31,14 @@ var editCmd = &cobra.Command{
 			return
 		}
 
-		// Create a temporary file
-		tmpFile, err := os.CreateTemp("", "jot-*.md")
+		updatedContent, err := editContent(note.Content)
 		if err != nil {
-			fmt.Printf("Error: Could not create temp file: %v\n", err)
-			return
-		}
-		defer os.Remove(tmpFile.Name())
-
-		// Write the current content to the temp file
-		if _, err := tmpFile.WriteString(note.Content); err != nil {
-			fmt.Printf("Error: Could not write to temp file: %v\n", err)
-			return
-		}
-		tmpFile.Close()
-
-		// Determine which editor to use
-		editor := config.GetEditor()
-
-		// Open the editor
-		editProcess := exec.Command(editor, tmpFile.Name())
-		editProcess.Stdin = os.Stdin
-		editProcess.Stdout = os.Stdout
-		editProcess.Stderr = os.Stderr
-
-		if err := editProcess.Run(); err != nil {
-			fmt.Printf("Error: Editor failed: %v\n", err)
-			return
-		}
-
-		// Read the updated content
-		updatedContent, err := os.ReadFile(tmpFile.Name())
-		if err != nil {
-			fmt.Printf("Error: Could not read updated file: %v\n", err)
+			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
 		// Save back to database
-		err = database.UpdateNote(id, string(updatedContent))
+		err = store.Update(cmd.Context(), id, updatedContent)
 		if err != nil {
 			fmt.Printf("Error saving note: %v\n", err)
 			return
@@ -79,6 +48,44 @@ var editCmd = &cobra.Command{
 	},
 }
 
+// editContent opens content in the user's editor through a temporary file
+// and returns what they saved.
+func editContent(content string) (string, error) {
+	// Create a temporary file
+	tmpFile, err := os.CreateTemp("", "jot-*.md")
+	if err != nil {
+		return "", fmt.Errorf("could not create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	// Write the current content to the temp file
+	if _, err := tmpFile.WriteString(content); err != nil {
+		tmpFile.Close()
+		return "", fmt.Errorf("could not write to temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	// Determine which editor to use
+	editor := config.GetEditor()
+
+	// Open the editor
+	editProcess := exec.Command(editor, tmpFile.Name())
+	editProcess.Stdin = os.Stdin
+	editProcess.Stdout = os.Stdout
+	editProcess.Stderr = os.Stderr
+
+	if err := editProcess.Run(); err != nil {
+		return "", fmt.Errorf("editor failed: %v", err)
+	}
+
+	// Read the updated content
+	updated, err := os.ReadFile(tmpFile.Name())
+	if err != nil {
+		return "", fmt.Errorf("could not read updated file: %v", err)
+	}
+	return string(updated), nil
+}
+
 func init() {
 	rootCmd.AddCommand(editCmd)
 }
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/export"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFilter   noteFilterFlags
+	exportFormat   string
+	exportDir      string
+	exportFilename string
+	exportPrune    bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export notes to a directory of Markdown files",
+	Long: `Export notes as Markdown files with YAML front matter (id, tags, priority,
+created and updated dates), one file per note.
+
+Exports are incremental: re-running only rewrites files whose note changed.
+File names come from a Go template with the fields .ID, .Date, .Slug, .Title
+and .Priority, e.g. --filename "{{.Date}}-{{.Slug}}".
+
+Notes to export can be chosen with a query. ` + queryHelp,
+	Example: `  jotcli export --dir ./notes
+  jotcli export --dir ./work --tag work --filename "{{.Date}}-{{.Slug}}"
+  jotcli export --dir ./q1 "created:>=2026-01 created:<2026-04"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if exportFormat != "markdown" && exportFormat != "md" {
+			cmd.Printf("Error: Unsupported export format %q (only markdown is supported)\n", exportFormat)
+			return
+		}
+		if exportDir == "" {
+			cmd.Println("Error: Specify the output directory with --dir")
+			return
+		}
+
+		filter, err := exportFilter.queryFilter(cmd, args)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		notes, err := noteStore(cmd).List(cmd.Context(), filter)
+		if err != nil {
+			cmd.Printf("Error retrieving notes: %v\n", err)
+			return
+		}
+
+		res, err := export.Markdown(notes, export.Options{
+			Dir:              exportDir,
+			FilenameTemplate: exportFilename,
+			Prune:            exportPrune,
+		})
+		if err != nil {
+			cmd.Printf("Error exporting notes: %v\n", err)
+			return
+		}
+
+		summary := fmt.Sprintf("%d written, %d unchanged", res.Written, res.Unchanged)
+		if res.Removed > 0 {
+			summary += fmt.Sprintf(", %d removed", res.Removed)
+		}
+		cmd.Printf("✅ Exported %d note(s) to %s (%s)\n", len(notes), exportDir, summary)
+	},
+}
+
+func init() {
+	exportFilter.register(exportCmd, database.SortCreated)
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "markdown", "Export format")
+	exportCmd.Flags().StringVarP(&exportDir, "dir", "d", "", "Directory to write the files to")
+	exportCmd.Flags().StringVar(&exportFilename, "filename", export.DefaultFilenameTemplate, "File name template")
+	exportCmd.Flags().BoolVar(&exportPrune, "prune", false, "Delete previously exported files for notes that are no longer exported")
+	rootCmd.AddCommand(exportCmd)
+}
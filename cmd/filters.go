@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/dates"
+	"github.com/flyme2mars/jotcli/internal/query"
+	"github.com/spf13/cobra"
+)
+
+// noteFilterFlags are the filtering and sorting flags shared by every command
+// that works on a selection of notes (list, search, export, ...).
+type noteFilterFlags struct {
+	tags     []string
+	matchAll bool
+	sort     string
+	reverse  bool
+	since    string
+	until    string
+	on       string
+}
+
+func (f *noteFilterFlags) register(cmd *cobra.Command, defaultSort string) {
+	sortHelp := "Sort by created, updated, priority, due or id"
+	if defaultSort == database.SortRelevance {
+		sortHelp = "Sort by relevance, created, updated, priority, due or id"
+	}
+
+	cmd.Flags().StringSliceVarP(&f.tags, "tag", "t", nil, "Filter notes by tag (repeat or comma-separate to match any of several)")
+	cmd.Flags().BoolVar(&f.matchAll, "all", false, "Only include notes that have every tag given with --tag")
+	cmd.Flags().StringVarP(&f.sort, "sort", "s", defaultSort, sortHelp)
+	cmd.Flags().BoolVarP(&f.reverse, "reverse", "r", false, "Reverse the sort order")
+	cmd.Flags().StringVar(&f.since, "since", "", "Only include notes created on or after a date, e.g. 2026-03-01, yesterday, 3d or monday")
+	cmd.Flags().StringVar(&f.until, "until", "", "Only include notes created up to and including a date")
+	cmd.Flags().StringVar(&f.on, "on", "", "Only include notes created on a date, or within a period like \"last week\"")
+}
+
+func (f *noteFilterFlags) filter() (database.NoteFilter, error) {
+	filter := database.NoteFilter{
+		Tags:     f.tags,
+		MatchAll: f.matchAll,
+		Sort:     f.sort,
+		Reverse:  f.reverse,
+	}
+
+	now := time.Now()
+	parse := func(flag, value string) (dates.Range, error) {
+		r, err := dates.Parse(value, now)
+		if err != nil {
+			return r, fmt.Errorf("--%s: %v", flag, err)
+		}
+		return r, nil
+	}
+
+	if f.since != "" {
+		r, err := parse("since", f.since)
+		if err != nil {
+			return filter, err
+		}
+		filter.Since = r.Start
+	}
+	if f.until != "" {
+		r, err := parse("until", f.until)
+		if err != nil {
+			return filter, err
+		}
+		filter.Until = r.End
+	}
+	if f.on != "" {
+		r, err := parse("on", f.on)
+		if err != nil {
+			return filter, err
+		}
+		if filter.Since.IsZero() || r.Start.After(filter.Since) {
+			filter.Since = r.Start
+		}
+		if filter.Until.IsZero() || r.End.Before(filter.Until) {
+			filter.Until = r.End
+		}
+	}
+	return filter, nil
+}
+
+// queryFilter is filter narrowed down further by a query given as arguments,
+// e.g. `jotcli list "tag:work -tag:done priority:high"`.
+// Saved searches can be used as @name.
+func (f *noteFilterFlags) queryFilter(cmd *cobra.Command, args []string) (database.NoteFilter, error) {
+	filter, err := f.filter()
+	if err != nil {
+		return filter, err
+	}
+	input := strings.Join(args, " ")
+	if strings.TrimSpace(input) == "" {
+		return filter, nil
+	}
+
+	q, err := query.ParseWith(input, query.SavedSearches(cmd.Context(), noteStore(cmd)))
+	if err != nil {
+		return filter, err
+	}
+	filter.Where = q
+	return filter, nil
+}
+
+// queryHelp documents the query language for commands that accept a query.
+const queryHelp = `Query syntax:
+  word "exact phrase"   notes containing the word or phrase (word* for a prefix)
+  tag:work              notes tagged work, or a tag under it like work/meetings
+  priority:high         also with >, >=, < and <=, e.g. priority:>=medium
+  created:2026-03-01    or updated:, by day, month (2026-03), year (2026) or
+                        phrase (yesterday, 3d, "last week"), also with >, >=,
+                        < and <=, e.g. created:>2026-01-01
+  due:"this week"       notes due in a period, also with >, >=, < and <=
+  status:open           tasks by status (open, doing, done, cancelled or none)
+  id:>100               notes by ID
+  -tag:done, NOT word   leave out notes that match
+  a OR b, (a b) OR c    either side; OR binds tighter than terms side by side
+
+Quote queries that use >, < or a leading "-", so that neither the shell nor
+the flag parser takes them for something else.`
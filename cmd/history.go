@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [note]",
+	Short: "List the saved revisions of a note",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := resolveNoteID(cmd, args[0])
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		revisions, err := noteStore(cmd).Revisions(cmd.Context(), id)
+		if err != nil {
+			cmd.Printf("Error retrieving history: %v\n", err)
+			return
+		}
+
+		if len(revisions) == 0 {
+			cmd.Printf("No history found for note %d\n", id)
+			return
+		}
+
+		headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true).Padding(0, 1)
+		cellStyle := lipgloss.NewStyle().Padding(0, 1)
+		borderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+		rows := [][]string{}
+		for i := len(revisions) - 1; i >= 0; i-- {
+			r := revisions[i]
+			rev := fmt.Sprintf("%d", r.Rev)
+			if i == len(revisions)-1 {
+				rev += " (current)"
+			}
+
+			firstLine, _, _ := strings.Cut(strings.TrimSpace(r.Content), "\n")
+			firstLine = truncate(firstLine, 50)
+
+			rows = append(rows, []string{
+				rev,
+				r.CreatedAt.Format("2006-01-02 15:04"),
+				fmt.Sprintf("%d", strings.Count(r.Content, "\n")+1),
+				firstLine,
+			})
+		}
+
+		t := table.New().
+			Border(lipgloss.NormalBorder()).
+			BorderStyle(borderStyle).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == table.HeaderRow {
+					return headerStyle
+				}
+				return cellStyle
+			}).
+			Headers("Rev", "Saved", "Lines", "Content").
+			Rows(rows...)
+
+		cmd.Println(t.Render())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+}
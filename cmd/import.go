@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/importer"
+	"github.com/flyme2mars/jotcli/internal/markdown"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFrom    string
+	importMapping []string
+	importDryRun  bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import [path]",
+	Short: "Import notes from Markdown, JSON, CSV, Evernote or Google Keep",
+	Long: `Import notes from another source. The format is detected from the path
+unless given with --from:
+
+  markdown  a .md file or a directory of them; YAML front matter may set
+            tags, priority and created/date
+  json      jotcli's own --output json or ndjson
+  csv       a CSV file with a header row; use --map to say which column
+            holds each field (content, tags, priority, created, updated)
+  enex      an Evernote .enex export, or a folder of them; notes are
+            converted to Markdown, attachments are left as placeholders
+  keep      a Google Takeout folder (or its Keep folder); labels become
+            tags, checklists become task lists, pinned notes are high
+            priority and tagged pinned, archived ones are tagged archived
+
+Notes whose content already exists are skipped. Everything is imported in a
+single transaction, so a failed import leaves the database untouched.`,
+	Example: `  jotcli import ~/Documents/notes
+  jotcli import backup.json --dry-run
+  jotcli import sheet.csv --map content=Body --map tags=Labels --map created=Date
+  jotcli import --from enex Notebook.enex
+  jotcli import --from keep ~/Downloads/Takeout`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := args[0]
+
+		format := strings.ToLower(importFrom)
+		if format == "" {
+			var err error
+			format, err = importer.DetectFormat(path)
+			if err != nil {
+				cmd.Printf("Error: %v\n", err)
+				return
+			}
+		}
+
+		var notes []database.NewNote
+		var err error
+		switch format {
+		case importer.FormatMarkdown, "md":
+			notes, err = importer.ReadMarkdown(path)
+		case importer.FormatJSON:
+			notes, err = importer.ReadJSON(path)
+		case importer.FormatCSV:
+			var mapping map[string]string
+			mapping, err = importer.ParseCSVMapping(importMapping)
+			if err == nil {
+				notes, err = importer.ReadCSV(path, mapping)
+			}
+		case importer.FormatENEX, "evernote":
+			notes, err = importer.ReadENEX(path)
+		case importer.FormatKeep:
+			notes, err = importer.ReadKeep(path)
+		default:
+			cmd.Printf("Error: Unknown import format %q (use markdown, json, csv, enex or keep)\n", importFrom)
+			return
+		}
+		if err != nil {
+			cmd.Printf("Error reading %s: %v\n", path, err)
+			return
+		}
+
+		res, err := noteStore(cmd).Import(cmd.Context(), notes, importDryRun)
+		if err != nil {
+			cmd.Printf("Error importing notes: %v\n", err)
+			return
+		}
+
+		if importDryRun {
+			cmd.Printf("Dry run: would import %d note(s) from %s\n", len(res.Imported), path)
+			for _, n := range res.Imported {
+				cmd.Printf("  + %s\n", summarizeNote(n.Content))
+			}
+			for _, n := range res.Duplicates {
+				cmd.Printf("  = %s (duplicate)\n", summarizeNote(n.Content))
+			}
+		} else {
+			cmd.Printf("✅ Imported %d note(s) from %s\n", len(res.Imported), path)
+		}
+		if len(res.Duplicates) > 0 {
+			cmd.Printf("Skipped %d duplicate(s) already in jot\n", len(res.Duplicates))
+		}
+		if res.Empty > 0 {
+			cmd.Printf("Skipped %d empty note(s)\n", res.Empty)
+		}
+	},
+}
+
+// summarizeNote shortens a note to its first line for one-line listings.
+func summarizeNote(content string) string {
+	return truncate(markdown.FirstLine(content), 60)
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importFrom, "from", "", "Source format: markdown, json, csv, enex or keep (detected from the path by default)")
+	importCmd.Flags().StringSliceVar(&importMapping, "map", nil, "CSV column mapping as field=column (repeatable)")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show what would be imported without saving anything")
+	rootCmd.AddCommand(importCmd)
+}
@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/dates"
+	"github.com/flyme2mars/jotcli/internal/markdown"
+	"github.com/spf13/cobra"
+)
+
+// journalTag is added to every journal entry so they can be found with the
+// usual tag filters too.
+const journalTag = "journal"
+
+var (
+	journalDate  string
+	journalMonth string
+)
+
+var journalCmd = &cobra.Command{
+	Use:   "journal",
+	Short: "Open the journal entry for a day in your editor",
+	Long: `Open the journal entry for a day in your editor, creating it if needed.
+There is one entry per day; it is only saved once you write something.
+Without --date it opens today's entry, like 'jotcli today'.`,
+	Example: `  jotcli journal --date 2026-10-01
+  jotcli journal --date yesterday
+  jotcli journal list --month 2026-10`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		day := time.Now()
+		if journalDate != "" {
+			r, err := dates.Parse(journalDate, day)
+			if err != nil {
+				cmd.Printf("Error: --date: %v\n", err)
+				return
+			}
+			day = r.Start
+		}
+		editJournal(cmd, day)
+	},
+}
+
+var journalListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "Show a calendar of the days with journal entries",
+	Long: `Show a calendar for each month with journal entries, the days that have
+one in brackets, followed by the first line of each entry.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, err := resolveOutputFormat(cmd)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		month := ""
+		if journalMonth != "" {
+			m, err := time.ParseInLocation("2006-01", journalMonth, time.Local)
+			if err != nil {
+				cmd.Printf("Error: --month: invalid month %q (use YYYY-MM)\n", journalMonth)
+				return
+			}
+			month = m.Format("2006-01")
+		}
+
+		notes, err := noteStore(cmd).List(cmd.Context(), database.NoteFilter{Journal: true})
+		if err != nil {
+			cmd.Printf("Error retrieving journal: %v\n", err)
+			return
+		}
+		notes = slices.DeleteFunc(notes, func(n database.Note) bool {
+			return month != "" && !strings.HasPrefix(n.JournalDate, month)
+		})
+		slices.SortFunc(notes, func(a, b database.Note) int {
+			return strings.Compare(a.JournalDate, b.JournalDate)
+		})
+
+		if !isHumanFormat(format) {
+			if err := writeNotes(cmd.OutOrStdout(), format, notes); err != nil {
+				cmd.Printf("Error writing output: %v\n", err)
+			}
+			return
+		}
+		if len(notes) == 0 {
+			cmd.Println("No journal entries found.")
+			return
+		}
+		printJournalCalendar(cmd, notes)
+	},
+}
+
+// editJournal opens the journal entry for day in the editor. A new entry is
+// only added if the user changes the heading it starts with.
+func editJournal(cmd *cobra.Command, day time.Time) {
+	store := noteStore(cmd)
+	date := day.Format("2006-01-02")
+	entry, err := store.JournalEntry(cmd.Context(), date)
+	if err != nil {
+		cmd.Printf("Error: %v\n", err)
+		return
+	}
+
+	content := journalHeading(day)
+	if entry != nil {
+		content = entry.Content
+	}
+	updated, err := editContent(content)
+	if err != nil {
+		cmd.Printf("Error: %v\n", err)
+		return
+	}
+	if updated == content {
+		cmd.Println("No changes made.")
+		return
+	}
+
+	if entry == nil {
+		if _, err := store.Add(cmd.Context(), newJournalEntry(day, updated)); err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+	} else if err := store.Update(cmd.Context(), entry.ID, updated); err != nil {
+		cmd.Printf("Error saving note: %v\n", err)
+		return
+	}
+	cmd.Printf("✅ Journal for %s saved!\n", day.Format("Monday, 2 January 2006"))
+}
+
+// addToJournal puts a timestamped bullet at the top of today's journal
+// entry, creating the entry if needed.
+func addToJournal(cmd *cobra.Command, text string) {
+	store := noteStore(cmd)
+	now := time.Now()
+	entry, err := store.JournalEntry(cmd.Context(), now.Format("2006-01-02"))
+	if err != nil {
+		cmd.Printf("Error: %v\n", err)
+		return
+	}
+
+	bullet := journalBullet(now, text)
+	if entry == nil {
+		_, err = store.Add(cmd.Context(), newJournalEntry(now, prependBullet(journalHeading(now), bullet)))
+	} else {
+		err = store.Update(cmd.Context(), entry.ID, prependBullet(entry.Content, bullet))
+	}
+	if err != nil {
+		cmd.Printf("Error: %v\n", err)
+		return
+	}
+	cmd.Printf("✅ Added to the journal for %s: %s\n", now.Format("Monday, 2 January 2006"), text)
+}
+
+// newJournalEntry is the note for day's entry. An entry for another day is
+// dated that day, at the current time, so it lists under it.
+func newJournalEntry(day time.Time, content string) database.NewNote {
+	n := database.NewNote{
+		Content:     content,
+		Tags:        []string{journalTag},
+		Priority:    "low",
+		JournalDate: day.Format("2006-01-02"),
+	}
+	if now := time.Now(); n.JournalDate != now.Format("2006-01-02") {
+		n.CreatedAt = time.Date(day.Year(), day.Month(), day.Day(), now.Hour(), now.Minute(), now.Second(), 0, day.Location())
+		n.UpdatedAt = now
+	}
+	return n
+}
+
+// journalHeading is what a new journal entry starts with.
+func journalHeading(day time.Time) string {
+	return "# " + day.Format("Monday, 2 January 2006") + "\n\n"
+}
+
+// journalBullet formats text as a list item starting with the time, with
+// any further lines indented under it.
+func journalBullet(at time.Time, text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	return "- " + at.Format("15:04") + " " + strings.Join(lines, "\n  ")
+}
+
+// prependBullet adds bullet to the top of an entry, below its heading, so
+// the newest bullets come first.
+func prependBullet(content, bullet string) string {
+	heading, rest := "", content
+	if strings.HasPrefix(content, "#") {
+		heading, rest, _ = strings.Cut(content, "\n")
+		heading += "\n\n"
+	}
+	rest = strings.TrimLeft(rest, "\n")
+	if rest == "" {
+		return heading + bullet + "\n"
+	}
+	return heading + bullet + "\n" + rest
+}
+
+// printJournalCalendar prints a calendar for each month with entries, the
+// days that have one in brackets, and a line for each entry below it.
+func printJournalCalendar(cmd *cobra.Command, notes []database.Note) {
+	for i := 0; i < len(notes); {
+		month := notes[i].JournalDate[:7]
+		j := i
+		days := map[int]bool{}
+		for ; j < len(notes) && notes[j].JournalDate[:7] == month; j++ {
+			if d, err := time.ParseInLocation("2006-01-02", notes[j].JournalDate, time.Local); err == nil {
+				days[d.Day()] = true
+			}
+		}
+
+		if i > 0 {
+			cmd.Println()
+		}
+		first, _ := time.ParseInLocation("2006-01", month, time.Local)
+		cmd.Print(monthCalendar(first, days))
+		cmd.Println()
+		for _, n := range notes[i:j] {
+			cmd.Printf("  %s  #%d  %s\n", n.JournalDate[8:], n.ID, journalSummary(n.Content))
+		}
+		i = j
+	}
+}
+
+// monthCalendar draws the month starting at first as a grid of weeks from
+// Monday to Sunday, with the marked days in brackets.
+func monthCalendar(first time.Time, marked map[int]bool) string {
+	var b strings.Builder
+	b.WriteString(first.Format("January 2006") + "\n")
+	b.WriteString(" Mo  Tu  We  Th  Fr  Sa  Su\n")
+
+	offset := (int(first.Weekday()) + 6) % 7
+	last := first.AddDate(0, 1, -1).Day()
+	line := strings.Repeat("    ", offset)
+	for day := 1; day <= last; day++ {
+		if marked[day] {
+			line += fmt.Sprintf("%4s", fmt.Sprintf("[%d]", day))
+		} else {
+			line += fmt.Sprintf("%3d ", day)
+		}
+		if (offset+day)%7 == 0 || day == last {
+			b.WriteString(strings.TrimRight(line, " ") + "\n")
+			line = ""
+		}
+	}
+	return b.String()
+}
+
+// journalSummary is the first line of an entry after its heading.
+func journalSummary(content string) string {
+	if strings.HasPrefix(content, "#") {
+		_, content, _ = strings.Cut(content, "\n")
+	}
+	if line := markdown.FirstLine(content); line != "" {
+		return line
+	}
+	return "(empty)"
+}
+
+func init() {
+	journalCmd.Flags().StringVar(&journalDate, "date", "", "The day to open, e.g. 2026-10-01, yesterday or \"last friday\"")
+	journalListCmd.Flags().StringVar(&journalMonth, "month", "", "Only show one month, as YYYY-MM")
+	journalCmd.AddCommand(journalListCmd)
+	rootCmd.AddCommand(journalCmd)
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/markdown"
+	"github.com/spf13/cobra"
+)
+
+var linksCmd = &cobra.Command{
+	Use:   "links [note]",
+	Short: "List the notes a note links to",
+	Long: `List the [[links]] in a note. Link to another note by its ID, [[12]], its
+slug, [[sprint-planning]], or its title, [[Sprint planning]]; [[12|text]]
+works too. Links that don't lead to a note are shown as broken.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, err := resolveOutputFormat(cmd)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		note, err := resolveNote(cmd, args[0])
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		store := noteStore(cmd)
+		links, err := store.Links(cmd.Context(), note.ID)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		targets := map[int]*database.Note{}
+		var notes []database.Note
+		for _, l := range links {
+			if l.TargetID == 0 || targets[l.TargetID] != nil {
+				continue
+			}
+			target, err := store.Get(cmd.Context(), l.TargetID)
+			if err != nil {
+				cmd.Printf("Error: %v\n", err)
+				return
+			}
+			if target != nil {
+				targets[l.TargetID] = target
+				notes = append(notes, *target)
+			}
+		}
+
+		if !isHumanFormat(format) {
+			if err := writeNotes(cmd.OutOrStdout(), format, notes); err != nil {
+				cmd.Printf("Error writing output: %v\n", err)
+			}
+			return
+		}
+		if len(links) == 0 {
+			cmd.Printf("Note %d has no links.\n", note.ID)
+			return
+		}
+		cmd.Printf("Links from note %d:\n", note.ID)
+		for _, l := range links {
+			if target := targets[l.TargetID]; target != nil {
+				cmd.Printf("  → #%d  %s\n", target.ID, markdown.FirstLine(target.Content))
+			} else {
+				cmd.Printf("  ✗ [[%s]] (broken)\n", l.Target)
+			}
+		}
+	},
+}
+
+var backlinksCmd = &cobra.Command{
+	Use:   "backlinks [note]",
+	Short: "List the notes that link to a note",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, err := resolveOutputFormat(cmd)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		note, err := resolveNote(cmd, args[0])
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		notes, err := noteStore(cmd).Backlinks(cmd.Context(), note.ID)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		if !isHumanFormat(format) {
+			if err := writeNotes(cmd.OutOrStdout(), format, notes); err != nil {
+				cmd.Printf("Error writing output: %v\n", err)
+			}
+			return
+		}
+		if len(notes) == 0 {
+			cmd.Printf("No notes link to note %d.\n", note.ID)
+			return
+		}
+		cmd.Printf("Notes linking to note %d:\n", note.ID)
+		for _, n := range notes {
+			cmd.Println(noteLine(n, n.UpdatedAt.Format("2006-01-02")))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(linksCmd, backlinksCmd)
+}
@@ -5,86 +5,131 @@ import (
 	"os"
 	"strings"
 
-	"github.com/flyme2mars/jotcli/internal/database"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
+	"github.com/flyme2mars/jotcli/internal/database"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
 
-var listTag string
+var listFilter noteFilterFlags
 
 var listCmd = &cobra.Command{
-	Use:   "list",
+	Use:   "list [query]",
 	Short: "List all notes",
+	Long: `List notes, optionally narrowed down by a query.
+
+` + queryHelp,
+	Example: `  jotcli list
+  jotcli list "tag:work priority:high -tag:done"
+  jotcli list 'created:>=2026-01 "release notes" OR changelog'`,
 	Run: func(cmd *cobra.Command, args []string) {
-		notes, err := database.GetNotes(listTag)
+		format, err := resolveOutputFormat(cmd)
 		if err != nil {
-			cmd.Printf("Error retrieving notes: %v\n", err)
+			cmd.Printf("Error: %v\n", err)
 			return
 		}
 
-		if len(notes) == 0 {
-			cmd.Println("No notes found.")
+		filter, err := listFilter.queryFilter(cmd, args)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
 			return
 		}
 
-		// Get terminal width
-		width, _, err := term.GetSize(int(os.Stdout.Fd()))
+		notes, err := noteStore(cmd).List(cmd.Context(), filter)
 		if err != nil {
-			width = 80 // Fallback
+			cmd.Printf("Error retrieving notes: %v\n", err)
+			return
 		}
 
-		// Define styles
-		headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true).Padding(0, 1)
-		cellStyle := lipgloss.NewStyle().Padding(0, 1)
-		borderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-
-		// Prepare data and calculate available space for the "Note" column
-		// Fixed widths for ID (4), Tag (12), Priority (10), Created At (18) + Borders
-		reservedWidth := 4 + 12 + 10 + 18 + 10 
-		noteWidth := width - reservedWidth
-		if noteWidth < 20 {
-			noteWidth = 20 // Minimum width for the note
+		printNotes(cmd, notes, format, filter.Sort)
+	},
+}
+
+// printNotes prints notes in the given output format, with dates shown for
+// the field they are sorted by.
+func printNotes(cmd *cobra.Command, notes []database.Note, format, sort string) {
+	if len(notes) == 0 && isHumanFormat(format) {
+		cmd.Println("No notes found.")
+		return
+	}
+
+	if format != formatTable {
+		if err := writeNotes(cmd.OutOrStdout(), format, notes); err != nil {
+			cmd.Printf("Error writing output: %v\n", err)
 		}
+		return
+	}
 
-		rows := [][]string{}
-		for _, n := range notes {
-			// Clean up newlines for the table view
-			displayContent := strings.ReplaceAll(n.Content, "\n", " ")
-			displayContent = strings.ReplaceAll(displayContent, "\\n", " ")
+	// Get terminal width
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width = 80 // Fallback
+	}
 
-			// Truncate if too long
-			if len(displayContent) > noteWidth {
-				displayContent = displayContent[:noteWidth-3] + "..."
-			}
+	// Define styles
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true).Padding(0, 1)
+	cellStyle := lipgloss.NewStyle().Padding(0, 1)
+	borderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	// Prepare data and calculate available space for the "Note" column
+	// Fixed widths for ID (4), Tag (12), Priority (10), Created At (18) + Borders
+	reservedWidth := 4 + 12 + 10 + 18 + 10
+	noteWidth := width - reservedWidth
+	if noteWidth < 20 {
+		noteWidth = 20 // Minimum width for the note
+	}
+
+	// Show the date the notes are sorted by
+	dateHeader := "Created"
+	if sort == database.SortUpdated {
+		dateHeader = "Updated"
+	}
 
-			rows = append(rows, []string{
-				fmt.Sprintf("%d", n.ID),
-				displayContent,
-				n.Tag,
-				n.Priority,
-				n.CreatedAt.Format("2006-01-02"),
-			})
+	rows := [][]string{}
+	for _, n := range notes {
+		// Clean up newlines for the table view
+		displayContent := strings.ReplaceAll(n.Content, "\n", " ")
+		displayContent = strings.ReplaceAll(displayContent, "\\n", " ")
+
+		// Truncate if too long
+		if len(displayContent) > noteWidth {
+			displayContent = displayContent[:noteWidth-3] + "..."
 		}
 
-		t := table.New().
-			Border(lipgloss.NormalBorder()).
-			BorderStyle(borderStyle).
-			StyleFunc(func(row, col int) lipgloss.Style {
-				if row == table.HeaderRow {
-					return headerStyle
-				}
-				return cellStyle
-			}).
-			Headers("ID", "Note", "Tag", "Priority", "Created").
-			Rows(rows...)
-
-		cmd.Println(t.Render())
-	},
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", n.ID),
+			displayContent,
+			strings.Join(n.Tags, ", "),
+			n.Priority,
+			noteDate(n, dateHeader),
+		})
+	}
+
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		BorderStyle(borderStyle).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			return cellStyle
+		}).
+		Headers("ID", "Note", "Tags", "Priority", dateHeader).
+		Rows(rows...)
+
+	cmd.Println(t.Render())
 }
 
 func init() {
-	listCmd.Flags().StringVarP(&listTag, "tag", "t", "", "Filter notes by tag")
+	listFilter.register(listCmd, database.SortCreated)
 	rootCmd.AddCommand(listCmd)
-}
\ No newline at end of file
+}
+
+// noteDate formats the created or updated date of a note for table output.
+func noteDate(n database.Note, header string) string {
+	if header == "Updated" {
+		return n.UpdatedAt.Format("2006-01-02")
+	}
+	return n.CreatedAt.Format("2006-01-02")
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bufio"
+	"strings"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/config"
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/markdown"
+	"github.com/flyme2mars/jotcli/internal/templates"
+	"github.com/spf13/cobra"
+)
+
+var newTemplate string
+
+var newCmd = &cobra.Command{
+	Use:   "new [title]",
+	Short: "Write a new note in your editor, optionally from a template",
+	Long: `Write a new note in your editor. With --template it starts from a
+template in the templates directory (templates_dir in ~/.jotcli.yaml,
+~/.jot-templates by default), rendered with Go's text/template.
+
+Templates can use {{.Title}}, {{.Date}}, {{.Time}}, {{.Branch}} (the
+current git branch) and {{.Now}}, e.g. {{.Now.Format "Monday"}}. The title
+is asked for if the template uses it and none is given.`,
+	Example: `  jotcli new --template bug "Login fails on Safari"
+  jotcli new --template meeting
+  jotcli templates`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		content, ok := composeNote(cmd, newTemplate, strings.Join(args, " "))
+		if !ok {
+			return
+		}
+
+		id, err := noteStore(cmd).Add(cmd.Context(), database.NewNote{Content: content, Priority: "low"})
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		cmd.Printf("✅ Note %d saved: %s\n", id, markdown.FirstLine(content))
+	},
+}
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List the note templates",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := config.GetTemplatesDir()
+		names, err := templates.List(dir)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		if len(names) == 0 {
+			cmd.Printf("No templates found in %s.\n", dir)
+			return
+		}
+		cmd.Printf("Templates in %s:\n", dir)
+		for _, name := range names {
+			cmd.Printf("  %s\n", name)
+		}
+	},
+}
+
+// composeNote opens the editor on a rendered template, or an empty note if
+// name is "", and returns what the user wrote. It reports false, after
+// printing why, if there is nothing to save.
+func composeNote(cmd *cobra.Command, name, title string) (string, bool) {
+	content := ""
+	if name != "" {
+		text, err := templates.Load(config.GetTemplatesDir(), name)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return "", false
+		}
+		if title == "" && templates.UsesTitle(text) {
+			title = promptLine(cmd, "Title: ")
+		}
+		content, err = templates.Render(name, text, templates.NewVars(title, time.Now()))
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return "", false
+		}
+	} else if title != "" {
+		content = "# " + title + "\n\n"
+	}
+
+	updated, err := editContent(content)
+	if err != nil {
+		cmd.Printf("Error: %v\n", err)
+		return "", false
+	}
+	if strings.TrimSpace(updated) == "" {
+		cmd.Println("Nothing written; note not saved.")
+		return "", false
+	}
+	return updated, true
+}
+
+// promptLine asks for a line of input.
+func promptLine(cmd *cobra.Command, prompt string) string {
+	cmd.Print(prompt)
+	line, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+func init() {
+	newCmd.Flags().StringVar(&newTemplate, "template", "", "Start from this template (see 'jotcli templates')")
+	rootCmd.AddCommand(newCmd, templatesCmd)
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// Output formats accepted by --output.
+const (
+	formatTable  = "table"
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+	formatCSV    = "csv"
+	formatTSV    = "tsv"
+	formatIDs    = "ids"
+	formatPlain  = "plain"
+)
+
+var outputFormats = []string{formatTable, formatJSON, formatNDJSON, formatCSV, formatTSV, formatIDs, formatPlain}
+
+var outputFormat string
+
+// resolveOutputFormat returns the format chosen with --output. Without the
+// flag, tables are only drawn for a terminal; pipes and files get plain text.
+func resolveOutputFormat(cmd *cobra.Command) (string, error) {
+	if outputFormat == "" {
+		if f, ok := cmd.OutOrStdout().(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+			return formatTable, nil
+		}
+		return formatPlain, nil
+	}
+
+	format := strings.ToLower(outputFormat)
+	for _, f := range outputFormats {
+		if f == format {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output format %q (use %s)", outputFormat, strings.Join(outputFormats, ", "))
+}
+
+// isHumanFormat reports whether a format is meant for people rather than
+// scripts, in which case friendly messages such as "No notes found." are fine.
+func isHumanFormat(format string) bool {
+	return format == formatTable || format == formatPlain
+}
+
+// writeNotes renders notes in any format other than table.
+func writeNotes(w io.Writer, format string, notes []database.Note) error {
+	results := make([]database.SearchResult, len(notes))
+	records := make([]any, len(notes))
+	for i, n := range notes {
+		n = normalizeNote(n)
+		results[i] = database.SearchResult{Note: n}
+		records[i] = n
+	}
+	return writeRecords(w, format, records, results, false)
+}
+
+// writeSearchResults renders search results in any format other than table.
+func writeSearchResults(w io.Writer, format string, results []database.SearchResult) error {
+	records := make([]any, len(results))
+	for i := range results {
+		results[i].Note = normalizeNote(results[i].Note)
+		results[i].Snippet = database.StripHighlights(results[i].Snippet)
+		records[i] = results[i]
+	}
+	return writeRecords(w, format, records, results, true)
+}
+
+// normalizeNote makes sure empty tag lists serialize as [] rather than null.
+func normalizeNote(n database.Note) database.Note {
+	if n.Tags == nil {
+		n.Tags = []string{}
+	}
+	return n
+}
+
+func writeRecords(w io.Writer, format string, records []any, results []database.SearchResult, withSnippet bool) error {
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+
+	case formatNDJSON:
+		enc := json.NewEncoder(w)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case formatIDs:
+		for _, r := range results {
+			if _, err := fmt.Fprintln(w, r.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(recordHeader(withSnippet))
+		for _, r := range results {
+			cw.Write(recordFields(r, withSnippet))
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case formatTSV:
+		escape := strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r")
+		fmt.Fprintln(w, strings.Join(recordHeader(withSnippet), "\t"))
+		for _, r := range results {
+			fields := recordFields(r, withSnippet)
+			for i := range fields {
+				fields[i] = escape.Replace(fields[i])
+			}
+			if _, err := fmt.Fprintln(w, strings.Join(fields, "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case formatPlain:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, r := range results {
+			text := r.Content
+			if withSnippet && r.Snippet != "" {
+				text = r.Snippet
+			}
+			text = strings.Join(strings.Fields(text), " ")
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n",
+				r.ID, r.CreatedAt.Format("2006-01-02"), r.Priority, strings.Join(r.Tags, ","), text)
+		}
+		return tw.Flush()
+	}
+
+	return fmt.Errorf("unsupported output format %q", format)
+}
+
+func recordHeader(withSnippet bool) []string {
+	header := []string{"id", "content", "tags", "priority", "created_at", "updated_at", "due_at", "status", "title", "slug"}
+	if withSnippet {
+		header = append(header, "snippet", "rank")
+	}
+	return header
+}
+
+func recordFields(r database.SearchResult, withSnippet bool) []string {
+	var due string
+	if r.DueAt != nil {
+		due = r.DueAt.Format(time.RFC3339)
+	}
+	fields := []string{
+		fmt.Sprintf("%d", r.ID),
+		r.Content,
+		strings.Join(r.Tags, ","),
+		r.Priority,
+		r.CreatedAt.Format(time.RFC3339),
+		r.UpdatedAt.Format(time.RFC3339),
+		due,
+		r.Status,
+		r.Title,
+		r.Slug,
+	}
+	if withSnippet {
+		fields = append(fields, r.Snippet, fmt.Sprintf("%g", r.Rank))
+	}
+	return fields
+}
+
+// truncate shortens s to at most max characters, ending it with "..." if
+// anything was cut. It counts runes, so multi-byte characters stay whole.
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-3]) + "..."
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "",
+		"Output format: "+strings.Join(outputFormats, ", ")+" (default table on a terminal, plain otherwise)")
+}
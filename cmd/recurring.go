@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/markdown"
+	"github.com/flyme2mars/jotcli/internal/recur"
+	"github.com/spf13/cobra"
+)
+
+var recurringCmd = &cobra.Command{
+	Use:   "recurring",
+	Short: "Manage recurring tasks",
+	Long: `Manage tasks that repeat, such as standups, weekly reviews and monthly
+reports.
+
+Make a task repeat with 'jotcli add --due <when> --every <rule>' or
+'jotcli recurring set <note> <rule>'. When it is marked done, the next
+occurrence is created with the same content, tags and priority, its
+checklist cleared and its due date moved on by the rule, past today if the
+task was finished late.
+
+Rules: ` + recur.Help,
+}
+
+var recurringListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List recurring tasks and when they are next due",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, err := resolveOutputFormat(cmd)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		notes, err := noteStore(cmd).List(cmd.Context(), database.NoteFilter{Recurring: true, Sort: database.SortDue})
+		if err != nil {
+			cmd.Printf("Error retrieving notes: %v\n", err)
+			return
+		}
+
+		if format != formatTable {
+			if len(notes) == 0 && isHumanFormat(format) {
+				cmd.Println("No recurring tasks.")
+				return
+			}
+			if err := writeNotes(cmd.OutOrStdout(), format, notes); err != nil {
+				cmd.Printf("Error writing output: %v\n", err)
+			}
+			return
+		}
+		if len(notes) == 0 {
+			cmd.Println("No recurring tasks. Add one with 'jotcli add <note> --due <when> --every <rule>'.")
+			return
+		}
+
+		headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true).Padding(0, 1)
+		cellStyle := lipgloss.NewStyle().Padding(0, 1)
+		borderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+		now := time.Now()
+		rows := [][]string{}
+		for _, n := range notes {
+			repeats := n.Recurrence
+			if rule, err := recur.Parse(n.Recurrence); err == nil {
+				repeats = rule.Describe()
+			}
+			next := ""
+			if n.DueAt != nil {
+				next = formatDue(*n.DueAt, now)
+			}
+			rows = append(rows, []string{strconv.Itoa(n.ID), markdown.FirstLine(n.Content), repeats, next})
+		}
+
+		t := table.New().
+			Border(lipgloss.NormalBorder()).
+			BorderStyle(borderStyle).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == table.HeaderRow {
+					return headerStyle
+				}
+				return cellStyle
+			}).
+			Headers("ID", "Note", "Repeats", "Next Due").
+			Rows(rows...)
+
+		cmd.Println(t.Render())
+	},
+}
+
+var recurringSetCmd = &cobra.Command{
+	Use:   "set <note> <rule>",
+	Short: "Make a task with a due date repeat",
+	Example: `  jotcli recurring set 12 weekday
+  jotcli recurring set 12 "2 weeks"
+  jotcli recurring set 12 "FREQ=WEEKLY;BYDAY=MO,TH"`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := resolveNoteID(cmd, args[0])
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		rule, err := recur.Parse(strings.Join(args[1:], " "))
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		store := noteStore(cmd)
+		note, err := store.Get(cmd.Context(), id)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		if note == nil {
+			cmd.Printf("Error: Note with ID %d not found\n", id)
+			return
+		}
+		if note.DueAt == nil {
+			cmd.Printf("Error: Note %d has no due date (set one with 'jotcli due %d <when>')\n", id, id)
+			return
+		}
+
+		if err := store.SetRecurrence(cmd.Context(), id, rule.String()); err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		if note.Status == "" {
+			if _, err := store.SetStatus(cmd.Context(), id, database.StatusOpen); err != nil {
+				cmd.Printf("Error: %v\n", err)
+				return
+			}
+		}
+
+		cmd.Printf("🔁 Note %d repeats %s\n", id, rule.Describe())
+	},
+}
+
+var recurringCancelCmd = &cobra.Command{
+	Use:   "cancel <note...>",
+	Short: "Stop tasks from repeating",
+	Long: `Stop tasks from repeating. The current occurrence stays as an ordinary task;
+mark it done or cancelled as usual.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store := noteStore(cmd)
+		for _, arg := range args {
+			id, err := resolveNoteID(cmd, arg)
+			if err != nil {
+				cmd.Printf("Error: %v\n", err)
+				continue
+			}
+
+			note, err := store.Get(cmd.Context(), id)
+			if err != nil {
+				cmd.Printf("Error: %v\n", err)
+				continue
+			}
+			if note == nil || note.Recurrence == "" {
+				cmd.Printf("Error: Note %d isn't a recurring task\n", id)
+				continue
+			}
+
+			if err := store.SetRecurrence(cmd.Context(), id, ""); err != nil {
+				cmd.Printf("Error: %v\n", err)
+				continue
+			}
+			cmd.Printf("✅ Note %d no longer repeats\n", id)
+		}
+	},
+}
+
+// printNextOccurrence reports the next occurrence a completed recurring
+// task created, if any.
+func printNextOccurrence(cmd *cobra.Command, id int) {
+	if id == 0 {
+		return
+	}
+	note, err := noteStore(cmd).Get(cmd.Context(), id)
+	if err != nil || note == nil || note.DueAt == nil {
+		cmd.Printf("🔁 Next occurrence: note %d\n", id)
+		return
+	}
+	cmd.Printf("🔁 Next occurrence: note %d, due %s\n", id, formatDue(*note.DueAt, time.Now()))
+}
+
+func init() {
+	recurringCmd.AddCommand(recurringListCmd, recurringSetCmd, recurringCancelCmd)
+	rootCmd.AddCommand(recurringCmd)
+}
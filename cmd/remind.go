@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/config"
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/spf13/cobra"
+)
+
+var remindCheck bool
+
+var remindCmd = &cobra.Command{
+	Use:   "remind",
+	Short: "Send reminders for notes that have come due",
+	Long: `Send reminders for notes whose due date has passed and that haven't had one
+yet. Without --check this only lists them.
+
+With --check each reminder is printed, or handed to the command set as
+remind_command in the config file, and the note is marked as notified so
+it isn't reminded about again. The command runs with the note's content on
+stdin and JOT_NOTE_ID, JOT_NOTE_DUE and JOT_NOTE_TITLE in its environment.
+Nothing is printed when there is nothing to remind about, so it can run
+from cron:
+
+  */5 * * * * jotcli remind --check`,
+	Example: `  jotcli remind
+  jotcli remind --check`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := noteStore(cmd)
+		now := time.Now()
+		notes, err := store.Reminders(cmd.Context(), now)
+		if err != nil {
+			cmd.Printf("Error retrieving reminders: %v\n", err)
+			return
+		}
+
+		if !remindCheck {
+			if len(notes) == 0 {
+				cmd.Println("No reminders due.")
+				return
+			}
+			for _, n := range notes {
+				cmd.Println(noteLine(n, formatDue(*n.DueAt, now)))
+			}
+			return
+		}
+
+		command := config.GetRemindCommand()
+		for _, n := range notes {
+			if command == "" {
+				cmd.Printf("⏰ %s\n", strings.TrimSpace(noteLine(n, formatDue(*n.DueAt, now))))
+			} else if err := runRemindCommand(cmd, command, n); err != nil {
+				// Not marked, so the next check tries again
+				cmd.Printf("Error: reminder for note %d failed: %v\n", n.ID, err)
+				continue
+			}
+
+			if err := store.MarkNotified(cmd.Context(), n.ID, now); err != nil {
+				cmd.Printf("Error: %v\n", err)
+			}
+		}
+	},
+}
+
+// runRemindCommand runs the configured reminder command for a note.
+func runRemindCommand(cmd *cobra.Command, command string, n database.Note) error {
+	c := exec.Command("sh", "-c", command)
+	c.Stdin = strings.NewReader(n.Content)
+	c.Stdout = cmd.OutOrStdout()
+	c.Stderr = cmd.ErrOrStderr()
+	c.Env = append(os.Environ(),
+		fmt.Sprintf("JOT_NOTE_ID=%d", n.ID),
+		"JOT_NOTE_DUE="+n.DueAt.Format(time.RFC3339),
+		"JOT_NOTE_TITLE="+n.DisplayTitle(),
+	)
+	return c.Run()
+}
+
+func init() {
+	remindCmd.Flags().BoolVar(&remindCheck, "check", false, "Send the reminders and mark them as notified")
+	rootCmd.AddCommand(remindCmd)
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/markdown"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// maxPicks is how many notes are offered when a reference is ambiguous.
+const maxPicks = 9
+
+// resolveNoteID finds the note a command argument refers to: an ID, a slug,
+// or the start of a title, e.g. "spr plan" for "Sprint planning", which
+// commands taking several notes need quoted as one argument. IDs are
+// taken as they are, so commands report missing notes the way they always
+// have. When several titles match, the user picks one at a terminal.
+func resolveNoteID(cmd *cobra.Command, ref string) (int, error) {
+	return findNoteID(cmd, ref, func() ([]database.Note, error) {
+		return noteStore(cmd).List(cmd.Context(), database.NoteFilter{Sort: database.SortID})
+	})
+}
+
+// resolveTrashedNoteID is resolveNoteID for the notes in the trash.
+func resolveTrashedNoteID(cmd *cobra.Command, ref string) (int, error) {
+	return findNoteID(cmd, ref, func() ([]database.Note, error) {
+		return noteStore(cmd).Trash(cmd.Context())
+	})
+}
+
+// findNoteID resolves ref among the notes returned by list, which is only
+// called when ref isn't an ID.
+func findNoteID(cmd *cobra.Command, ref string, list func() ([]database.Note, error)) (int, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return id, nil
+	}
+
+	notes, err := list()
+	if err != nil {
+		return 0, fmt.Errorf("could not look up %q: %v", ref, err)
+	}
+	matches := matchNotes(notes, ref)
+	switch len(matches) {
+	case 0:
+		return 0, fmt.Errorf("no note matches %q", ref)
+	case 1:
+		return matches[0].ID, nil
+	}
+	return pickNote(cmd, ref, matches)
+}
+
+// resolveNote is resolveNoteID for commands that need the note itself.
+func resolveNote(cmd *cobra.Command, ref string) (*database.Note, error) {
+	id, err := resolveNoteID(cmd, ref)
+	if err != nil {
+		return nil, err
+	}
+	note, err := noteStore(cmd).Get(cmd.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	if note == nil {
+		return nil, fmt.Errorf("no note with ID %d", id)
+	}
+	return note, nil
+}
+
+// matchNotes returns the notes a name could mean, trying the closest kind
+// of match first: the slug, the whole title, then titles whose words start
+// with the words of ref in order.
+func matchNotes(notes []database.Note, ref string) []database.Note {
+	key := markdown.LinkKey(ref)
+	for _, n := range notes {
+		if n.Slug == key {
+			return []database.Note{n}
+		}
+	}
+
+	slug := markdown.Slug(ref)
+	var exact, fuzzy []database.Note
+	for _, n := range notes {
+		title := markdown.LinkKey(n.DisplayTitle())
+		switch {
+		case title == key:
+			exact = append(exact, n)
+		case matchWords(title, key) || (slug != "" && strings.HasPrefix(n.Slug, slug)):
+			fuzzy = append(fuzzy, n)
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return fuzzy
+}
+
+// matchWords reports whether each word of ref starts a word of title, in
+// order.
+func matchWords(title, ref string) bool {
+	words := strings.Fields(title)
+	for _, part := range strings.Fields(ref) {
+		for len(words) > 0 && !strings.HasPrefix(words[0], part) {
+			words = words[1:]
+		}
+		if len(words) == 0 {
+			return false
+		}
+		words = words[1:]
+	}
+	return ref != ""
+}
+
+// pickNote asks which of several matching notes was meant. Without a
+// terminal to ask at, it lists them in the error instead.
+func pickNote(cmd *cobra.Command, ref string, matches []database.Note) (int, error) {
+	if len(matches) > maxPicks {
+		matches = matches[:maxPicks]
+	}
+	f, ok := cmd.InOrStdin().(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		names := make([]string, len(matches))
+		for i, n := range matches {
+			names[i] = fmt.Sprintf("%s (#%d)", n.Slug, n.ID)
+		}
+		return 0, fmt.Errorf("%q could be %s", ref, strings.Join(names, ", "))
+	}
+
+	cmd.Printf("%q matches several notes:\n", ref)
+	for i, n := range matches {
+		cmd.Printf("  %d) #%d  %s  (%s)\n", i+1, n.ID, n.DisplayTitle(), n.Slug)
+	}
+	cmd.Printf("Which one? [1-%d] ", len(matches))
+	line, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	choice, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || choice < 1 || choice > len(matches) {
+		return 0, fmt.Errorf("no note picked for %q", ref)
+	}
+	return matches[choice-1].ID, nil
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var revertCmd = &cobra.Command{
+	Use:   "revert [note] [rev]",
+	Short: "Restore a note to an earlier revision",
+	Long: `Restore a note to an earlier revision.
+
+The reverted content is saved as a new revision, so nothing in the history is lost.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := resolveNoteID(cmd, args[0])
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		rev, err := strconv.Atoi(args[1])
+		if err != nil {
+			cmd.Println("Error: Invalid revision number")
+			return
+		}
+
+		if err := noteStore(cmd).Revert(cmd.Context(), id, rev); err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		cmd.Printf("✅ Note %d reverted to revision %d\n", id, rev)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(revertCmd)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/flyme2mars/jotcli/internal/config"
 	"github.com/flyme2mars/jotcli/internal/database"
@@ -16,6 +17,14 @@ var rootCmd = &cobra.Command{
 	Long:  `A quick and efficient way to capture notes, tag them, and view them in your terminal.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		// This runs BEFORE any subcommand
+		// Cobra only hands the root's context down to a subcommand the first
+		// time it runs, so always take it from the root
+		ctx := cmd.Root().Context()
+		if _, ok := ctx.Value(storeKey{}).(database.NoteStore); ok {
+			cmd.SetContext(ctx)
+			return
+		}
+
 		// Initialize Config first
 		err := config.InitConfig()
 		if err != nil {
@@ -23,12 +32,28 @@ var rootCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		// Restoring a backup has to work even when the current database won't open
+		if cmd == restoreCmd && isBackupFile(args) {
+			return
+		}
+
 		// Then Initialize Database
 		err = database.InitDB()
 		if err != nil {
 			fmt.Printf("Error initializing database: %v\n", err)
 			os.Exit(1)
 		}
+
+		store := database.NewSQLiteStore(database.DB)
+
+		// Clear out notes that have sat in the trash past the retention period
+		if retention := config.GetTrashRetention(); retention > 0 {
+			if _, err := store.PurgeTrash(ctx, time.Now().Add(-retention)); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not purge trash: %v\n", err)
+			}
+		}
+
+		cmd.SetContext(WithStore(WithDB(ctx, database.DB), store))
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// This runs when no subcommands are provided
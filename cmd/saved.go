@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/query"
+	"github.com/spf13/cobra"
+)
+
+var (
+	savedForce  bool
+	savedFilter noteFilterFlags
+)
+
+// savedNamePattern keeps saved search names usable as @name in queries.
+var savedNamePattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N}_./-]*$`)
+
+var savedCmd = &cobra.Command{
+	Use:   "saved",
+	Short: "Manage saved searches",
+	Long: `Save queries under a name and run them again later.
+
+A saved search can be run with 'jotcli saved run <name>' or used as @name in
+any query, e.g. 'jotcli list @inbox' or 'jotcli list @work priority:high'.
+Saved searches also appear in the sidebar of 'jotcli view'.`,
+}
+
+var savedAddCmd = &cobra.Command{
+	Use:   "add <name> <query>",
+	Short: "Save a query under a name",
+	Example: `  jotcli saved add inbox tag:inbox
+  jotcli saved add urgent "priority:high -tag:done"
+  jotcli saved add work-urgent @urgent tag:work`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := strings.TrimPrefix(args[0], "@")
+		input := strings.Join(args[1:], " ")
+		if !savedNamePattern.MatchString(name) {
+			cmd.Printf("Error: Invalid name %q (use letters, digits, '-', '_', '.' and '/')\n", args[0])
+			return
+		}
+
+		store := noteStore(cmd)
+		existing, err := store.SavedSearch(cmd.Context(), name)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		if existing != nil && !savedForce {
+			cmd.Printf("Error: A saved search named %q already exists (use --force to replace it)\n", existing.Name)
+			return
+		}
+
+		// Check the query as it will be run, including references back to
+		// this name, which would loop forever
+		resolve := query.SavedSearches(cmd.Context(), store)
+		_, err = query.ParseWith(input, func(ref string) (string, error) {
+			if strings.EqualFold(ref, name) {
+				return input, nil
+			}
+			return resolve(ref)
+		})
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		if err := store.SaveSearch(cmd.Context(), database.SavedSearch{Name: name, Query: input}); err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		cmd.Printf("✅ Saved search @%s: %s\n", name, input)
+	},
+}
+
+var savedListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List saved searches",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		saved, err := noteStore(cmd).SavedSearches(cmd.Context())
+		if err != nil {
+			cmd.Printf("Error retrieving saved searches: %v\n", err)
+			return
+		}
+
+		if len(saved) == 0 {
+			cmd.Println("No saved searches. Add one with 'jotcli saved add <name> <query>'.")
+			return
+		}
+
+		headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true).Padding(0, 1)
+		cellStyle := lipgloss.NewStyle().Padding(0, 1)
+		borderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+		rows := [][]string{}
+		for _, s := range saved {
+			rows = append(rows, []string{"@" + s.Name, s.Query})
+		}
+
+		t := table.New().
+			Border(lipgloss.NormalBorder()).
+			BorderStyle(borderStyle).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == table.HeaderRow {
+					return headerStyle
+				}
+				return cellStyle
+			}).
+			Headers("Name", "Query").
+			Rows(rows...)
+
+		cmd.Println(t.Render())
+	},
+}
+
+var savedRunCmd = &cobra.Command{
+	Use:   "run <name>",
+	Short: "List the notes matching a saved search",
+	Long: `List the notes matching a saved search. This is the same as
+'jotcli list @name'.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, err := resolveOutputFormat(cmd)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		filter, err := savedFilter.queryFilter(cmd, []string{"@" + strings.TrimPrefix(args[0], "@")})
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		notes, err := noteStore(cmd).List(cmd.Context(), filter)
+		if err != nil {
+			cmd.Printf("Error retrieving notes: %v\n", err)
+			return
+		}
+
+		printNotes(cmd, notes, format, filter.Sort)
+	},
+}
+
+var savedDeleteCmd = &cobra.Command{
+	Use:     "delete <name>",
+	Aliases: []string{"rm"},
+	Short:   "Delete a saved search",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := strings.TrimPrefix(args[0], "@")
+		if err := noteStore(cmd).DeleteSavedSearch(cmd.Context(), name); err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		cmd.Printf("✅ Saved search @%s deleted\n", name)
+	},
+}
+
+func init() {
+	savedAddCmd.Flags().BoolVarP(&savedForce, "force", "f", false, "Replace a saved search with the same name")
+	savedFilter.register(savedRunCmd, database.SortCreated)
+
+	savedCmd.AddCommand(savedAddCmd, savedListCmd, savedRunCmd, savedDeleteCmd)
+	rootCmd.AddCommand(savedCmd)
+}
@@ -1,43 +1,86 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
-	"github.com/flyme2mars/jotcli/internal/database"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/query"
+	"github.com/flyme2mars/jotcli/internal/ui"
 	"github.com/spf13/cobra"
 )
 
+var searchFilter noteFilterFlags
+
 var searchCmd = &cobra.Command{
 	Use:   "search [query]",
-	Short: "Search for notes containing a query string",
-	Args:  cobra.MinimumNArgs(1),
+	Short: "Full-text search across your notes",
+	Long: `Search note content using SQLite FTS5, with results ranked by relevance.
+
+Supported syntax:
+  apple pie            notes containing both words
+  "apple pie"          the exact phrase
+  app*                 words starting with "app"
+  apple OR banana      either word
+  apple NOT pie        "apple" but not "pie"
+  NEAR(apple pie, 5)   both words within 5 tokens of each other
+
+Fields from the query language narrow the search down further, e.g.
+  jotcli search "deploy tag:work created:>=2026-06"
+
+` + queryHelp,
+	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		query := args[0]
-		
-		sqlQuery := `SELECT id, content, tag, priority, created_at FROM notes WHERE content LIKE ? ORDER BY created_at DESC`
-		rows, err := database.DB.Query(sqlQuery, "%"+query+"%")
+		input := strings.Join(args, " ")
+
+		format, err := resolveOutputFormat(cmd)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		q, err := query.ParseWith(input, query.SavedSearches(cmd.Context(), noteStore(cmd)))
+		if err != nil && (errors.Is(err, query.ErrUnknownField) || !query.UsesFields(input)) {
+			// Not a query after all, e.g. a URL: search for the text as
+			// typed, which never fails on stray punctuation
+			q, err = nil, nil
+		}
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		// Plain searches go to the full-text index as typed, so the whole
+		// FTS5 syntax keeps working; fields and saved searches need the
+		// parsed query
+		filter, err := searchFilter.filter()
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		text := input
+		if q != nil && !q.IsPlainText() {
+			filter.Where, text = q, q.Text()
+		}
+
+		results, err := noteStore(cmd).Search(cmd.Context(), text, filter)
 		if err != nil {
 			cmd.Printf("Error searching notes: %v\n", err)
 			return
 		}
-		defer rows.Close()
-
-		var notes []database.Note
-		for rows.Next() {
-			var n database.Note
-			err := rows.Scan(&n.ID, &n.Content, &n.Tag, &n.Priority, &n.CreatedAt)
-			if err != nil {
-				cmd.Printf("Error scanning result: %v\n", err)
-				return
-			}
-			notes = append(notes, n)
+
+		if len(results) == 0 && isHumanFormat(format) {
+			cmd.Printf("No notes found matching '%s'\n", input)
+			return
 		}
 
-		if len(notes) == 0 {
-			cmd.Printf("No notes found matching '%s'\n", query)
+		if format != formatTable {
+			if err := writeSearchResults(cmd.OutOrStdout(), format, results); err != nil {
+				cmd.Printf("Error writing output: %v\n", err)
+			}
 			return
 		}
 
@@ -45,15 +88,25 @@ var searchCmd = &cobra.Command{
 		cellStyle := lipgloss.NewStyle().Padding(0, 1)
 		borderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 
+		dateHeader := "Created"
+		if searchFilter.sort == database.SortUpdated {
+			dateHeader = "Updated"
+		}
+
 		rowsTable := [][]string{}
-		for _, n := range notes {
-			content := strings.ReplaceAll(n.Content, "\n", " ")
+		for _, r := range results {
+			// A search by fields alone has no text to show a match for
+			snippet := r.Snippet
+			if snippet == "" {
+				snippet = r.DisplayTitle()
+			}
+			snippet = strings.ReplaceAll(snippet, "\n", " ")
 			rowsTable = append(rowsTable, []string{
-				fmt.Sprintf("%d", n.ID),
-				content,
-				n.Tag,
-				n.Priority,
-				n.CreatedAt.Format("2006-01-02"),
+				fmt.Sprintf("%d", r.ID),
+				ui.RenderSnippet(snippet),
+				strings.Join(r.Tags, ", "),
+				r.Priority,
+				noteDate(r.Note, dateHeader),
 			})
 		}
 
@@ -66,7 +119,7 @@ var searchCmd = &cobra.Command{
 				}
 				return cellStyle
 			}).
-			Headers("ID", "Note", "Tag", "Priority", "Created").
+			Headers("ID", "Match", "Tags", "Priority", dateHeader).
 			Rows(rowsTable...)
 
 		cmd.Println(t.Render())
@@ -74,5 +127,6 @@ var searchCmd = &cobra.Command{
 }
 
 func init() {
+	searchFilter.register(searchCmd, database.SortRelevance)
 	rootCmd.AddCommand(searchCmd)
-}
\ No newline at end of file
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/spf13/cobra"
+)
+
+type (
+	storeKey struct{}
+	dbKey    struct{}
+)
+
+// WithStore returns a context carrying the NoteStore commands should read and
+// write notes through. Running the root command with such a context, via
+// ExecuteContext, skips loading the config and opening the configured database.
+func WithStore(ctx context.Context, s database.NoteStore) context.Context {
+	return context.WithValue(ctx, storeKey{}, s)
+}
+
+// noteStore returns the NoteStore set up for the running command.
+func noteStore(cmd *cobra.Command) database.NoteStore {
+	s, _ := cmd.Context().Value(storeKey{}).(database.NoteStore)
+	return s
+}
+
+// WithDB returns a context carrying the SQLite database behind the store, for
+// the commands that work on the database itself rather than on notes, such
+// as backup and doctor.
+func WithDB(ctx context.Context, db *sql.DB) context.Context {
+	return context.WithValue(ctx, dbKey{}, db)
+}
+
+// sqlDB returns the SQLite database set up for the running command, or nil
+// if it was only given a NoteStore.
+func sqlDB(cmd *cobra.Command) *sql.DB {
+	db, _ := cmd.Context().Value(dbKey{}).(*sql.DB)
+	return db
+}
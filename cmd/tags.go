@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeInto         string
+	deleteTaggedNotes bool
+)
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "Manage tags",
+	Long: `List, rename, merge and delete tags.
+
+Tags can be nested with a slash, e.g. work/clientA. Filtering on a parent
+tag such as "work" also matches all of its children.`,
+}
+
+var tagsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all tags with their note counts",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tags, err := noteStore(cmd).Tags(cmd.Context())
+		if err != nil {
+			cmd.Printf("Error retrieving tags: %v\n", err)
+			return
+		}
+
+		if len(tags) == 0 {
+			cmd.Println("No tags found.")
+			return
+		}
+
+		headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true).Padding(0, 1)
+		cellStyle := lipgloss.NewStyle().Padding(0, 1)
+		borderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+		rows := [][]string{}
+		for _, tc := range tags {
+			// Indent children under their parent
+			depth := strings.Count(tc.Name, "/")
+			rows = append(rows, []string{
+				strings.Repeat("  ", depth) + tc.Name,
+				fmt.Sprintf("%d", tc.Count),
+			})
+		}
+
+		t := table.New().
+			Border(lipgloss.NormalBorder()).
+			BorderStyle(borderStyle).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == table.HeaderRow {
+					return headerStyle
+				}
+				return cellStyle
+			}).
+			Headers("Tag", "Notes").
+			Rows(rows...)
+
+		cmd.Println(t.Render())
+	},
+}
+
+var tagsRenameCmd = &cobra.Command{
+	Use:   "rename [old] [new]",
+	Short: "Rename a tag and its children",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := noteStore(cmd).MergeTags(cmd.Context(), args[:1], args[1])
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		cmd.Printf("✅ Tag %s renamed to %s\n", database.NormalizeTag(args[0]), database.NormalizeTag(args[1]))
+	},
+}
+
+var tagsMergeCmd = &cobra.Command{
+	Use:   "merge [tags...] into [target]",
+	Short: "Merge several tags into one",
+	Example: `  jotcli tags merge meeting meetings into meetings
+  jotcli tags merge todo later --into backlog`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sources, target := args, mergeInto
+		if target == "" {
+			if len(args) < 3 || !strings.EqualFold(args[len(args)-2], "into") {
+				cmd.Println("Error: Specify the target tag with 'into [target]' or --into")
+				return
+			}
+			sources, target = args[:len(args)-2], args[len(args)-1]
+		}
+
+		err := noteStore(cmd).MergeTags(cmd.Context(), sources, target)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		cmd.Printf("✅ Merged %s into %s\n", strings.Join(database.ParseTags(sources), ", "), database.NormalizeTag(target))
+	},
+}
+
+var tagsDeleteCmd = &cobra.Command{
+	Use:   "delete [tag]",
+	Short: "Delete a tag and its children",
+	Long: `Delete a tag and its children.
+
+By default the tag is only removed from its notes. Pass --notes to delete
+the tagged notes as well.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		count, err := noteStore(cmd).DeleteTag(cmd.Context(), args[0], deleteTaggedNotes)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		name := database.NormalizeTag(args[0])
+		if deleteTaggedNotes {
+			cmd.Printf("✅ Tag %s deleted along with %d note(s)\n", name, count)
+		} else {
+			cmd.Printf("✅ Tag %s removed from %d note(s)\n", name, count)
+		}
+	},
+}
+
+func init() {
+	tagsMergeCmd.Flags().StringVar(&mergeInto, "into", "", "Tag to merge into")
+	tagsDeleteCmd.Flags().BoolVar(&deleteTaggedNotes, "notes", false, "Also delete the notes carrying the tag")
+
+	tagsCmd.AddCommand(tagsListCmd, tagsRenameCmd, tagsMergeCmd, tagsDeleteCmd)
+	rootCmd.AddCommand(tagsCmd)
+}
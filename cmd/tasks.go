@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/markdown"
+	"github.com/spf13/cobra"
+)
+
+var todoFilter noteFilterFlags
+
+var todoCmd = &cobra.Command{
+	Use:   "todo [query]",
+	Short: "List open tasks",
+	Long: `List the notes with an open or doing status, highest priority first,
+optionally narrowed down by a query. Make a note a task with
+'jotcli add --status open' or 'jotcli status <note> open'.
+
+` + queryHelp,
+	Example: `  jotcli todo
+  jotcli todo tag:work
+  jotcli todo --sort due`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, err := resolveOutputFormat(cmd)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		filter, err := todoFilter.queryFilter(cmd, args)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		filter.Statuses = []string{database.StatusOpen, database.StatusDoing}
+
+		notes, err := noteStore(cmd).List(cmd.Context(), filter)
+		if err != nil {
+			cmd.Printf("Error retrieving notes: %v\n", err)
+			return
+		}
+
+		if !isHumanFormat(format) {
+			if err := writeNotes(cmd.OutOrStdout(), format, notes); err != nil {
+				cmd.Printf("Error writing output: %v\n", err)
+			}
+			return
+		}
+		if len(notes) == 0 {
+			cmd.Println("Nothing to do.")
+			return
+		}
+		now := time.Now()
+		for _, n := range notes {
+			cmd.Println(taskLine(n, now))
+		}
+	},
+}
+
+var doneCmd = &cobra.Command{
+	Use:   "done <note...>",
+	Short: "Mark tasks as done",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, arg := range args {
+			id, err := resolveNoteID(cmd, arg)
+			if err != nil {
+				cmd.Printf("Error: %v\n", err)
+				continue
+			}
+
+			next, err := noteStore(cmd).SetStatus(cmd.Context(), id, database.StatusDone)
+			if err != nil {
+				cmd.Printf("Error: %v\n", err)
+				continue
+			}
+
+			cmd.Printf("✅ Note %d done\n", id)
+			printNextOccurrence(cmd, next)
+		}
+	},
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status <note> <status>",
+	Short: "Set the task status of a note",
+	Long: `Set the task status of a note to open, doing, done or cancelled, or to none
+to make it a plain note again.`,
+	Example: `  jotcli status 12 doing
+  jotcli status 12 none`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := resolveNoteID(cmd, args[0])
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		status, err := parseStatusArg(args[1])
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		next, err := noteStore(cmd).SetStatus(cmd.Context(), id, status)
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		if status == "" {
+			cmd.Printf("✅ Note %d is no longer a task\n", id)
+			return
+		}
+		cmd.Printf("✅ Note %d is %s\n", id, status)
+		printNextOccurrence(cmd, next)
+	},
+}
+
+// parseStatusArg is database.ParseStatus, also accepting none for plain notes.
+func parseStatusArg(s string) (string, error) {
+	if s == "" || strings.EqualFold(s, "none") {
+		return "", nil
+	}
+	return database.ParseStatus(s)
+}
+
+// taskLine is noteLine for a task, with its checklist progress and due date.
+func taskLine(n database.Note, now time.Time) string {
+	line := noteLine(n, database.StatusMarks[n.Status])
+	if done, total := markdown.Progress(n.Content); total > 0 {
+		line += fmt.Sprintf("  %d/%d", done, total)
+	}
+	if n.DueAt != nil {
+		line += "  due " + formatDue(*n.DueAt, now)
+	}
+	return line
+}
+
+func init() {
+	todoFilter.register(todoCmd, database.SortPriority)
+	rootCmd.AddCommand(todoCmd, doneCmd, statusCmd)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var clearTitle bool
+
+var titleCmd = &cobra.Command{
+	Use:   "title <note> [title]",
+	Short: "Show or set a note's title",
+	Long: `Show a note's title and slug, or give it a new title. A note without a
+title goes by its first line. Its slug follows the new title, and any
+[[links]] to the title start working.
+
+Commands that take a note accept its ID, its slug, or the start of its
+title, e.g. 'jotcli edit sprint-planning' or 'jotcli done "spr plan"'. Quote
+titles of more than one word: each argument of done or delete is a note.`,
+	Example: `  jotcli title 12 "Sprint planning"
+  jotcli title sprint-planning
+  jotcli title sprint-planning --clear`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		note, err := resolveNote(cmd, args[0])
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		title := strings.Join(args[1:], " ")
+		if title == "" && !clearTitle {
+			if note.Title == "" {
+				cmd.Printf("Note %d has no title; it goes by %q\n", note.ID, note.DisplayTitle())
+			} else {
+				cmd.Printf("Note %d: %s\n", note.ID, note.Title)
+			}
+			cmd.Printf("Slug: %s\n", note.Slug)
+			return
+		}
+		if title != "" && clearTitle {
+			cmd.Println("Error: Give a title or --clear, not both")
+			return
+		}
+
+		store := noteStore(cmd)
+		if err := store.SetTitle(cmd.Context(), note.ID, title); err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		updated, err := store.Get(cmd.Context(), note.ID)
+		if err != nil || updated == nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+		if title == "" {
+			cmd.Printf("✅ Title of note %d cleared (slug %s)\n", note.ID, updated.Slug)
+			return
+		}
+		cmd.Printf("✅ Note %d is now titled %q (slug %s)\n", note.ID, updated.Title, updated.Slug)
+	},
+}
+
+func init() {
+	titleCmd.Flags().BoolVar(&clearTitle, "clear", false, "Remove the title so the note goes by its first line")
+	rootCmd.AddCommand(titleCmd)
+}
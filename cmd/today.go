@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/dates"
+	"github.com/flyme2mars/jotcli/internal/markdown"
+	"github.com/spf13/cobra"
+)
+
+var todayList bool
+
+var todayCmd = &cobra.Command{
+	Use:   "today [query]",
+	Short: "Open today's journal entry, or show the notes jotted today",
+	Long: `Open today's journal entry in your editor, creating it if needed. See
+'jotcli journal' for other days.
+
+With --list, show the notes created today instead, oldest first,
+optionally narrowed down by a query. Use 'jotcli list --on <date>' for
+other days.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !todayList {
+			if len(args) > 0 {
+				cmd.Println("Error: A query needs --list")
+				return
+			}
+			editJournal(cmd, time.Now())
+			return
+		}
+		start := dates.StartOfDay(time.Now())
+		printPeriod(cmd, args, start, start.AddDate(0, 0, 1))
+	},
+}
+
+var weekCmd = &cobra.Command{
+	Use:   "week [query]",
+	Short: "Show the notes jotted in the last 7 days, by day",
+	Long: `Show the notes created in the last 7 days, including today, grouped by
+day and oldest first. A query narrows them down, e.g. 'jotcli week tag:work'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		end := dates.StartOfDay(time.Now()).AddDate(0, 0, 1)
+		printPeriod(cmd, args, end.AddDate(0, 0, -7), end)
+	},
+}
+
+// printPeriod prints the notes created in [since, until) that match a query,
+// grouped by day.
+func printPeriod(cmd *cobra.Command, args []string, since, until time.Time) {
+	format, err := resolveOutputFormat(cmd)
+	if err != nil {
+		cmd.Printf("Error: %v\n", err)
+		return
+	}
+
+	f := noteFilterFlags{sort: database.SortCreated, reverse: true}
+	filter, err := f.queryFilter(cmd, args)
+	if err != nil {
+		cmd.Printf("Error: %v\n", err)
+		return
+	}
+	filter.Since, filter.Until = since, until
+
+	notes, err := noteStore(cmd).List(cmd.Context(), filter)
+	if err != nil {
+		cmd.Printf("Error retrieving notes: %v\n", err)
+		return
+	}
+
+	if !isHumanFormat(format) {
+		if err := writeNotes(cmd.OutOrStdout(), format, notes); err != nil {
+			cmd.Printf("Error writing output: %v\n", err)
+		}
+		return
+	}
+	if len(notes) == 0 {
+		cmd.Println("No notes found.")
+		return
+	}
+	printByDay(cmd, notes)
+}
+
+// printByDay prints notes under a heading for each day, one line per note.
+func printByDay(cmd *cobra.Command, notes []database.Note) {
+	var current string
+	for _, n := range notes {
+		day := n.CreatedAt.Format("Monday, 2 January 2006")
+		if day != current {
+			if current != "" {
+				cmd.Println()
+			}
+			cmd.Println(day)
+			current = day
+		}
+
+		cmd.Println(noteLine(n, n.CreatedAt.Format("15:04")))
+	}
+}
+
+// noteLine is a one-line summary of a note: its ID, a time, its first line
+// and its tags.
+func noteLine(n database.Note, when string) string {
+	line := fmt.Sprintf("  #%d  %s  %s", n.ID, when, markdown.FirstLine(n.Content))
+	if len(n.Tags) > 0 {
+		line += "  [" + strings.Join(n.Tags, ", ") + "]"
+	}
+	return line
+}
+
+func init() {
+	todayCmd.Flags().BoolVarP(&todayList, "list", "l", false, "List the notes jotted today instead of opening the journal")
+	rootCmd.AddCommand(todayCmd, weekCmd)
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/flyme2mars/jotcli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var emptyTrashYes bool
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Inspect and empty the trash",
+	Long: `Deleted notes are kept in the trash so they can be restored.
+
+Notes are purged automatically once they have been in the trash for longer
+than trash_retention_days (30 by default) in ~/.jotcli.yaml.`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List notes in the trash",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		notes, err := noteStore(cmd).Trash(cmd.Context())
+		if err != nil {
+			cmd.Printf("Error retrieving trash: %v\n", err)
+			return
+		}
+
+		if len(notes) == 0 {
+			cmd.Println("Trash is empty.")
+			return
+		}
+
+		headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true).Padding(0, 1)
+		cellStyle := lipgloss.NewStyle().Padding(0, 1)
+		borderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+		rows := [][]string{}
+		for _, n := range notes {
+			content := truncate(strings.ReplaceAll(n.Content, "\n", " "), 50)
+			rows = append(rows, []string{
+				fmt.Sprintf("%d", n.ID),
+				content,
+				strings.Join(n.Tags, ", "),
+				n.DeletedAt.Format("2006-01-02 15:04"),
+			})
+		}
+
+		t := table.New().
+			Border(lipgloss.NormalBorder()).
+			BorderStyle(borderStyle).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == table.HeaderRow {
+					return headerStyle
+				}
+				return cellStyle
+			}).
+			Headers("ID", "Note", "Tags", "Deleted").
+			Rows(rows...)
+
+		cmd.Println(t.Render())
+		if days := int(config.GetTrashRetention().Hours() / 24); days > 0 {
+			cmd.Printf("Notes are permanently removed %d days after deletion.\n", days)
+		}
+	},
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently delete everything in the trash",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !emptyTrashYes {
+			cmd.Print("Permanently delete all notes in the trash? [y/N] ")
+			answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if answer != "y" && answer != "yes" {
+				cmd.Println("Aborted.")
+				return
+			}
+		}
+
+		count, err := noteStore(cmd).PurgeTrash(cmd.Context(), time.Time{})
+		if err != nil {
+			cmd.Printf("Error: %v\n", err)
+			return
+		}
+
+		cmd.Printf("✅ Permanently deleted %d note(s)\n", count)
+	},
+}
+
+var restoreYes bool
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <note>... | <backup-file>",
+	Short: "Restore notes from the trash, or the database from a backup",
+	Long: `Restore notes from the trash by ID, slug or the start of their title.
+
+Given the path of a file written by "jotcli backup" instead, replace the whole
+database with that backup. The backup is checked first, and the current
+database is saved to the backup directory before it is replaced. A single
+argument naming an existing file is always taken as a backup, even if it
+could also be a note's slug or title.`,
+	Example: `  jotcli restore 5 7
+  jotcli restore sprint-planning
+  jotcli restore ~/.jot-backups/jot-20261001-090000.db`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// A file wins over a note with the same slug or title
+		if isBackupFile(args) {
+			restoreBackup(cmd, args[0])
+			return
+		}
+
+		for _, arg := range args {
+			id, err := resolveTrashedNoteID(cmd, arg)
+			if err != nil {
+				cmd.Printf("Error: %v\n", err)
+				continue
+			}
+
+			if err := noteStore(cmd).Restore(cmd.Context(), id); err != nil {
+				cmd.Printf("Error: %v\n", err)
+				continue
+			}
+
+			cmd.Printf("✅ Note %d restored\n", id)
+		}
+	},
+}
+
+func init() {
+	trashEmptyCmd.Flags().BoolVarP(&emptyTrashYes, "yes", "y", false, "Don't ask for confirmation")
+	restoreCmd.Flags().BoolVarP(&restoreYes, "yes", "y", false, "Don't ask for confirmation when restoring a backup")
+
+	trashCmd.AddCommand(trashListCmd, trashEmptyCmd)
+	rootCmd.AddCommand(trashCmd, restoreCmd)
+}
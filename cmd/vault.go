@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/flyme2mars/jotcli/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vaultFolder string
+	vaultPrefer string
+)
+
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "Keep notes in sync with an Obsidian vault",
+}
+
+var vaultSyncCmd = &cobra.Command{
+	Use:   "sync <vault-dir>",
+	Short: "Sync notes with a folder of an Obsidian vault",
+	Long: `Mirror every note into a folder of an Obsidian vault as Markdown with
+front matter, and read back edits made in Obsidian.
+
+Tags are written to the front matter and #hashtags work on both sides.
+Note links like [[12]] become links to the note's file, e.g. [[12-groceries]],
+and links to synced files are turned back into note links.
+
+Notes created in the vault folder are added to jot; deleting a file moves
+its note to the trash, and deleting a note removes its file. When a note
+changed in both places since the last sync it is reported as a conflict and
+left alone; re-run with --prefer jot or --prefer vault to settle it.`,
+	Example: `  jotcli vault sync ~/Obsidian/Main
+  jotcli vault sync ~/Obsidian/Main --folder Inbox/jot
+  jotcli vault sync ~/Obsidian/Main --prefer vault`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		res, err := vault.Sync(cmd.Context(), noteStore(cmd), args[0], vault.Options{
+			Folder: vaultFolder,
+			Prefer: strings.ToLower(vaultPrefer),
+		})
+		if err != nil {
+			cmd.Printf("Error syncing vault: %v\n", err)
+			return
+		}
+
+		var parts []string
+		for _, c := range []struct {
+			n     int
+			label string
+		}{
+			{res.Exported, "exported"},
+			{res.Imported, "imported"},
+			{res.Pushed, "updated in the vault"},
+			{res.Pulled, "updated from the vault"},
+			{res.Trashed, "moved to the trash"},
+			{res.Removed, "removed from the vault"},
+			{res.Resolved, "conflict(s) resolved"},
+		} {
+			if c.n > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", c.n, c.label))
+			}
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "everything up to date")
+		}
+		cmd.Printf("✅ Synced with %s (%s)\n", filepath.Join(args[0], vaultFolder), strings.Join(parts, ", "))
+
+		if len(res.Conflicts) > 0 {
+			cmd.Printf("%d conflict(s) left untouched:\n", len(res.Conflicts))
+			for _, c := range res.Conflicts {
+				cmd.Printf("  #%d %s: %s\n", c.NoteID, c.Path, c.Reason)
+			}
+			cmd.Println("Re-run with --prefer jot or --prefer vault to resolve them.")
+		}
+	},
+}
+
+func init() {
+	vaultSyncCmd.Flags().StringVar(&vaultFolder, "folder", vault.DefaultFolder, "Folder inside the vault that holds jot's notes")
+	vaultSyncCmd.Flags().StringVar(&vaultPrefer, "prefer", "", "Resolve conflicts in favour of jot or vault")
+	vaultCmd.AddCommand(vaultSyncCmd)
+	rootCmd.AddCommand(vaultCmd)
+}
@@ -13,7 +13,7 @@ var viewCmd = &cobra.Command{
 	Use:   "view",
 	Short: "Interactive view of your notes",
 	Run: func(cmd *cobra.Command, args []string) {
-		p := tea.NewProgram(ui.InitialModel())
+		p := tea.NewProgram(ui.NewModel(cmd.Context(), noteStore(cmd)))
 		if _, err := p.Run(); err != nil {
 			fmt.Printf("Alas, there's been an error: %v", err)
 			os.Exit(1)
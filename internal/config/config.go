@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -17,6 +18,11 @@ func InitConfig() error {
 	defaultDBPath := filepath.Join(home, ".jot.db")
 	viper.SetDefault("database", defaultDBPath)
 	viper.SetDefault("editor", "vim")
+	viper.SetDefault("trash_retention_days", 30)
+	viper.SetDefault("backup_dir", filepath.Join(home, ".jot-backups"))
+	viper.SetDefault("backup_keep", 10)
+	viper.SetDefault("remind_command", "")
+	viper.SetDefault("templates_dir", filepath.Join(home, ".jot-templates"))
 
 	// 2. Set config file details
 	viper.SetConfigName(".jotcli") // Name: ~/.jotcli.yaml
@@ -50,3 +56,31 @@ func GetEditor() string {
 	}
 	return viper.GetString("editor")
 }
+
+// GetTrashRetention is how long deleted notes stay in the trash before being
+// purged for good. Zero disables automatic purging.
+func GetTrashRetention() time.Duration {
+	return time.Duration(viper.GetInt("trash_retention_days")) * 24 * time.Hour
+}
+
+// GetBackupDir is where `jotcli backup` writes timestamped backups.
+func GetBackupDir() string {
+	return viper.GetString("backup_dir")
+}
+
+// GetBackupKeep is how many timestamped backups to keep. Zero keeps them all.
+func GetBackupKeep() int {
+	return viper.GetInt("backup_keep")
+}
+
+// GetRemindCommand is the shell command `jotcli remind --check` runs for each
+// reminder. Empty means reminders are printed instead.
+func GetRemindCommand() string {
+	return viper.GetString("remind_command")
+}
+
+// GetTemplatesDir is where `jotcli add --template` and `jotcli new` look for
+// note templates.
+func GetTemplatesDir() string {
+	return viper.GetString("templates_dir")
+}
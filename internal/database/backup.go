@@ -0,0 +1,164 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// backupTimeFormat stamps backup file names so they sort chronologically.
+const backupTimeFormat = "20060102-150405"
+
+// BackupName returns the file name of a timestamped backup taken at t.
+func BackupName(t time.Time) string {
+	return "jot-" + t.Format(backupTimeFormat) + ".db"
+}
+
+// Backup writes a consistent snapshot of db to path using VACUUM INTO,
+// which is safe while other processes are using the database.
+func Backup(db *sql.DB, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("could not back up database: %s already exists", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not back up database: %v", err)
+	}
+	if _, err := db.Exec(`VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("could not back up database: %v", err)
+	}
+	return nil
+}
+
+// ListBackups returns the paths of the timestamped backups in dir, newest first.
+func ListBackups(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "jot-*.db"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths, nil
+}
+
+// RotateBackups deletes all but the newest keep backups in dir and returns
+// the paths it removed. A keep of zero or less keeps everything.
+func RotateBackups(dir string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	paths, err := ListBackups(dir)
+	if err != nil || len(paths) <= keep {
+		return nil, err
+	}
+
+	var removed []string
+	for _, p := range paths[keep:] {
+		if err := os.Remove(p); err != nil {
+			return removed, err
+		}
+		removed = append(removed, p)
+	}
+	return removed, nil
+}
+
+// CheckBackup makes sure a file can be restored: it must be an intact jot
+// database with a schema no newer than this binary understands. It returns
+// the backup's schema version.
+func CheckBackup(path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, err
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow(`PRAGMA integrity_check`).Scan(&result); err != nil {
+		return 0, fmt.Errorf("%s is not a jot database: %v", path, err)
+	}
+	if result != "ok" {
+		return 0, fmt.Errorf("%s is damaged: %s", path, result)
+	}
+
+	var tables int
+	err = db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'notes'`).Scan(&tables)
+	if err != nil {
+		return 0, err
+	}
+	if tables == 0 {
+		return 0, fmt.Errorf("%s is not a jot database", path)
+	}
+
+	version, err := UserVersion(db)
+	if err != nil {
+		return 0, err
+	}
+	if version > SchemaVersion() {
+		return version, fmt.Errorf("%w (backup is at version %d, jotcli supports up to %d)", ErrSchemaTooNew, version, SchemaVersion())
+	}
+	return version, nil
+}
+
+// RestoreBackup replaces the database file at dst with the backup at src
+// after checking it with CheckBackup. The database at dst must be closed;
+// an older backup is brought up to date by the next Migrate.
+func RestoreBackup(src, dst string) error {
+	if _, err := CheckBackup(src); err != nil {
+		return err
+	}
+
+	tmp := dst + ".restore"
+	if err := copyFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("could not restore backup: %v", err)
+	}
+	// Leftover journal files belong to the database being replaced
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(dst + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			os.Remove(tmp)
+			return fmt.Errorf("could not restore backup: %v", err)
+		}
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("could not restore backup: %v", err)
+	}
+	return nil
+}
+
+// CopyDatabaseFile copies the database file at src to dst as-is. It is a
+// fallback for keeping a copy of a database too damaged to open.
+func CopyDatabaseFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return copyFile(src, dst)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
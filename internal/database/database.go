@@ -1,47 +1,135 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/flyme2mars/jotcli/internal/config"
 	_ "modernc.org/sqlite"
 )
 
+// ErrNoteNotFound is returned when an operation targets a note that doesn't
+// exist (or, for trash operations, isn't in the expected state).
+var ErrNoteNotFound = errors.New("note not found")
+
+// Note is a single note. The JSON field names are part of jotcli's output
+// format (list/search --output json), so treat them as a stable interface.
 type Note struct {
-	ID        int
-	Content   string
-	Tag       string
-	Priority  string
-	CreatedAt time.Time
+	ID          int        `json:"id"`
+	Content     string     `json:"content"`
+	Tags        []string   `json:"tags"`
+	Priority    string     `json:"priority"`
+	Status      string     `json:"status,omitempty"` // one of the Status* constants, or empty for plain notes
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // set while the note is in the trash
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	NotifiedAt  *time.Time `json:"notified_at,omitempty"`  // set once a reminder for DueAt has gone out
+	Recurrence  string     `json:"recurrence,omitempty"`   // an RRULE from the recur package
+	JournalDate string     `json:"journal_date,omitempty"` // the day of a journal entry, as YYYY-MM-DD
+	Title       string     `json:"title,omitempty"`        // set explicitly; see DisplayTitle
+	Slug        string     `json:"slug,omitempty"`         // unique, for addressing the note by name
+}
+
+// NoteFilter narrows down and orders the notes returned by GetNotes and
+// SearchNotes.
+type NoteFilter struct {
+	Tags      []string
+	MatchAll  bool   // require every tag instead of any of them
+	Sort      string // one of the Sort* constants; empty means the default
+	Reverse   bool
+	Since     time.Time // only notes created at or after this time
+	Until     time.Time // only notes created before this time
+	DueBefore time.Time // only notes due before this time
+	Statuses  []string  // only notes with one of these statuses; "" stands for plain notes
+	Recurring bool      // only notes that repeat
+	Journal   bool      // only journal entries
+	Where     Condition // an extra condition, such as a parsed query
+}
+
+// TimestampPrefix is the layout of the start of the timestamps stored in the
+// notes table. Comparing that much of them as strings orders notes by the
+// local time they were written at, which lets SQL filter on dates.
+const TimestampPrefix = "2006-01-02 15:04:05"
+
+// Condition is an extra test notes must pass, such as a query from the query
+// package. SQL returns the condition for a notes table aliased as n, and
+// Match is the same test for a note in memory.
+type Condition interface {
+	SQL() (string, []any)
+	Match(n Note) bool
 }
 
 var DB *sql.DB
 
+// noteColumns selects everything needed by scanNote from a notes table
+// aliased as n, with the note's tags collapsed into a sorted, comma-separated list.
+const noteColumns = `n.id, n.content, n.priority, n.status, n.created_at, n.updated_at, n.deleted_at, n.due_at, n.notified_at, n.recurrence, COALESCE(n.journal_date, ''), n.title, COALESCE(n.slug, ''),
+	COALESCE((SELECT group_concat(name, ',') FROM (
+		SELECT t.name FROM note_tags nt JOIN tags t ON t.id = nt.tag_id
+		WHERE nt.note_id = n.id ORDER BY t.name
+	)), '')`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanNote(s scanner, extra ...any) (Note, error) {
+	var n Note
+	var tags string
+	var deletedAt, dueAt, notifiedAt sql.NullTime
+	dest := append([]any{&n.ID, &n.Content, &n.Priority, &n.Status, &n.CreatedAt, &n.UpdatedAt, &deletedAt, &dueAt, &notifiedAt, &n.Recurrence, &n.JournalDate, &n.Title, &n.Slug, &tags}, extra...)
+	if err := s.Scan(dest...); err != nil {
+		return n, err
+	}
+	n.DeletedAt = nullTime(deletedAt)
+	n.DueAt = nullTime(dueAt)
+	n.NotifiedAt = nullTime(notifiedAt)
+	if tags != "" {
+		n.Tags = strings.Split(tags, ",")
+	}
+	return n, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
 func InitDB() error {
 	dbPath := config.GetDBPath()
-	
+
 	var err error
 	DB, err = OpenDB(dbPath)
 	if err != nil {
 		return err
 	}
 
-	createTableSQL := `CREATE TABLE IF NOT EXISTS notes (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		content TEXT NOT NULL,
-		tag TEXT,
-		priority TEXT,
-		created_at DATETIME
-	);`
-
-	_, err = DB.Exec(createTableSQL)
-	return err
+	return Migrate(DB)
 }
 
+// connectionParams are applied to every connection OpenDB makes:
+//   - WAL journaling lets the TUI keep reading while a shell hook adds notes,
+//     and survives interruption better than a rollback journal.
+//   - busy_timeout makes a writer wait for the lock instead of failing with
+//     SQLITE_BUSY straight away.
+//   - Immediate transactions take the write lock up front, so two writers
+//     queue on busy_timeout rather than deadlocking when both try to upgrade
+//     a read lock.
+//   - Foreign keys are off by default in SQLite.
+const connectionParams = "?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=foreign_keys(1)&_txlock=immediate"
+
+// maxOpenConns bounds the connection pool. SQLite has a single writer
+// anyway; a few connections let reads carry on alongside it.
+const maxOpenConns = 4
+
 func OpenDB(path string) (*sql.DB, error) {
 	// Ensure the directory for the database exists (important for cloud folders!)
 	dir := filepath.Dir(path)
@@ -51,50 +139,140 @@ func OpenDB(path string) (*sql.DB, error) {
 			return nil, err
 		}
 	}
-	return sql.Open("sqlite", path)
-}
 
-func AddNote(content, tag, priority string) error {
-	query := `INSERT INTO notes (content, tag, priority, created_at) VALUES (?, ?, ?, ?)`
-	_, err := DB.Exec(query, content, tag, priority, time.Now())
+	db, err := sql.Open("sqlite", path+connectionParams)
 	if err != nil {
-		return fmt.Errorf("could not save note: %v", err)
+		return nil, err
 	}
-	return nil
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxOpenConns)
+	return db, nil
 }
 
-func GetNotes(tagFilter string) ([]Note, error) {
-	var rows *sql.Rows
-	var err error
+// NewNote describes a note to be created. Zero timestamps default to now.
+type NewNote struct {
+	Content     string
+	Tags        []string
+	Priority    string
+	Status      string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DueAt       *time.Time
+	Recurrence  string
+	JournalDate string // makes the note the journal entry for a day, as YYYY-MM-DD
+	Title       string // optional; the first line stands in for it
+}
+
+// AddNote saves a new note. Any #hashtags in the content are added to tags.
+func AddNote(content string, tags []string, priority string) error {
+	_, err := CreateNote(NewNote{Content: content, Tags: tags, Priority: priority})
+	return err
+}
+
+// CreateNote saves a new note and returns its ID.
+func CreateNote(n NewNote) (int, error) {
+	return defaultStore().Add(context.Background(), n)
+}
 
-	if tagFilter != "" {
-		query := `SELECT id, content, tag, priority, created_at FROM notes WHERE tag = ? ORDER BY created_at DESC`
-		rows, err = DB.Query(query, tagFilter)
-	} else {
-		query := `SELECT id, content, tag, priority, created_at FROM notes ORDER BY created_at DESC`
-		rows, err = DB.Query(query)
+// insertNote creates a note with its first revision, tags and links,
+// returning its ID.
+func insertNote(tx *sql.Tx, n NewNote) (int, error) {
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	if n.UpdatedAt.IsZero() {
+		n.UpdatedAt = n.CreatedAt
 	}
 
+	var journalDate any
+	if n.JournalDate != "" {
+		journalDate = n.JournalDate
+	}
+	n.Title = strings.TrimSpace(n.Title)
+	slug, err := uniqueSlug(displayTitle(n.Title, n.Content), slugTaken(tx, 0))
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer rows.Close()
+	query := `INSERT INTO notes (content, priority, status, created_at, updated_at, due_at, recurrence, journal_date, title, slug)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := tx.Exec(query, n.Content, n.Priority, n.Status, n.CreatedAt, n.UpdatedAt, n.DueAt, n.Recurrence, journalDate, n.Title, slug)
+	if err != nil {
+		return 0, err
+	}
+	id64, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	id := int(id64)
 
-	var notes []Note
-	for rows.Next() {
-		var n Note
-		err := rows.Scan(&n.ID, &n.Content, &n.Tag, &n.Priority, &n.CreatedAt)
-		if err != nil {
-			return nil, err
+	if err := addRevision(tx, id, n.Content, n.UpdatedAt); err != nil {
+		return 0, err
+	}
+	if err := addNoteTags(tx, id, append(ParseTags(n.Tags), ExtractHashtags(n.Content)...)); err != nil {
+		return 0, err
+	}
+	if err := setLinks(tx, id, n.Content); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetNotes returns the notes matching filter, newest first unless the
+// filter says otherwise. Notes in the trash are never included.
+func GetNotes(filter NoteFilter) ([]Note, error) {
+	return defaultStore().List(context.Background(), filter)
+}
+
+// where builds the WHERE clause (without the keyword) for notes aliased as n.
+func (f NoteFilter) where() (string, []any) {
+	conds := []string{"n.deleted_at IS NULL"}
+	var args []any
+
+	if tags := ParseTags(f.Tags); len(tags) > 0 {
+		cond, condArgs := tagCondition(tags, f.MatchAll)
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+	}
+
+	if !f.Since.IsZero() {
+		conds = append(conds, "substr(n.created_at, 1, 19) >= ?")
+		args = append(args, f.Since.Format(TimestampPrefix))
+	}
+	if !f.Until.IsZero() {
+		conds = append(conds, "substr(n.created_at, 1, 19) < ?")
+		args = append(args, f.Until.Format(TimestampPrefix))
+	}
+
+	if !f.DueBefore.IsZero() {
+		conds = append(conds, "substr(n.due_at, 1, 19) < ?")
+		args = append(args, f.DueBefore.Format(TimestampPrefix))
+	}
+
+	if len(f.Statuses) > 0 {
+		conds = append(conds, "n.status IN (?"+strings.Repeat(", ?", len(f.Statuses)-1)+")")
+		for _, s := range f.Statuses {
+			args = append(args, s)
 		}
-		notes = append(notes, n)
 	}
-	return notes, nil
+
+	if f.Recurring {
+		conds = append(conds, "n.recurrence != ''")
+	}
+	if f.Journal {
+		conds = append(conds, "n.journal_date IS NOT NULL")
+	}
+
+	if f.Where != nil {
+		cond, condArgs := f.Where.SQL()
+		conds = append(conds, "("+cond+")")
+		args = append(args, condArgs...)
+	}
+
+	return strings.Join(conds, " AND "), args
 }
 
-func GetNotesBySearch(query string) ([]Note, error) {
-	sqlQuery := `SELECT id, content, tag, priority, created_at FROM notes WHERE content LIKE ? ORDER BY created_at DESC`
-	rows, err := DB.Query(sqlQuery, "%"+query+"%")
+func queryNotes(ctx context.Context, db *sql.DB, query string, args ...any) ([]Note, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -102,42 +280,40 @@ func GetNotesBySearch(query string) ([]Note, error) {
 
 	var notes []Note
 	for rows.Next() {
-		var n Note
-		err := rows.Scan(&n.ID, &n.Content, &n.Tag, &n.Priority, &n.CreatedAt)
+		n, err := scanNote(rows)
 		if err != nil {
 			return nil, err
 		}
 		notes = append(notes, n)
 	}
-	return notes, nil
+	return notes, rows.Err()
 }
 
+// GetNoteByID returns the note with the given ID, or nil if there is no such
+// note or it is in the trash.
 func GetNoteByID(id int) (*Note, error) {
-	query := `SELECT id, content, tag, priority, created_at FROM notes WHERE id = ?`
-	row := DB.QueryRow(query, id)
-
-	var n Note
-	err := row.Scan(&n.ID, &n.Content, &n.Tag, &n.Priority, &n.CreatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return &n, nil
+	return defaultStore().Get(context.Background(), id)
 }
 
+// UpdateNote replaces a note's content, adding any new #hashtags it contains.
+// The new content is recorded as a revision; saving unchanged content is a no-op.
 func UpdateNote(id int, content string) error {
-	query := `UPDATE notes SET content = ? WHERE id = ?`
-	_, err := DB.Exec(query, content, id)
-	return err
+	return defaultStore().Update(context.Background(), id, content)
 }
 
+// DeleteNote moves a note to the trash. It can be brought back with
+// RestoreNote until the trash is emptied or purged.
 func DeleteNote(id int) error {
-	query := `DELETE FROM notes WHERE id = ?`
-	_, err := DB.Exec(query, id)
-	if err != nil {
-		return fmt.Errorf("could not delete note: %v", err)
-	}
-	return nil
+	return defaultStore().Delete(context.Background(), id)
+}
+
+// SetNotePriority changes the priority of a note.
+func SetNotePriority(id int, priority string) error {
+	return defaultStore().SetPriority(context.Background(), id, priority)
+}
+
+func (s *SQLiteStore) SetPriority(ctx context.Context, id int, priority string) error {
+	query := `UPDATE notes SET priority = ?, updated_at = ? WHERE id = ? AND priority IS NOT ?`
+	_, err := s.db.ExecContext(ctx, query, priority, time.Now(), id, priority)
+	return err
 }
@@ -1,34 +1,43 @@
 package database
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
+// setupTestDB points DB at a fresh file and runs the real migrations on it,
+// so tests always exercise the same schema as the binary.
+func setupTestDB(t *testing.T, path string) {
+	t.Helper()
+
+	var err error
+	DB, err = OpenDB(path)
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := Migrate(DB); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+}
+
 func TestAddAndGetNotes(t *testing.T) {
 	// 1. Setup: Use a temporary database for testing
 	tempDB := "test_jot.db"
 	// Ensure cleanup after test
 	defer os.Remove(tempDB)
 
-	// Mock the DB initialization logic for test
-	var err error
-	DB, err = OpenDB(tempDB)
-	if err != nil {
-		t.Fatalf("Failed to open test database: %v", err)
-	}
+	setupTestDB(t, tempDB)
 	defer DB.Close()
 
-	// Ensure table exists
-	createTableSQL := `CREATE TABLE IF NOT EXISTS notes (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		content TEXT NOT NULL,
-		tag TEXT,
-		priority TEXT,
-		created_at DATETIME
-	);`
-	DB.Exec(createTableSQL)
-
 	// 2. Test Cases (Table-Driven)
 	tests := []struct {
 		name     string
@@ -44,14 +53,14 @@ func TestAddAndGetNotes(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Test AddNote
-			err := AddNote(tt.content, tt.tag, tt.priority)
+			err := AddNote(tt.content, []string{tt.tag}, tt.priority)
 			if err != nil {
 				t.Errorf("AddNote() error = %v", err)
 			}
 
 			// Test retrieval
 			// We use empty string to get all notes for verification
-			notes, err := GetNotes("")
+			notes, err := GetNotes(NoteFilter{})
 			if err != nil {
 				t.Errorf("GetNotes() error = %v", err)
 			}
@@ -75,6 +84,99 @@ func TestDeleteNote(t *testing.T) {
 	tempDB := "test_delete.db"
 	defer os.Remove(tempDB)
 
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	// Add a note to delete
+	err := AddNote("Delete me", []string{"trash"}, "low")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	notes, _ := GetNotes(NoteFilter{})
+	if len(notes) != 1 {
+		t.Fatalf("Expected 1 note, got %d", len(notes))
+	}
+
+	// Delete it
+	err = DeleteNote(notes[0].ID)
+	if err != nil {
+		t.Errorf("DeleteNote() error = %v", err)
+	}
+
+	// Verify it's gone
+	notes, _ = GetNotes(NoteFilter{})
+	if len(notes) != 0 {
+		t.Errorf("Note was not deleted, still have %d notes", len(notes))
+	}
+}
+
+func TestTrash(t *testing.T) {
+	tempDB := "test_trash.db"
+	defer os.Remove(tempDB)
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	AddNote("Keep me around", []string{"work"}, "low")
+	AddNote("Old junk", nil, "low")
+	notes, _ := GetNotes(NoteFilter{})
+	junk, keeper := notes[0], notes[1]
+
+	if err := DeleteNote(keeper.ID); err != nil {
+		t.Fatalf("DeleteNote() error = %v", err)
+	}
+	if err := DeleteNote(keeper.ID); !errors.Is(err, ErrNoteNotFound) {
+		t.Errorf("Deleting a trashed note again should fail with ErrNoteNotFound, got %v", err)
+	}
+	if n, _ := GetNoteByID(keeper.ID); n != nil {
+		t.Errorf("GetNoteByID() returned a trashed note")
+	}
+	if results, _ := SearchNotes("keep", NoteFilter{}); len(results) != 0 {
+		t.Errorf("Search returned a trashed note")
+	}
+
+	trashed, err := GetTrashedNotes()
+	if err != nil || len(trashed) != 1 || trashed[0].DeletedAt == nil {
+		t.Fatalf("GetTrashedNotes() = %+v, %v", trashed, err)
+	}
+
+	if err := RestoreNote(keeper.ID); err != nil {
+		t.Fatalf("RestoreNote() error = %v", err)
+	}
+	restored, _ := GetNoteByID(keeper.ID)
+	if restored == nil || len(restored.Tags) != 1 {
+		t.Fatalf("Restored note missing or lost its tags: %+v", restored)
+	}
+	if err := RestoreNote(keeper.ID); !errors.Is(err, ErrNoteNotFound) {
+		t.Errorf("Restoring a live note should fail with ErrNoteNotFound, got %v", err)
+	}
+
+	// Only notes past the retention period are purged
+	DeleteNote(junk.ID)
+	DeleteNote(keeper.ID)
+	DB.Exec(`UPDATE notes SET deleted_at = ? WHERE id = ?`, time.Now().Add(-60*24*time.Hour), junk.ID)
+	purged, err := PurgeTrash(30 * 24 * time.Hour)
+	if err != nil || purged != 1 {
+		t.Fatalf("PurgeTrash() = %d, %v; want 1, nil", purged, err)
+	}
+	if purged, _ := PurgeTrash(0); purged != 0 {
+		t.Errorf("PurgeTrash(0) should keep everything, purged %d", purged)
+	}
+
+	emptied, err := EmptyTrash()
+	if err != nil || emptied != 1 {
+		t.Fatalf("EmptyTrash() = %d, %v; want 1, nil", emptied, err)
+	}
+	if trashed, _ := GetTrashedNotes(); len(trashed) != 0 {
+		t.Errorf("Trash not empty after EmptyTrash(): %d notes", len(trashed))
+	}
+}
+
+func TestMigrateUpgradesLegacyDatabase(t *testing.T) {
+	tempDB := "test_legacy.db"
+	defer os.Remove(tempDB)
+
 	var err error
 	DB, err = OpenDB(tempDB)
 	if err != nil {
@@ -82,35 +184,1261 @@ func TestDeleteNote(t *testing.T) {
 	}
 	defer DB.Close()
 
-	createTableSQL := `CREATE TABLE IF NOT EXISTS notes (
+	// A database created before migrations existed: the table is there but
+	// user_version was never set.
+	_, err = DB.Exec(`CREATE TABLE notes (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		content TEXT NOT NULL,
 		tag TEXT,
 		priority TEXT,
 		created_at DATETIME
-	);`
-	DB.Exec(createTableSQL)
+	);`)
+	if err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+	_, err = DB.Exec(`INSERT INTO notes (content, tag, priority, created_at) VALUES ('old note', 'work', 'low', CURRENT_TIMESTAMP)`)
+	if err != nil {
+		t.Fatalf("Failed to insert legacy note: %v", err)
+	}
+	_, err = DB.Exec(`INSERT INTO notes (content, tag, priority, created_at) VALUES ('see [[Old Note]]', '', 'low', CURRENT_TIMESTAMP)`)
+	if err != nil {
+		t.Fatalf("Failed to insert legacy note: %v", err)
+	}
 
-	// Add a note to delete
-	err = AddNote("Delete me", "trash", "low")
+	if err := Migrate(DB); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	version, err := UserVersion(DB)
 	if err != nil {
-		t.Fatalf("Failed to add note: %v", err)
+		t.Fatalf("UserVersion() error = %v", err)
+	}
+	if version != SchemaVersion() {
+		t.Errorf("Expected schema version %d, got %d", SchemaVersion(), version)
 	}
 
-	notes, _ := GetNotes("")
-	if len(notes) != 1 {
-		t.Fatalf("Expected 1 note, got %d", len(notes))
+	notes, err := GetNotes(NoteFilter{Sort: SortID})
+	if err != nil {
+		t.Fatalf("GetNotes() error = %v", err)
+	}
+	if len(notes) != 2 || notes[0].Content != "old note" {
+		t.Fatalf("Legacy notes did not survive migration: %+v", notes)
+	}
+	if notes[0].Slug != "old-note" || notes[1].Slug != "see-old-note" {
+		t.Errorf("Legacy notes got slugs %q and %q", notes[0].Slug, notes[1].Slug)
+	}
+	links, err := defaultStore().Links(context.Background(), 2)
+	if err != nil || len(links) != 1 || links[0].TargetID != 1 {
+		t.Errorf("Legacy link was not migrated, got %+v, %v", links, err)
+	}
+	if len(notes[0].Tags) != 1 || notes[0].Tags[0] != "work" {
+		t.Errorf("Legacy tag was not migrated, got %v", notes[0].Tags)
 	}
 
-	// Delete it
-	err = DeleteNote(notes[0].ID)
+	// Running again must be a no-op
+	if err := Migrate(DB); err != nil {
+		t.Errorf("Second Migrate() error = %v", err)
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	tempDB := "test_newer.db"
+	defer os.Remove(tempDB)
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	_, err := DB.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, SchemaVersion()+1))
 	if err != nil {
-		t.Errorf("DeleteNote() error = %v", err)
+		t.Fatalf("Failed to bump user_version: %v", err)
 	}
 
-	// Verify it's gone
-	notes, _ = GetNotes("")
-	if len(notes) != 0 {
-		t.Errorf("Note was not deleted, still have %d notes", len(notes))
+	err = Migrate(DB)
+	if !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Expected ErrSchemaTooNew, got %v", err)
+	}
+}
+
+func TestSearchNotes(t *testing.T) {
+	tempDB := "test_fts.db"
+	defer os.Remove(tempDB)
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	AddNote("Apple pie recipe with apple slices", []string{"food"}, "low")
+	AddNote("Banana bread, no apple", []string{"food"}, "medium")
+	AddNote("Café meeting notes", []string{"work"}, "high")
+	AddNote("Quarterly planning for the release", []string{"work"}, "low")
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"Ranked by relevance", "apple", []string{"Apple pie recipe with apple slices", "Banana bread, no apple"}},
+		{"Exact phrase", `"pie recipe"`, []string{"Apple pie recipe with apple slices"}},
+		{"Prefix", "plan*", []string{"Quarterly planning for the release"}},
+		{"Boolean NOT", "apple NOT banana", []string{"Apple pie recipe with apple slices"}},
+		{"Case and diacritic folding", "CAFE", []string{"Café meeting notes"}},
+		{"NEAR", "NEAR(quarterly release, 4)", []string{"Quarterly planning for the release"}},
+		{"Invalid syntax falls back to terms", `banana "bread`, []string{"Banana bread, no apple"}},
+		{"No match", "zebra", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := SearchNotes(tt.query, NoteFilter{})
+			if err != nil {
+				t.Fatalf("SearchNotes(%q) error = %v", tt.query, err)
+			}
+			if len(results) != len(tt.want) {
+				t.Fatalf("SearchNotes(%q) returned %d results, want %d", tt.query, len(results), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if results[i].Content != want {
+					t.Errorf("result %d = %q, want %q", i, results[i].Content, want)
+				}
+			}
+		})
+	}
+
+	results, _ := SearchNotes("recipe", NoteFilter{})
+	if len(results) != 1 || results[0].Snippet != "Apple pie "+HighlightStart+"recipe"+HighlightEnd+" with apple slices" {
+		t.Errorf("Unexpected snippet: %+v", results)
+	}
+
+	// The index must follow edits made through UpdateNote
+	UpdateNote(results[0].ID, "Cherry tart")
+	if results, _ := SearchNotes("recipe", NoteFilter{}); len(results) != 0 {
+		t.Errorf("Search index not updated after edit, still found %d results", len(results))
+	}
+	if results, _ := SearchNotes("cherry", NoteFilter{}); len(results) != 1 {
+		t.Errorf("Search index missing edited content, found %d results", len(results))
+	}
+}
+
+func TestNoteTags(t *testing.T) {
+	tempDB := "test_tags.db"
+	defer os.Remove(tempDB)
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	AddNote("Standup with the team #meeting", []string{"work"}, "low")
+	AddNote("Plan the offsite", []string{"Work, Planning", "work"}, "medium")
+	AddNote("Groceries: milk, C# book, see https://example.com/#top and issue #42", nil, "low")
+
+	notes, err := GetNotes(NoteFilter{})
+	if err != nil {
+		t.Fatalf("GetNotes() error = %v", err)
+	}
+	byContent := make(map[string][]string)
+	for _, n := range notes {
+		byContent[n.Content] = n.Tags
+	}
+
+	wantTags := map[string]string{
+		"Standup with the team #meeting": "meeting,work",
+		"Plan the offsite":               "planning,work",
+		"Groceries: milk, C# book, see https://example.com/#top and issue #42": "",
+	}
+	for content, want := range wantTags {
+		if got := strings.Join(byContent[content], ","); got != want {
+			t.Errorf("Tags for %q = %q, want %q", content, got, want)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter NoteFilter
+		want   int
+	}{
+		{"Single tag", NoteFilter{Tags: []string{"work"}}, 2},
+		{"Any of several", NoteFilter{Tags: []string{"meeting", "planning"}}, 2},
+		{"All of several", NoteFilter{Tags: []string{"work", "meeting"}, MatchAll: true}, 1},
+		{"Case and # insensitive", NoteFilter{Tags: []string{"#WORK"}}, 2},
+		{"Unknown tag", NoteFilter{Tags: []string{"nope"}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes, err := GetNotes(tt.filter)
+			if err != nil {
+				t.Fatalf("GetNotes() error = %v", err)
+			}
+			if len(notes) != tt.want {
+				t.Errorf("GetNotes(%+v) returned %d notes, want %d", tt.filter, len(notes), tt.want)
+			}
+		})
+	}
+
+	// Editing a note picks up new hashtags
+	planning, _ := GetNotes(NoteFilter{Tags: []string{"planning"}})
+	UpdateNote(planning[0].ID, "Plan the offsite #travel")
+	note, _ := GetNoteByID(planning[0].ID)
+	if got := strings.Join(note.Tags, ","); got != "planning,travel,work" {
+		t.Errorf("Tags after edit = %q, want %q", got, "planning,travel,work")
+	}
+}
+
+func TestMergeTagWithItsChild(t *testing.T) {
+	tempDB := "test_merge_child.db"
+	defer os.Remove(tempDB)
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	for name, store := range map[string]NoteStore{"sqlite": defaultStore(), "memory": NewMemoryStore()} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, tags := range [][]string{{"a"}, {"a/x"}} {
+				if _, err := store.Add(ctx, NewNote{Content: "Tagged " + tags[0], Tags: tags, Priority: "low"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := store.MergeTags(ctx, []string{"a", "a/x"}, "b"); err != nil {
+				t.Fatalf("MergeTags() error = %v", err)
+			}
+			tags, err := store.Tags(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, tc := range tags {
+				names = append(names, tc.Name)
+			}
+			if !slices.Equal(names, []string{"b", "b/x"}) {
+				t.Errorf("Tags() after merge = %v, want [b b/x]", names)
+			}
+		})
+	}
+}
+
+func TestTagManagement(t *testing.T) {
+	tempDB := "test_tag_admin.db"
+	defer os.Remove(tempDB)
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	AddNote("Client A kickoff", []string{"work/clienta"}, "low")
+	AddNote("Client B invoice", []string{"work/clientb"}, "low")
+	AddNote("General work", []string{"work"}, "low")
+	AddNote("Team sync", []string{"meeting"}, "low")
+	AddNote("Retro", []string{"meetings", "work_items"}, "low")
+
+	count := func(tag string) int {
+		notes, err := GetNotes(NoteFilter{Tags: []string{tag}})
+		if err != nil {
+			t.Fatalf("GetNotes(%q) error = %v", tag, err)
+		}
+		return len(notes)
+	}
+
+	if got := count("work"); got != 3 {
+		t.Errorf("Parent tag should include children: got %d notes, want 3", got)
+	}
+	if got := count("work/clienta"); got != 1 {
+		t.Errorf("Child tag filter: got %d notes, want 1", got)
+	}
+
+	// Renaming a parent carries its children along
+	if err := RenameTag("work", "job"); err != nil {
+		t.Fatalf("RenameTag() error = %v", err)
+	}
+	if got := count("job/clientb"); got != 1 {
+		t.Errorf("Child tag was not renamed with its parent")
+	}
+	if got := count("work_items"); got != 1 {
+		t.Errorf("Unrelated tag sharing a prefix was touched by rename")
+	}
+
+	if err := MergeTags([]string{"meeting", "meetings"}, "sync"); err != nil {
+		t.Fatalf("MergeTags() error = %v", err)
+	}
+	if got := count("sync"); got != 2 {
+		t.Errorf("Merged tag has %d notes, want 2", got)
+	}
+
+	if err := RenameTag("missing", "other"); !errors.Is(err, ErrTagNotFound) {
+		t.Errorf("Expected ErrTagNotFound, got %v", err)
+	}
+
+	affected, err := DeleteTag("job", false)
+	if err != nil || affected != 3 {
+		t.Fatalf("DeleteTag(untag) = %d, %v; want 3, nil", affected, err)
+	}
+	if notes, _ := GetNotes(NoteFilter{}); len(notes) != 5 {
+		t.Errorf("Untagging should keep notes, have %d", len(notes))
+	}
+
+	affected, err = DeleteTag("sync", true)
+	if err != nil || affected != 2 {
+		t.Fatalf("DeleteTag(notes) = %d, %v; want 2, nil", affected, err)
+	}
+	if notes, _ := GetNotes(NoteFilter{}); len(notes) != 3 {
+		t.Errorf("Expected 3 notes left after deleting tagged notes, have %d", len(notes))
+	}
+
+	tags, err := ListTags()
+	if err != nil {
+		t.Fatalf("ListTags() error = %v", err)
+	}
+	// work_items survives with no notes, as its only note was deleted via "sync"
+	if len(tags) != 1 || tags[0] != (TagCount{Name: "work_items", Count: 0}) {
+		t.Errorf("Unexpected tags left: %+v", tags)
+	}
+}
+
+func TestRevisions(t *testing.T) {
+	tempDB := "test_revisions.db"
+	defer os.Remove(tempDB)
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	AddNote("first draft", nil, "low")
+	notes, _ := GetNotes(NoteFilter{})
+	id := notes[0].ID
+
+	UpdateNote(id, "second draft")
+	UpdateNote(id, "second draft") // unchanged, must not add a revision
+	UpdateNote(id, "final version")
+
+	revisions, err := GetRevisions(id)
+	if err != nil {
+		t.Fatalf("GetRevisions() error = %v", err)
+	}
+	var got []string
+	for _, r := range revisions {
+		got = append(got, fmt.Sprintf("%d:%s", r.Rev, r.Content))
+	}
+	if want := "1:first draft|2:second draft|3:final version"; strings.Join(got, "|") != want {
+		t.Errorf("Revisions = %q, want %q", strings.Join(got, "|"), want)
+	}
+
+	if err := RevertNote(id, 1); err != nil {
+		t.Fatalf("RevertNote() error = %v", err)
+	}
+	note, _ := GetNoteByID(id)
+	if note.Content != "first draft" {
+		t.Errorf("Content after revert = %q, want %q", note.Content, "first draft")
+	}
+	if revisions, _ := GetRevisions(id); len(revisions) != 4 {
+		t.Errorf("Revert should add a revision, have %d", len(revisions))
+	}
+
+	if err := RevertNote(id, 99); err == nil {
+		t.Errorf("Expected an error reverting to a missing revision")
+	}
+	if err := UpdateNote(12345, "nope"); !errors.Is(err, ErrNoteNotFound) {
+		t.Errorf("Expected ErrNoteNotFound updating a missing note, got %v", err)
+	}
+}
+
+func TestSortNotes(t *testing.T) {
+	tempDB := "test_sort.db"
+	defer os.Remove(tempDB)
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	AddNote("alpha", nil, "medium")
+	AddNote("bravo", nil, "high")
+	AddNote("charlie", nil, "low")
+
+	// Editing alpha makes it the most recently updated note
+	notes, _ := GetNotes(NoteFilter{Sort: SortID})
+	UpdateNote(notes[0].ID, "alpha edited")
+
+	edited, _ := GetNoteByID(notes[0].ID)
+	if !edited.UpdatedAt.After(edited.CreatedAt) {
+		t.Errorf("UpdatedAt (%v) not bumped past CreatedAt (%v)", edited.UpdatedAt, edited.CreatedAt)
+	}
+
+	tests := []struct {
+		filter NoteFilter
+		want   string
+	}{
+		{NoteFilter{}, "charlie,bravo,alpha edited"},
+		{NoteFilter{Sort: SortCreated, Reverse: true}, "alpha edited,bravo,charlie"},
+		{NoteFilter{Sort: SortUpdated}, "alpha edited,charlie,bravo"},
+		{NoteFilter{Sort: SortPriority}, "bravo,alpha edited,charlie"},
+		{NoteFilter{Sort: SortPriority, Reverse: true}, "charlie,alpha edited,bravo"},
+		{NoteFilter{Sort: SortID}, "alpha edited,bravo,charlie"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s reverse=%v", tt.filter.Sort, tt.filter.Reverse), func(t *testing.T) {
+			notes, err := GetNotes(tt.filter)
+			if err != nil {
+				t.Fatalf("GetNotes() error = %v", err)
+			}
+			var got []string
+			for _, n := range notes {
+				got = append(got, n.Content)
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("Order = %q, want %q", strings.Join(got, ","), tt.want)
+			}
+		})
+	}
+
+	if _, err := GetNotes(NoteFilter{Sort: "colour"}); err == nil {
+		t.Errorf("Expected an error for an unknown sort field")
+	}
+	for name, store := range map[string]NoteStore{"sqlite": defaultStore(), "memory": NewMemoryStore()} {
+		if _, err := store.List(context.Background(), NoteFilter{Sort: SortRelevance}); err == nil || !strings.Contains(err.Error(), "only applies to search") {
+			t.Errorf("%s: List() sorted by relevance error = %v, want it refused", name, err)
+		}
+	}
+
+	results, err := SearchNotes("alpha OR bravo OR charlie", NoteFilter{Sort: SortPriority})
+	if err != nil || len(results) != 3 || results[0].Content != "bravo" {
+		t.Errorf("Search sorted by priority returned %+v, %v", results, err)
+	}
+}
+
+func TestImportNotes(t *testing.T) {
+	tempDB := "test_import.db"
+	defer os.Remove(tempDB)
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	AddNote("Already here\n", nil, "low") // as saved from an editor
+
+	created := time.Date(2025, 5, 6, 7, 8, 9, 0, time.Local)
+	batch := []NewNote{
+		{Content: "Imported one", Tags: []string{"old"}, Priority: "high", CreatedAt: created},
+		{Content: "  Already here  "},
+		{Content: "Imported one"},
+		{Content: "   "},
+		{Content: "Imported two"},
+	}
+
+	res, err := ImportNotes(batch, true)
+	if err != nil {
+		t.Fatalf("ImportNotes(dry run) error = %v", err)
+	}
+	if len(res.Imported) != 2 || len(res.Duplicates) != 2 || res.Empty != 1 {
+		t.Errorf("Dry run result = %d imported, %d duplicates, %d empty", len(res.Imported), len(res.Duplicates), res.Empty)
+	}
+	if notes, _ := GetNotes(NoteFilter{}); len(notes) != 1 {
+		t.Fatalf("Dry run changed the database: %d notes", len(notes))
+	}
+
+	if _, err := ImportNotes(batch, false); err != nil {
+		t.Fatalf("ImportNotes() error = %v", err)
+	}
+	notes, _ := GetNotes(NoteFilter{Tags: []string{"old"}})
+	if len(notes) != 1 || !notes[0].CreatedAt.Equal(created) || notes[0].Priority != "high" {
+		t.Errorf("Imported note lost its metadata: %+v", notes)
 	}
-}
\ No newline at end of file
+	if all, _ := GetNotes(NoteFilter{}); len(all) != 3 {
+		t.Errorf("Expected 3 notes after import, got %d", len(all))
+	}
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	tempDB := "test_backup.db"
+	defer os.Remove(tempDB)
+	dir := t.TempDir()
+
+	setupTestDB(t, tempDB)
+	AddNote("Keep me", nil, "low")
+
+	// Several backups, rotated down to the newest two
+	for i := 0; i < 3; i++ {
+		name := BackupName(time.Date(2026, 10, 1, 9, 0, i, 0, time.Local))
+		if err := Backup(DB, dir+"/"+name); err != nil {
+			t.Fatalf("Backup() error = %v", err)
+		}
+	}
+	removed, err := RotateBackups(dir, 2)
+	if err != nil || len(removed) != 1 || !strings.HasSuffix(removed[0], "jot-20261001-090000.db") {
+		t.Errorf("RotateBackups() = %v, %v; want the oldest removed", removed, err)
+	}
+	backups, _ := ListBackups(dir)
+	if len(backups) != 2 || !strings.HasSuffix(backups[0], "jot-20261001-090002.db") {
+		t.Fatalf("ListBackups() = %v, want the newest first", backups)
+	}
+
+	version, err := CheckBackup(backups[0])
+	if err != nil || version != SchemaVersion() {
+		t.Errorf("CheckBackup() = %d, %v", version, err)
+	}
+	notADB := dir + "/notes.txt"
+	os.WriteFile(notADB, []byte("hello"), 0644)
+	if _, err := CheckBackup(notADB); err == nil {
+		t.Errorf("CheckBackup() should reject a file that isn't a database")
+	}
+
+	// Lose the note, then restore the backup over the database
+	AddNote("Added after the backup", nil, "low")
+	DB.Close()
+	if err := RestoreBackup(backups[0], tempDB); err != nil {
+		t.Fatalf("RestoreBackup() error = %v", err)
+	}
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	notes, _ := GetNotes(NoteFilter{})
+	if len(notes) != 1 || notes[0].Content != "Keep me" {
+		t.Errorf("Notes after restore = %+v, want only the backed up note", notes)
+	}
+}
+
+func TestDiagnoseAndRepair(t *testing.T) {
+	tempDB := "test_doctor.db"
+	defer os.Remove(tempDB)
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	AddNote("Healthy #fine", nil, "low")
+	results, err := Diagnose(DB)
+	if err != nil {
+		t.Fatalf("Diagnose() error = %v", err)
+	}
+	for _, r := range results {
+		if len(r.Problems) > 0 {
+			t.Errorf("Check %q failed on a healthy database: %v", r.Name, r.Problems)
+		}
+	}
+
+	// Break things behind the triggers' backs, as databases written before
+	// foreign keys were enforced could be. The pragma is per connection.
+	DB.SetMaxOpenConns(1)
+	DB.Exec(`PRAGMA foreign_keys = OFF`)
+	DB.Exec(`INSERT INTO note_tags (note_id, tag_id) VALUES (99, 1)`)
+	DB.Exec(`INSERT INTO note_revisions (note_id, rev, content, created_at) VALUES (98, 1, 'x', ?)`, time.Now())
+	DB.Exec(`DELETE FROM note_revisions WHERE note_id = 1`)
+	DB.Exec(`DROP TRIGGER notes_fts_insert`)
+	DB.Exec(`INSERT INTO notes (content, priority, created_at, updated_at) VALUES ('Unindexed', 'low', ?, ?)`, time.Now(), time.Now())
+
+	results, _ = Diagnose(DB)
+	failed := map[string]bool{}
+	for _, r := range results {
+		if len(r.Problems) > 0 {
+			failed[r.Name] = true
+		}
+	}
+	for _, name := range []string{"orphaned tags", "orphaned revisions", "missing revisions", "full-text index"} {
+		if !failed[name] {
+			t.Errorf("Check %q should have failed", name)
+		}
+	}
+
+	if _, err := Repair(DB); err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+	results, _ = Diagnose(DB)
+	for _, r := range results {
+		if len(r.Problems) > 0 {
+			t.Errorf("Check %q still failing after repair: %v", r.Name, r.Problems)
+		}
+	}
+	if res, _ := SearchNotes("unindexed", NoteFilter{}); len(res) != 1 {
+		t.Errorf("Rebuilt index should find the unindexed note")
+	}
+}
+
+func TestConnectionSettings(t *testing.T) {
+	tempDB := "test_pragmas.db"
+	defer os.Remove(tempDB)
+	defer os.Remove(tempDB + "-wal")
+	defer os.Remove(tempDB + "-shm")
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	var mode string
+	var foreignKeys, timeout int
+	DB.QueryRow(`PRAGMA journal_mode`).Scan(&mode)
+	DB.QueryRow(`PRAGMA foreign_keys`).Scan(&foreignKeys)
+	DB.QueryRow(`PRAGMA busy_timeout`).Scan(&timeout)
+	if mode != "wal" || foreignKeys != 1 || timeout == 0 {
+		t.Errorf("journal_mode = %q, foreign_keys = %d, busy_timeout = %d", mode, foreignKeys, timeout)
+	}
+}
+
+// concurrentWriterEnv makes the test binary act as one of the writer
+// processes started by TestConcurrentAddNote.
+const concurrentWriterEnv = "JOT_TEST_CONCURRENT_WRITER"
+
+func TestConcurrentAddNote(t *testing.T) {
+	const goroutines, processes, perWriter = 8, 4, 25
+
+	if path := os.Getenv(concurrentWriterEnv); path != "" {
+		// Child process: add notes through a connection pool of its own
+		var err error
+		if DB, err = OpenDB(path); err != nil {
+			t.Fatal(err)
+		}
+		defer DB.Close()
+		for i := 0; i < perWriter; i++ {
+			if err := AddNote(fmt.Sprintf("process %d note %d", os.Getpid(), i), []string{"proc"}, "low"); err != nil {
+				t.Fatalf("AddNote() error = %v", err)
+			}
+		}
+		return
+	}
+
+	tempDB := "test_concurrent.db"
+	defer os.Remove(tempDB)
+	defer os.Remove(tempDB + "-wal")
+	defer os.Remove(tempDB + "-shm")
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines*perWriter+processes)
+
+	for p := 0; p < processes; p++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestConcurrentAddNote$")
+		cmd.Env = append(os.Environ(), concurrentWriterEnv+"="+tempDB)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if out, err := cmd.CombinedOutput(); err != nil {
+				errs <- fmt.Errorf("writer process failed: %v\n%s", err, out)
+			}
+		}()
+	}
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				if err := AddNote("goroutine "+strconv.Itoa(g)+" note "+strconv.Itoa(i), []string{"goroutine"}, "low"); err != nil {
+					errs <- err
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	want := (goroutines + processes) * perWriter
+	notes, err := GetNotes(NoteFilter{})
+	if err != nil || len(notes) != want {
+		t.Fatalf("Got %d notes (%v), want %d", len(notes), err, want)
+	}
+	var revisions int
+	DB.QueryRow(`SELECT count(*) FROM note_revisions`).Scan(&revisions)
+	if revisions != want {
+		t.Errorf("Got %d revisions, want %d", revisions, want)
+	}
+	results, _ := Diagnose(DB)
+	for _, r := range results {
+		if len(r.Problems) > 0 {
+			t.Errorf("Check %q failed after concurrent writes: %v", r.Name, r.Problems)
+		}
+	}
+}
+
+func TestNoteStores(t *testing.T) {
+	tempDB := "test_stores.db"
+	defer os.Remove(tempDB)
+	defer os.Remove(tempDB + "-wal")
+	defer os.Remove(tempDB + "-shm")
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	stores := map[string]NoteStore{
+		"sqlite": NewSQLiteStore(DB),
+		"memory": NewMemoryStore(),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			add := func(content string, tags []string, priority string) int {
+				t.Helper()
+				id, err := store.Add(ctx, NewNote{Content: content, Tags: tags, Priority: priority})
+				if err != nil {
+					t.Fatalf("Add(%q) error = %v", content, err)
+				}
+				return id
+			}
+
+			apple := add("Apple pie #baking", []string{"food"}, "low")
+			bread := add("Banana bread", []string{"food/sweet"}, "high")
+			work := add("Quarterly report", []string{"work"}, "medium")
+
+			n, err := store.Get(ctx, apple)
+			if err != nil || n == nil {
+				t.Fatalf("Get(%d) = %v, %v", apple, n, err)
+			}
+			if strings.Join(n.Tags, ",") != "baking,food" {
+				t.Errorf("Get(%d).Tags = %v, want [baking food]", apple, n.Tags)
+			}
+
+			// Tags match their children, and the sort keys agree
+			notes, err := store.List(ctx, NoteFilter{Tags: []string{"food"}, Sort: SortPriority})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(notes) != 2 || notes[0].ID != bread || notes[1].ID != apple {
+				t.Errorf("List(food, priority) = %v, want notes %d and %d", notes, bread, apple)
+			}
+
+			results, err := store.Search(ctx, "banana report", NoteFilter{})
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if len(results) != 0 {
+				t.Errorf("Search(banana report) matched %d notes, want every word to be required", len(results))
+			}
+			results, err = store.Search(ctx, "apple", NoteFilter{})
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if len(results) != 1 || !strings.Contains(results[0].Snippet, HighlightStart+"Apple"+HighlightEnd) {
+				t.Errorf("Search(apple) = %+v, want one highlighted match", results)
+			}
+
+			// Updates are recorded as revisions and can be reverted
+			if err := store.Update(ctx, work, "Quarterly report #q3"); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if err := store.Update(ctx, work, "Quarterly report #q3"); err != nil {
+				t.Fatalf("Update() with unchanged content error = %v", err)
+			}
+			revisions, err := store.Revisions(ctx, work)
+			if err != nil || len(revisions) != 2 {
+				t.Fatalf("Revisions() = %d revisions, %v; want 2", len(revisions), err)
+			}
+			if err := store.Revert(ctx, work, 1); err != nil {
+				t.Fatalf("Revert() error = %v", err)
+			}
+			if n, _ := store.Get(ctx, work); n == nil || n.Content != "Quarterly report" || !slices.Contains(n.Tags, "q3") {
+				t.Errorf("After Revert, note = %+v", n)
+			}
+			if err := store.Revert(ctx, work, 9); err == nil {
+				t.Error("Revert() to a missing revision should fail")
+			}
+
+			// Trash
+			if err := store.Delete(ctx, apple); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if err := store.Delete(ctx, apple); !errors.Is(err, ErrNoteNotFound) {
+				t.Errorf("Deleting a trashed note: err = %v, want ErrNoteNotFound", err)
+			}
+			if n, _ := store.Get(ctx, apple); n != nil {
+				t.Errorf("Get() returned a trashed note")
+			}
+			if err := store.Update(ctx, apple, "Rotten apples"); !errors.Is(err, ErrNoteNotFound) {
+				t.Errorf("Updating a trashed note: err = %v, want ErrNoteNotFound", err)
+			}
+			if err := store.Restore(ctx, apple); err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			if err := store.Restore(ctx, apple); !errors.Is(err, ErrNoteNotFound) {
+				t.Errorf("Restoring a live note: err = %v, want ErrNoteNotFound", err)
+			}
+			if err := store.Update(ctx, 999, "x"); !errors.Is(err, ErrNoteNotFound) {
+				t.Errorf("Update() of a missing note: err = %v, want ErrNoteNotFound", err)
+			}
+
+			if _, err := store.List(ctx, NoteFilter{Sort: "size"}); err == nil {
+				t.Error("List() with an unknown sort field should fail")
+			}
+		})
+	}
+}
+
+func TestSavedSearches(t *testing.T) {
+	tempDB := "test_saved.db"
+	defer os.Remove(tempDB)
+	defer os.Remove(tempDB + "-wal")
+	defer os.Remove(tempDB + "-shm")
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	stores := map[string]NoteStore{
+		"sqlite": NewSQLiteStore(DB),
+		"memory": NewMemoryStore(),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, s := range []SavedSearch{{Name: "inbox", Query: "tag:inbox"}, {Name: "Urgent", Query: "priority:high"}} {
+				if err := store.SaveSearch(ctx, s); err != nil {
+					t.Fatalf("SaveSearch(%s) error = %v", s.Name, err)
+				}
+			}
+
+			// Names are case-insensitive, and saving again replaces the query
+			if err := store.SaveSearch(ctx, SavedSearch{Name: "INBOX", Query: "tag:inbox -tag:done"}); err != nil {
+				t.Fatalf("SaveSearch() error = %v", err)
+			}
+			s, err := store.SavedSearch(ctx, "Inbox")
+			if err != nil || s == nil || s.Query != "tag:inbox -tag:done" {
+				t.Fatalf("SavedSearch(Inbox) = %+v, %v", s, err)
+			}
+
+			saved, err := store.SavedSearches(ctx)
+			if err != nil {
+				t.Fatalf("SavedSearches() error = %v", err)
+			}
+			if len(saved) != 2 || !strings.EqualFold(saved[0].Name, "inbox") || saved[1].Name != "Urgent" {
+				t.Errorf("SavedSearches() = %+v, want inbox and Urgent", saved)
+			}
+
+			if err := store.DeleteSavedSearch(ctx, "urgent"); err != nil {
+				t.Fatalf("DeleteSavedSearch() error = %v", err)
+			}
+			if err := store.DeleteSavedSearch(ctx, "urgent"); !errors.Is(err, ErrSavedSearchNotFound) {
+				t.Errorf("Deleting a missing search: err = %v, want ErrSavedSearchNotFound", err)
+			}
+			if s, _ := store.SavedSearch(ctx, "urgent"); s != nil {
+				t.Errorf("SavedSearch() returned a deleted search")
+			}
+		})
+	}
+}
+
+func TestDueDatesAndReminders(t *testing.T) {
+	tempDB := "test_due.db"
+	defer os.Remove(tempDB)
+	defer os.Remove(tempDB + "-wal")
+	defer os.Remove(tempDB + "-shm")
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	stores := map[string]NoteStore{
+		"sqlite": NewSQLiteStore(DB),
+		"memory": NewMemoryStore(),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			past, soon, later := now.Add(-time.Hour), now.Add(time.Hour), now.AddDate(0, 0, 10)
+
+			ids := make(map[string]int)
+			for content, due := range map[string]*time.Time{"past": &past, "soon": &soon, "later": &later, "none": nil} {
+				id, err := store.Add(ctx, NewNote{Content: content, DueAt: due})
+				if err != nil {
+					t.Fatalf("Add(%s) error = %v", content, err)
+				}
+				ids[content] = id
+			}
+
+			notes, err := store.List(ctx, NoteFilter{DueBefore: now.AddDate(0, 0, 2), Sort: SortDue})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(notes) != 2 || notes[0].Content != "past" || notes[1].Content != "soon" {
+				t.Errorf("Expected past and soon due within two days, got %+v", notes)
+			}
+			if notes[0].DueAt == nil || !notes[0].DueAt.Equal(past) {
+				t.Errorf("Expected due date %v, got %v", past, notes[0].DueAt)
+			}
+
+			reminders, err := store.Reminders(ctx, now)
+			if err != nil || len(reminders) != 1 || reminders[0].ID != ids["past"] {
+				t.Fatalf("Reminders() = %+v, %v, want the past note", reminders, err)
+			}
+			if err := store.MarkNotified(ctx, ids["past"], now); err != nil {
+				t.Fatalf("MarkNotified() error = %v", err)
+			}
+			if reminders, _ := store.Reminders(ctx, now); len(reminders) != 0 {
+				t.Errorf("Expected no reminders once notified, got %+v", reminders)
+			}
+
+			// A new due date gets a new reminder, and clearing it removes the note from the agenda
+			if err := store.SetDue(ctx, ids["soon"], &past); err != nil {
+				t.Fatalf("SetDue() error = %v", err)
+			}
+			if reminders, _ := store.Reminders(ctx, now); len(reminders) != 1 || reminders[0].ID != ids["soon"] {
+				t.Errorf("Expected a reminder for the moved note, got %+v", reminders)
+			}
+			if err := store.SetDue(ctx, ids["soon"], nil); err != nil {
+				t.Fatalf("SetDue(nil) error = %v", err)
+			}
+			if n, _ := store.Get(ctx, ids["soon"]); n == nil || n.DueAt != nil {
+				t.Errorf("Expected the due date to be cleared, got %+v", n)
+			}
+			if err := store.SetDue(ctx, 9999, &past); !errors.Is(err, ErrNoteNotFound) {
+				t.Errorf("SetDue() on a missing note error = %v, want ErrNoteNotFound", err)
+			}
+		})
+	}
+}
+
+func TestTaskStatus(t *testing.T) {
+	tempDB := "test_status.db"
+	defer os.Remove(tempDB)
+	defer os.Remove(tempDB + "-wal")
+	defer os.Remove(tempDB + "-shm")
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	stores := map[string]NoteStore{
+		"sqlite": NewSQLiteStore(DB),
+		"memory": NewMemoryStore(),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			due := time.Now().Add(-time.Minute)
+			plain, _ := store.Add(ctx, NewNote{Content: "plain"})
+			task, _ := store.Add(ctx, NewNote{Content: "task", Status: StatusOpen, DueAt: &due})
+
+			notes, err := store.List(ctx, NoteFilter{Statuses: []string{StatusOpen, StatusDoing}})
+			if err != nil || len(notes) != 1 || notes[0].ID != task || notes[0].Status != StatusOpen {
+				t.Fatalf("List(open) = %+v, %v, want the task", notes, err)
+			}
+			if notes, _ := store.List(ctx, NoteFilter{Statuses: []string{""}}); len(notes) != 1 || notes[0].ID != plain {
+				t.Errorf("List(plain) = %+v, want the plain note", notes)
+			}
+
+			if _, err := store.SetStatus(ctx, task, StatusDone); err != nil {
+				t.Fatalf("SetStatus() error = %v", err)
+			}
+			if n, _ := store.Get(ctx, task); n == nil || !n.Closed() {
+				t.Errorf("Expected the task to be done, got %+v", n)
+			}
+			if reminders, _ := store.Reminders(ctx, time.Now()); len(reminders) != 0 {
+				t.Errorf("Done tasks shouldn't be reminded about, got %+v", reminders)
+			}
+		})
+	}
+
+	if _, err := ParseStatus("Doing"); err != nil {
+		t.Errorf("ParseStatus(Doing) error = %v", err)
+	}
+	if _, err := ParseStatus("later"); err == nil {
+		t.Error("ParseStatus(later) should fail")
+	}
+}
+
+func TestRecurringTasks(t *testing.T) {
+	tempDB := "test_recurring.db"
+	defer os.Remove(tempDB)
+	defer os.Remove(tempDB + "-wal")
+	defer os.Remove(tempDB + "-shm")
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	stores := map[string]NoteStore{
+		"sqlite": NewSQLiteStore(DB),
+		"memory": NewMemoryStore(),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			due := time.Now().Add(time.Hour)
+			id, err := store.Add(ctx, NewNote{
+				Content:    "Weekly review\n- [x] inbox\n- [ ] calendar",
+				Tags:       []string{"review"},
+				Priority:   "high",
+				Status:     StatusOpen,
+				DueAt:      &due,
+				Recurrence: "FREQ=WEEKLY",
+			})
+			if err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+
+			if recurring, _ := store.List(ctx, NoteFilter{Recurring: true}); len(recurring) != 1 || recurring[0].ID != id {
+				t.Fatalf("List(Recurring) = %+v, want the review", recurring)
+			}
+
+			next, err := store.SetStatus(ctx, id, StatusDone)
+			if err != nil || next == 0 {
+				t.Fatalf("SetStatus(done) = %d, %v, want a next occurrence", next, err)
+			}
+			n, _ := store.Get(ctx, next)
+			if n == nil || n.Status != StatusOpen || n.Recurrence != "FREQ=WEEKLY" || n.Priority != "high" {
+				t.Fatalf("Expected an open weekly occurrence, got %+v", n)
+			}
+			if n.Content != "Weekly review\n- [ ] inbox\n- [ ] calendar" || !slices.Equal(n.Tags, []string{"review"}) {
+				t.Errorf("Expected the content with a fresh checklist and the tags, got %+v", n)
+			}
+			if want := due.AddDate(0, 0, 7); n.DueAt == nil || !n.DueAt.Equal(want) {
+				t.Errorf("Expected the next one due %v, got %v", want, n.DueAt)
+			}
+
+			// The finished occurrence no longer repeats, so finishing it again does nothing
+			if old, _ := store.Get(ctx, id); old.Recurrence != "" {
+				t.Errorf("Expected the finished occurrence to stop repeating, got %q", old.Recurrence)
+			}
+			if again, _ := store.SetStatus(ctx, id, StatusDone); again != 0 {
+				t.Errorf("Expected no new occurrence, got note %d", again)
+			}
+
+			// A title carries over, so the series keeps going by the same name
+			titled, err := store.Add(ctx, NewNote{Content: "Body text", Title: "Pay rent", Priority: "low", DueAt: &due, Recurrence: "FREQ=MONTHLY"})
+			if err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			following, _ := store.SetStatus(ctx, titled, StatusDone)
+			if n, _ := store.Get(ctx, following); n == nil || n.Title != "Pay rent" || n.Slug != "pay-rent-2" {
+				t.Errorf("Expected the next rent payment to keep its title, got %+v", n)
+			}
+
+			if err := store.SetRecurrence(ctx, next, ""); err != nil {
+				t.Fatalf("SetRecurrence() error = %v", err)
+			}
+			if again, _ := store.SetStatus(ctx, next, StatusDone); again != 0 {
+				t.Errorf("Expected a cancelled series not to repeat, got note %d", again)
+			}
+		})
+	}
+}
+
+func TestJournalEntries(t *testing.T) {
+	tempDB := "test_journal.db"
+	defer os.Remove(tempDB)
+	defer os.Remove(tempDB + "-wal")
+	defer os.Remove(tempDB + "-shm")
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	stores := map[string]NoteStore{
+		"sqlite": NewSQLiteStore(DB),
+		"memory": NewMemoryStore(),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if entry, err := store.JournalEntry(ctx, "2026-10-01"); err != nil || entry != nil {
+				t.Fatalf("JournalEntry() before adding = %v, %v, want nil", entry, err)
+			}
+
+			id, err := store.Add(ctx, NewNote{Content: "# Thursday\n\nQuiet day", Priority: "low", JournalDate: "2026-10-01"})
+			if err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			if _, err := store.Add(ctx, NewNote{Content: "Not a journal entry", Priority: "low"}); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+
+			entry, err := store.JournalEntry(ctx, "2026-10-01")
+			if err != nil || entry == nil || entry.ID != id || entry.JournalDate != "2026-10-01" {
+				t.Fatalf("JournalEntry() = %+v, %v, want note %d", entry, err, id)
+			}
+			if journal, _ := store.List(ctx, NoteFilter{Journal: true}); len(journal) != 1 || journal[0].ID != id {
+				t.Errorf("List(Journal) = %+v, want only the entry", journal)
+			}
+
+			if _, err := store.Add(ctx, NewNote{Content: "Again", Priority: "low", JournalDate: "2026-10-01"}); err == nil {
+				t.Error("Add() should refuse a second entry for the same day")
+			}
+
+			// A trashed entry makes way for a new one, and can't come back over it
+			if err := store.Delete(ctx, id); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := store.Add(ctx, NewNote{Content: "Fresh start", Priority: "low", JournalDate: "2026-10-01"}); err != nil {
+				t.Fatalf("Add() after trashing error = %v", err)
+			}
+			if err := store.Restore(ctx, id); err == nil {
+				t.Error("Restore() should refuse a second entry for the same day")
+			}
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	tempDB := "test_links.db"
+	defer os.Remove(tempDB)
+	defer os.Remove(tempDB + "-wal")
+	defer os.Remove(tempDB + "-shm")
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	stores := map[string]NoteStore{
+		"sqlite": NewSQLiteStore(DB),
+		"memory": NewMemoryStore(),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			add := func(content string) int {
+				id, err := store.Add(ctx, NewNote{Content: content, Priority: "low"})
+				if err != nil {
+					t.Fatalf("Add(%q) error = %v", content, err)
+				}
+				return id
+			}
+			targets := func(id int) []int {
+				links, err := store.Links(ctx, id)
+				if err != nil {
+					t.Fatalf("Links(%d) error = %v", id, err)
+				}
+				var ids []int
+				for _, l := range links {
+					ids = append(ids, l.TargetID)
+				}
+				return ids
+			}
+			backlinks := func(id int) []int {
+				notes, err := store.Backlinks(ctx, id)
+				if err != nil {
+					t.Fatalf("Backlinks(%d) error = %v", id, err)
+				}
+				var ids []int
+				for _, n := range notes {
+					ids = append(ids, n.ID)
+				}
+				return ids
+			}
+
+			plan := add("# Sprint planning\n\nGoals")
+			hub := add("See [[sprint  Planning]], [[1|the plan]] and [[Retro]], later [[99]]")
+			if got := targets(hub); !slices.Equal(got, []int{plan, plan, 0, 0}) {
+				t.Errorf("Links() = %v, want the plan twice then two broken links", got)
+			}
+
+			// Links to notes that don't exist yet are mended when they appear
+			retro := add("Retro\nWhat went well")
+			if got := targets(hub); !slices.Equal(got, []int{plan, plan, retro, 0}) {
+				t.Errorf("Links() after adding the retro = %v", got)
+			}
+			if got := backlinks(retro); !slices.Equal(got, []int{hub}) {
+				t.Errorf("Backlinks(retro) = %v, want %v", got, []int{hub})
+			}
+
+			// Editing replaces the links; trashing a note breaks links to it
+			// until it comes back
+			if err := store.Update(ctx, hub, "Only [[Retro]] now"); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if got := backlinks(plan); len(got) != 0 {
+				t.Errorf("Backlinks(plan) after the edit = %v, want none", got)
+			}
+			store.Delete(ctx, retro)
+			if got := targets(hub); !slices.Equal(got, []int{0}) {
+				t.Errorf("Links() to a trashed note = %v, want broken", got)
+			}
+			store.Restore(ctx, retro)
+			if got := targets(hub); !slices.Equal(got, []int{retro}) {
+				t.Errorf("Links() after restoring = %v", got)
+			}
+
+			// Headings and aliases don't change where a link leads
+			headings := add("See [[2#goals]], [[sprint-planning#Goals|the goals]] and [[Retro#actions]]")
+			if got := targets(headings); !slices.Equal(got, []int{hub, plan, retro}) {
+				t.Errorf("Links() with headings = %v, want %v", got, []int{hub, plan, retro})
+			}
+		})
+	}
+
+	AddNote("Dangling [[99]]", nil, "low")
+	results, err := Diagnose(DB)
+	if err != nil {
+		t.Fatalf("Diagnose() error = %v", err)
+	}
+	for _, r := range results {
+		if r.Name == "broken links" && (len(r.Problems) != 1 || !strings.Contains(r.Problems[0], "note 5 links to [[99]]")) {
+			t.Errorf("Broken links = %v, want the link to note 99", r.Problems)
+		}
+	}
+}
+
+func TestTitlesAndSlugs(t *testing.T) {
+	tempDB := "test_titles.db"
+	defer os.Remove(tempDB)
+	defer os.Remove(tempDB + "-wal")
+	defer os.Remove(tempDB + "-shm")
+
+	setupTestDB(t, tempDB)
+	defer DB.Close()
+
+	stores := map[string]NoteStore{
+		"sqlite": NewSQLiteStore(DB),
+		"memory": NewMemoryStore(),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			add := func(n NewNote) *Note {
+				n.Priority = "low"
+				id, err := store.Add(ctx, n)
+				if err != nil {
+					t.Fatalf("Add(%q) error = %v", n.Content, err)
+				}
+				note, err := store.Get(ctx, id)
+				if err != nil || note == nil {
+					t.Fatalf("Get(%d) = %v, %v", id, note, err)
+				}
+				return note
+			}
+
+			plan := add(NewNote{Content: "# Sprint Planning!\n\nGoals"})
+			again := add(NewNote{Content: "Sprint planning"})
+			titled := add(NewNote{Content: "Budget, hiring", Title: "  Board meeting "})
+			year := add(NewNote{Content: "2026"})
+			empty := add(NewNote{Content: "!!!"})
+			long := add(NewNote{Content: strings.Repeat("word ", 20)})
+
+			for _, tt := range []struct {
+				note        *Note
+				slug, title string
+			}{
+				{plan, "sprint-planning", "Sprint Planning!"},
+				{again, "sprint-planning-2", "Sprint planning"},
+				{titled, "board-meeting", "Board meeting"},
+				{year, "note-2026", "2026"},
+				{empty, "note", "!!!"},
+			} {
+				if tt.note.Slug != tt.slug || tt.note.DisplayTitle() != tt.title {
+					t.Errorf("Note %d has slug %q and title %q, want %q and %q", tt.note.ID, tt.note.Slug, tt.note.DisplayTitle(), tt.slug, tt.title)
+				}
+			}
+			if titled.Title != "Board meeting" || plan.Title != "" {
+				t.Errorf("Titles = %q and %q, want only the explicit one", titled.Title, plan.Title)
+			}
+			if len(long.Slug) > maxSlugLen || strings.HasSuffix(long.Slug, "-") {
+				t.Errorf("Long slug = %q", long.Slug)
+			}
+
+			// A new title renames the slug and mends links to it
+			hub := add(NewNote{Content: "See [[Retro]] and [[retro-notes]]"})
+			if err := store.SetTitle(ctx, again.ID, "Retro notes"); err != nil {
+				t.Fatalf("SetTitle() error = %v", err)
+			}
+			renamed, _ := store.Get(ctx, again.ID)
+			if renamed.Title != "Retro notes" || renamed.Slug != "retro-notes" {
+				t.Errorf("After SetTitle() note has title %q and slug %q", renamed.Title, renamed.Slug)
+			}
+			links, _ := store.Links(ctx, hub.ID)
+			if len(links) != 2 || links[0].TargetID != 0 || links[1].TargetID != again.ID {
+				t.Errorf("Links() after SetTitle() = %+v, want [[retro-notes]] mended", links)
+			}
+
+			// Clearing the title goes back to the first line, which is free again
+			if err := store.SetTitle(ctx, again.ID, ""); err != nil {
+				t.Fatalf("SetTitle() error = %v", err)
+			}
+			if cleared, _ := store.Get(ctx, again.ID); cleared.Title != "" || cleared.Slug != "sprint-planning-2" {
+				t.Errorf("After clearing the title note has title %q and slug %q", cleared.Title, cleared.Slug)
+			}
+
+			// Slugs survive the trash, so a new note can't take one
+			store.Delete(ctx, titled.ID)
+			if other := add(NewNote{Content: "Board meeting"}); other.Slug != "board-meeting-2" {
+				t.Errorf("Slug next to a trashed note = %q", other.Slug)
+			}
+			if err := store.SetTitle(ctx, titled.ID, "x"); !errors.Is(err, ErrNoteNotFound) {
+				t.Errorf("SetTitle() on a trashed note error = %v, want ErrNoteNotFound", err)
+			}
+		})
+	}
+}
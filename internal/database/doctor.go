@@ -0,0 +1,230 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CheckResult is the outcome of one of the consistency checks run by
+// Diagnose. A check with no problems passed.
+type CheckResult struct {
+	Name     string
+	Problems []string
+	Fixable  bool // Repair can fix the problems found
+}
+
+// healthCheck inspects one aspect of the database and optionally knows how
+// to repair it.
+type healthCheck struct {
+	name string
+	run  func(db *sql.DB) ([]string, error)
+	fix  func(db *sql.DB) error
+}
+
+var healthChecks = []healthCheck{
+	{name: "integrity", run: checkIntegrity},
+	{name: "schema version", run: checkSchemaVersion},
+	{name: "orphaned tags", run: checkOrphanedTags, fix: fixOrphanedTags},
+	{name: "orphaned revisions", run: checkOrphanedRevisions, fix: fixOrphanedRevisions},
+	{name: "missing revisions", run: checkMissingRevisions, fix: fixMissingRevisions},
+	{name: "full-text index", run: checkFTS, fix: rebuildFTS},
+	{name: "broken links", run: checkBrokenLinks},
+}
+
+// Diagnose runs every consistency check against db.
+func Diagnose(db *sql.DB) ([]CheckResult, error) {
+	results := make([]CheckResult, 0, len(healthChecks))
+	for _, c := range healthChecks {
+		problems, err := c.run(db)
+		if err != nil {
+			return results, fmt.Errorf("could not run %s check: %v", c.name, err)
+		}
+		results = append(results, CheckResult{Name: c.name, Problems: problems, Fixable: c.fix != nil})
+	}
+	return results, nil
+}
+
+// Repair fixes the problems found by the checks that know how to, and
+// returns the names of the checks it repaired.
+func Repair(db *sql.DB) ([]string, error) {
+	var repaired []string
+	for _, c := range healthChecks {
+		if c.fix == nil {
+			continue
+		}
+		problems, err := c.run(db)
+		if err != nil {
+			return repaired, fmt.Errorf("could not run %s check: %v", c.name, err)
+		}
+		if len(problems) == 0 {
+			continue
+		}
+		if err := c.fix(db); err != nil {
+			return repaired, fmt.Errorf("could not repair %s: %v", c.name, err)
+		}
+		repaired = append(repaired, c.name)
+	}
+	return repaired, nil
+}
+
+func checkIntegrity(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, err
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	return problems, rows.Err()
+}
+
+func checkSchemaVersion(db *sql.DB) ([]string, error) {
+	version, err := UserVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if version != SchemaVersion() {
+		return []string{fmt.Sprintf("database is at version %d, expected %d", version, SchemaVersion())}, nil
+	}
+	return nil, nil
+}
+
+// queryProblems runs a query returning one description per row.
+func queryProblems(db *sql.DB, query string) ([]string, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, err
+		}
+		problems = append(problems, msg)
+	}
+	return problems, rows.Err()
+}
+
+const orphanedTagsWhere = `note_id NOT IN (SELECT id FROM notes) OR tag_id NOT IN (SELECT id FROM tags)`
+
+func checkOrphanedTags(db *sql.DB) ([]string, error) {
+	return queryProblems(db, `SELECT 'tag link ' || tag_id || ' on missing note ' || note_id FROM note_tags
+		WHERE note_id NOT IN (SELECT id FROM notes)
+		UNION ALL
+		SELECT 'note ' || note_id || ' linked to missing tag ' || tag_id FROM note_tags
+		WHERE tag_id NOT IN (SELECT id FROM tags)`)
+}
+
+func fixOrphanedTags(db *sql.DB) error {
+	_, err := db.Exec(`DELETE FROM note_tags WHERE ` + orphanedTagsWhere)
+	return err
+}
+
+func checkOrphanedRevisions(db *sql.DB) ([]string, error) {
+	return queryProblems(db, `SELECT count(*) || ' revision(s) of missing note ' || note_id FROM note_revisions
+		WHERE note_id NOT IN (SELECT id FROM notes) GROUP BY note_id`)
+}
+
+func fixOrphanedRevisions(db *sql.DB) error {
+	_, err := db.Exec(`DELETE FROM note_revisions WHERE note_id NOT IN (SELECT id FROM notes)`)
+	return err
+}
+
+func checkMissingRevisions(db *sql.DB) ([]string, error) {
+	return queryProblems(db, `SELECT 'note ' || id || ' has no revisions' FROM notes
+		WHERE id NOT IN (SELECT note_id FROM note_revisions)`)
+}
+
+// fixMissingRevisions records each note's current content as its latest revision.
+func fixMissingRevisions(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, content, updated_at FROM notes WHERE id NOT IN (SELECT note_id FROM note_revisions)`)
+	if err != nil {
+		return err
+	}
+	type missing struct {
+		id      int
+		content string
+		at      time.Time
+	}
+	var notes []missing
+	for rows.Next() {
+		var m missing
+		if err := rows.Scan(&m.id, &m.content, &m.at); err != nil {
+			rows.Close()
+			return err
+		}
+		notes = append(notes, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, m := range notes {
+		if err := addRevision(tx, m.id, m.content, m.at); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// checkFTS compares the full-text index with the notes table. FTS5's own
+// integrity-check catches index corruption; the counts catch rows the
+// triggers missed.
+func checkFTS(db *sql.DB) ([]string, error) {
+	var problems []string
+	if _, err := db.Exec(`INSERT INTO notes_fts(notes_fts) VALUES ('integrity-check')`); err != nil {
+		if !strings.Contains(err.Error(), "malformed") && !strings.Contains(err.Error(), "corrupt") {
+			return nil, err
+		}
+		problems = append(problems, "index does not match the notes table")
+	}
+
+	var notes, indexed int
+	if err := db.QueryRow(`SELECT count(*) FROM notes`).Scan(&notes); err != nil {
+		return nil, err
+	}
+	if err := db.QueryRow(`SELECT count(*) FROM notes_fts_docsize`).Scan(&indexed); err != nil {
+		return nil, err
+	}
+	if notes != indexed {
+		problems = append(problems, fmt.Sprintf("%d note(s) but %d indexed", notes, indexed))
+	}
+	return problems, nil
+}
+
+func rebuildFTS(db *sql.DB) error {
+	_, err := db.Exec(`INSERT INTO notes_fts(notes_fts) VALUES ('rebuild')`)
+	return err
+}
+
+// checkBrokenLinks finds [[links]] that don't lead to a note. Only the note
+// making them can fix that, so there is no repair.
+func checkBrokenLinks(db *sql.DB) ([]string, error) {
+	return queryProblems(db, `SELECT 'note ' || l.source_id || ' links to [[' || l.target || ']], which doesn''t lead to a note'
+		FROM links l
+		JOIN notes s ON s.id = l.source_id AND s.deleted_at IS NULL
+		LEFT JOIN notes t ON t.id = l.target_id AND t.deleted_at IS NULL
+		WHERE t.id IS NULL
+		ORDER BY l.source_id, l.rowid`)
+}
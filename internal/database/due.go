@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func (s *SQLiteStore) SetDue(ctx context.Context, id int, due *time.Time) error {
+	query := `UPDATE notes SET due_at = ?, notified_at = NULL, updated_at = ? WHERE id = ? AND deleted_at IS NULL`
+	res, err := s.db.ExecContext(ctx, query, due, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("could not set due date: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("could not set due date of note %d: %w", id, ErrNoteNotFound)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Reminders(ctx context.Context, now time.Time) ([]Note, error) {
+	query := `SELECT ` + noteColumns + ` FROM notes n
+		WHERE n.deleted_at IS NULL AND n.notified_at IS NULL AND substr(n.due_at, 1, 19) <= ?
+			AND n.status NOT IN ('done', 'cancelled')
+		ORDER BY n.due_at, n.id`
+	return queryNotes(ctx, s.db, query, now.Format(TimestampPrefix))
+}
+
+func (s *SQLiteStore) MarkNotified(ctx context.Context, id int, at time.Time) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE notes SET notified_at = ? WHERE id = ?`, at, id)
+	if err != nil {
+		return fmt.Errorf("could not mark note %d as notified: %v", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("could not mark note %d as notified: %w", id, ErrNoteNotFound)
+	}
+	return nil
+}
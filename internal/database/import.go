@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ImportResult summarizes an import.
+type ImportResult struct {
+	Imported   []NewNote
+	Duplicates []NewNote
+	Empty      int
+}
+
+// ImportNotes adds many notes in a single transaction, skipping empty notes
+// and any whose content already exists (including earlier notes in the same
+// batch). With dryRun the transaction is rolled back, so the result shows
+// exactly what a real import would do without changing anything.
+func ImportNotes(notes []NewNote, dryRun bool) (ImportResult, error) {
+	return defaultStore().Import(context.Background(), notes, dryRun)
+}
+
+func (s *SQLiteStore) Import(ctx context.Context, notes []NewNote, dryRun bool) (ImportResult, error) {
+	var res ImportResult
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+
+	for _, n := range notes {
+		n.Content = strings.TrimSpace(n.Content)
+		if n.Content == "" {
+			res.Empty++
+			continue
+		}
+		if n.Priority == "" {
+			n.Priority = "low"
+		}
+
+		// Compare the way strings.TrimSpace trimmed n.Content, not just spaces
+		var exists bool
+		query := `SELECT EXISTS (SELECT 1 FROM notes WHERE trim(content, ' ' || char(9) || char(10) || char(13)) = ?)`
+		err := tx.QueryRow(query, n.Content).Scan(&exists)
+		if err != nil {
+			return res, fmt.Errorf("could not check for duplicates: %v", err)
+		}
+		if exists {
+			res.Duplicates = append(res.Duplicates, n)
+			continue
+		}
+
+		if _, err := insertNote(tx, n); err != nil {
+			return res, fmt.Errorf("could not import note: %v", err)
+		}
+		res.Imported = append(res.Imported, n)
+	}
+
+	if dryRun {
+		return res, nil
+	}
+	return res, tx.Commit()
+}
@@ -0,0 +1,18 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+func (s *SQLiteStore) JournalEntry(ctx context.Context, date string) (*Note, error) {
+	query := `SELECT ` + noteColumns + ` FROM notes n WHERE n.journal_date = ? AND n.deleted_at IS NULL`
+	notes, err := queryNotes(ctx, s.db, query, date)
+	if err != nil {
+		return nil, fmt.Errorf("could not get journal entry: %v", err)
+	}
+	if len(notes) == 0 {
+		return nil, nil
+	}
+	return &notes[0], nil
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/flyme2mars/jotcli/internal/markdown"
+)
+
+// Link is a [[link]] from one note to another, by ID, slug or title.
+// TargetID is 0 while the link is broken: no note matches it, or the note
+// it points to is in the trash.
+type Link struct {
+	SourceID int    `json:"source_id"`
+	Target   string `json:"target"`
+	TargetID int    `json:"target_id,omitempty"`
+}
+
+// setLinks replaces the links a note makes with the ones in its content,
+// then points any broken links that match the note at it.
+func setLinks(tx *sql.Tx, id int, content string) error {
+	if _, err := tx.Exec(`DELETE FROM links WHERE source_id = ?`, id); err != nil {
+		return err
+	}
+	for _, target := range markdown.Links(content) {
+		targetID, err := resolveLink(tx, target)
+		if err != nil {
+			return err
+		}
+		var to any
+		if targetID > 0 {
+			to = targetID
+		}
+		query := `INSERT INTO links (source_id, target, target_key, target_id) VALUES (?, ?, ?, ?)`
+		if _, err := tx.Exec(query, id, target, markdown.LinkKey(target), to); err != nil {
+			return err
+		}
+	}
+	return mendLinks(tx, id)
+}
+
+// mendLinks points the broken links that match a note's ID, slug or title
+// at it.
+func mendLinks(tx *sql.Tx, id int) error {
+	var title, content, slug string
+	err := tx.QueryRow(`SELECT title, content, COALESCE(slug, '') FROM notes WHERE id = ?`, id).Scan(&title, &content, &slug)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE links SET target_id = ? WHERE target_id IS NULL AND target_key IN (?, ?, ?)`
+	_, err = tx.Exec(query, id, strconv.Itoa(id), slug, markdown.LinkKey(displayTitle(title, content)))
+	return err
+}
+
+// resolveBrokenLinks tries to resolve every broken link again.
+func resolveBrokenLinks(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT rowid, target FROM links WHERE target_id IS NULL`)
+	if err != nil {
+		return err
+	}
+	targets := map[int64]string{}
+	for rows.Next() {
+		var rowid int64
+		var target string
+		if err := rows.Scan(&rowid, &target); err != nil {
+			rows.Close()
+			return err
+		}
+		targets[rowid] = target
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for rowid, target := range targets {
+		id, err := resolveLink(tx, target)
+		if err != nil {
+			return err
+		}
+		if id > 0 {
+			if _, err := tx.Exec(`UPDATE links SET target_id = ? WHERE rowid = ?`, id, rowid); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveLink finds the note a link points to: the note with that ID or
+// slug, even in the trash so restoring it mends the link, or else the
+// oldest note with that title. It returns 0 for none.
+func resolveLink(tx *sql.Tx, target string) (int, error) {
+	key := markdown.LinkKey(target)
+	var id int
+	var err error
+	if n, convErr := strconv.Atoi(target); convErr == nil {
+		err = tx.QueryRow(`SELECT id FROM notes WHERE id = ?`, n).Scan(&id)
+	} else {
+		err = tx.QueryRow(`SELECT id FROM notes WHERE slug = ?`, key).Scan(&id)
+	}
+	if err != sql.ErrNoRows {
+		return id, err
+	}
+
+	// Titles are compared in Go, after narrowing down the untitled notes
+	// by the first word
+	word := strings.Fields(target)[0]
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(word) + "%"
+	rows, err := tx.Query(`SELECT id, title, content FROM notes
+		WHERE deleted_at IS NULL AND (title != '' OR content LIKE ? ESCAPE '\') ORDER BY id`, pattern)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var title, content string
+		if err := rows.Scan(&id, &title, &content); err != nil {
+			return 0, err
+		}
+		if markdown.LinkKey(displayTitle(title, content)) == key {
+			return id, nil
+		}
+	}
+	return 0, rows.Err()
+}
+
+// migrateLinks records the links in the notes saved before links were. They
+// are resolved once notes have slugs, by migrateSlugs.
+func migrateLinks(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, content FROM notes WHERE content LIKE '%[[%'`)
+	if err != nil {
+		return err
+	}
+	contents := map[int]string{}
+	for rows.Next() {
+		var id int
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		contents[id] = content
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, content := range contents {
+		for _, target := range markdown.Links(content) {
+			query := `INSERT INTO links (source_id, target, target_key) VALUES (?, ?, ?)`
+			if _, err := tx.Exec(query, id, target, markdown.LinkKey(target)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Links(ctx context.Context, id int) ([]Link, error) {
+	query := `SELECT l.source_id, l.target, COALESCE(t.id, 0) FROM links l
+		LEFT JOIN notes t ON t.id = l.target_id AND t.deleted_at IS NULL
+		WHERE l.source_id = ? ORDER BY l.rowid`
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("could not get links: %v", err)
+	}
+	defer rows.Close()
+
+	var links []Link
+	for rows.Next() {
+		var l Link
+		if err := rows.Scan(&l.SourceID, &l.Target, &l.TargetID); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+func (s *SQLiteStore) Backlinks(ctx context.Context, id int) ([]Note, error) {
+	query := `SELECT ` + noteColumns + ` FROM notes n
+		WHERE n.deleted_at IS NULL AND n.id IN (SELECT source_id FROM links WHERE target_id = ?)
+		ORDER BY n.id`
+	notes, err := queryNotes(ctx, s.db, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("could not get backlinks: %v", err)
+	}
+	return notes, nil
+}
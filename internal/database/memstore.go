@@ -0,0 +1,983 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/flyme2mars/jotcli/internal/markdown"
+)
+
+// MemoryStore is a NoteStore that keeps notes in memory. It behaves like
+// SQLiteStore, except that search matches words and "quoted phrases"
+// instead of understanding the full FTS5 syntax.
+type MemoryStore struct {
+	mu     sync.Mutex
+	nextID int
+	notes  map[int]*memoryNote
+	saved  map[string]SavedSearch        // by lower-case name
+	vaults map[string]map[int]VaultEntry // by vault folder, then note ID
+}
+
+type memoryNote struct {
+	note      Note
+	revisions []Revision
+	links     []Link
+}
+
+// NewMemoryStore returns an empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		notes:  make(map[int]*memoryNote),
+		saved:  make(map[string]SavedSearch),
+		vaults: make(map[string]map[int]VaultEntry),
+	}
+}
+
+// mergeTags adds tags to a sorted tag list, the way addNoteTags does.
+func mergeTags(current []string, tags []string) []string {
+	for _, tag := range ParseTags(tags) {
+		if !slices.Contains(current, tag) {
+			current = append(current, tag)
+		}
+	}
+	slices.Sort(current)
+	return current
+}
+
+// copyNote returns a note that doesn't share its tag list with the store.
+func copyNote(n Note) Note {
+	n.Tags = slices.Clone(n.Tags)
+	n.DeletedAt = copyTime(n.DeletedAt)
+	n.DueAt = copyTime(n.DueAt)
+	n.NotifiedAt = copyTime(n.NotifiedAt)
+	return n
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+func (s *MemoryStore) Add(ctx context.Context, n NewNote) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.add(n)
+}
+
+// add saves a new note. The caller holds s.mu.
+func (s *MemoryStore) add(n NewNote) (int, error) {
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	if n.UpdatedAt.IsZero() {
+		n.UpdatedAt = n.CreatedAt
+	}
+	if n.JournalDate != "" && s.journalEntry(n.JournalDate) != nil {
+		return 0, fmt.Errorf("could not add note: there is already a journal entry for %s", n.JournalDate)
+	}
+
+	n.Title = strings.TrimSpace(n.Title)
+	slug, _ := uniqueSlug(displayTitle(n.Title, n.Content), s.slugTaken(0))
+
+	s.nextID++
+	id := s.nextID
+	s.notes[id] = &memoryNote{
+		note: Note{
+			ID:          id,
+			Content:     n.Content,
+			Tags:        mergeTags(nil, append(n.Tags, ExtractHashtags(n.Content)...)),
+			Priority:    n.Priority,
+			Status:      n.Status,
+			CreatedAt:   n.CreatedAt,
+			UpdatedAt:   n.UpdatedAt,
+			DueAt:       copyTime(n.DueAt),
+			Recurrence:  n.Recurrence,
+			JournalDate: n.JournalDate,
+			Title:       n.Title,
+			Slug:        slug,
+		},
+		revisions: []Revision{{NoteID: id, Rev: 1, Content: n.Content, CreatedAt: n.UpdatedAt}},
+	}
+	s.setLinks(s.notes[id])
+	return id, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id int) (*Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.notes[id]
+	if !ok || m.note.DeletedAt != nil {
+		return nil, nil
+	}
+	n := copyNote(m.note)
+	return &n, nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, id int, content string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.notes[id]
+	if !ok || m.note.DeletedAt != nil {
+		return fmt.Errorf("could not update note %d: %w", id, ErrNoteNotFound)
+	}
+	if m.note.Content == content {
+		return nil
+	}
+
+	now := time.Now()
+	m.note.Content = content
+	m.note.UpdatedAt = now
+	m.note.Tags = mergeTags(m.note.Tags, ExtractHashtags(content))
+	m.revisions = append(m.revisions, Revision{NoteID: id, Rev: len(m.revisions) + 1, Content: content, CreatedAt: now})
+	s.setLinks(m)
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.notes[id]
+	if !ok || m.note.DeletedAt != nil {
+		return fmt.Errorf("could not delete note %d: %w", id, ErrNoteNotFound)
+	}
+	now := time.Now()
+	m.note.DeletedAt = &now
+	return nil
+}
+
+func (s *MemoryStore) Restore(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.notes[id]
+	if !ok || m.note.DeletedAt == nil {
+		return fmt.Errorf("note %d is not in the trash: %w", id, ErrNoteNotFound)
+	}
+	if m.note.JournalDate != "" && s.journalEntry(m.note.JournalDate) != nil {
+		return fmt.Errorf("could not restore note %d: there is already a journal entry for %s", id, m.note.JournalDate)
+	}
+	m.note.DeletedAt = nil
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, filter NoteFilter) ([]Note, error) {
+	results, err := s.match(ctx, filter, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := sortResults(results, filter.Sort, filter.Reverse, SortCreated); err != nil {
+		return nil, err
+	}
+
+	notes := make([]Note, len(results))
+	for i, r := range results {
+		notes[i] = r.Note
+	}
+	return notes, nil
+}
+
+func (s *MemoryStore) Search(ctx context.Context, query string, filter NoteFilter) ([]SearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		if filter.Sort == SortRelevance {
+			filter.Sort = ""
+		}
+		notes, err := s.List(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		return notesAsResults(notes), nil
+	}
+
+	results, err := s.match(ctx, filter, terms)
+	if err != nil {
+		return nil, err
+	}
+	if err := sortResults(results, filter.Sort, filter.Reverse, SortRelevance); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// match returns the live notes passing filter's conditions whose content
+// contains every term, with a snippet and rank when there are terms.
+func (s *MemoryStore) match(ctx context.Context, filter NoteFilter, terms [][]rune) ([]SearchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tags := ParseTags(filter.Tags)
+	var results []SearchResult
+	for _, m := range s.notes {
+		if m.note.DeletedAt != nil || !hasTags(m.note.Tags, tags, filter.MatchAll) {
+			continue
+		}
+		if !inPeriod(m.note.CreatedAt, filter.Since, filter.Until) {
+			continue
+		}
+		if !filter.DueBefore.IsZero() && (m.note.DueAt == nil || !inPeriod(*m.note.DueAt, time.Time{}, filter.DueBefore)) {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, m.note.Status) {
+			continue
+		}
+		if filter.Recurring && m.note.Recurrence == "" {
+			continue
+		}
+		if filter.Journal && m.note.JournalDate == "" {
+			continue
+		}
+		if filter.Where != nil && !filter.Where.Match(m.note) {
+			continue
+		}
+		r := SearchResult{Note: copyNote(m.note)}
+		if len(terms) > 0 {
+			var ok bool
+			if r.Snippet, r.Rank, ok = searchContent(m.note.Content, terms); !ok {
+				continue
+			}
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// hasTags is tagCondition for notes in memory: a filter tag matches itself
+// and its children.
+func hasTags(noteTags, filter []string, matchAll bool) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, want := range filter {
+		found := slices.ContainsFunc(noteTags, func(tag string) bool {
+			return tag == want || strings.HasPrefix(tag, want+"/")
+		})
+		if found && !matchAll {
+			return true
+		}
+		if !found && matchAll {
+			return false
+		}
+	}
+	return matchAll
+}
+
+// inPeriod is the Since and Until condition of NoteFilter.where for notes in
+// memory, comparing times the same way.
+func inPeriod(t, since, until time.Time) bool {
+	s := t.Format(TimestampPrefix)
+	if !since.IsZero() && s < since.Format(TimestampPrefix) {
+		return false
+	}
+	if !until.IsZero() && s >= until.Format(TimestampPrefix) {
+		return false
+	}
+	return true
+}
+
+// searchTerms splits a query into lower-case words and "quoted phrases",
+// ignoring FTS5 operators and punctuation.
+func searchTerms(query string) [][]rune {
+	var terms [][]rune
+	for i, part := range strings.Split(query, `"`) {
+		if i%2 == 1 {
+			if phrase := strings.Join(strings.Fields(part), " "); phrase != "" {
+				terms = append(terms, lowerRunes(phrase))
+			}
+			continue
+		}
+		for _, word := range strings.FieldsFunc(part, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+		}) {
+			switch word {
+			case "AND", "OR", "NOT", "NEAR":
+				continue
+			}
+			terms = append(terms, lowerRunes(word))
+		}
+	}
+	return terms
+}
+
+func lowerRunes(s string) []rune {
+	r := []rune(s)
+	for i := range r {
+		r[i] = unicode.ToLower(r[i])
+	}
+	return r
+}
+
+// searchContent reports whether content contains every term. The rank is
+// negative like BM25's, more matches ranking higher, and the snippet shows
+// the first match with every occurrence of a term highlighted.
+func searchContent(content string, terms [][]rune) (snippet string, rank float64, ok bool) {
+	text := []rune(content)
+	lower := lowerRunes(content)
+
+	// Every term's matches, as rune offsets
+	highlight := make([]int, len(text)+1) // >0 marks a match start, holding its length
+	first := -1
+	for _, term := range terms {
+		found := false
+		for i := 0; i+len(term) <= len(lower); i++ {
+			if slices.Equal(lower[i:i+len(term)], term) {
+				found = true
+				rank--
+				if len(term) > highlight[i] {
+					highlight[i] = len(term)
+				}
+				if first < 0 || i < first {
+					first = i
+				}
+			}
+		}
+		if !found {
+			return "", 0, false
+		}
+	}
+
+	// A window of text around the first match
+	const before, width = 30, 100
+	start := max(0, first-before)
+	end := min(len(text), start+width)
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; i++ {
+		if n := highlight[i]; n > 0 {
+			stop := min(i+n, len(text))
+			b.WriteString(HighlightStart + string(text[i:stop]) + HighlightEnd)
+			i = stop - 1
+			continue
+		}
+		if text[i] == '\n' {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(text[i])
+	}
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), rank, true
+}
+
+func (s *MemoryStore) Revisions(ctx context.Context, id int) ([]Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.notes[id]
+	if !ok {
+		return nil, nil
+	}
+	return slices.Clone(m.revisions), nil
+}
+
+func (s *MemoryStore) Revert(ctx context.Context, id, rev int) error {
+	revisions, err := s.Revisions(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rev < 1 || rev > len(revisions) {
+		return fmt.Errorf("note %d has no revision %d", id, rev)
+	}
+	return s.Update(ctx, id, revisions[rev-1].Content)
+}
+
+func (s *MemoryStore) SavedSearches(ctx context.Context) ([]SavedSearch, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var saved []SavedSearch
+	for _, ss := range s.saved {
+		saved = append(saved, ss)
+	}
+	slices.SortFunc(saved, func(a, b SavedSearch) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return saved, nil
+}
+
+func (s *MemoryStore) SavedSearch(ctx context.Context, name string) (*SavedSearch, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ss, ok := s.saved[strings.ToLower(name)]
+	if !ok {
+		return nil, nil
+	}
+	return &ss, nil
+}
+
+func (s *MemoryStore) SaveSearch(ctx context.Context, ss SavedSearch) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(ss.Name)
+	if old, ok := s.saved[key]; ok {
+		ss.CreatedAt = old.CreatedAt
+	} else if ss.CreatedAt.IsZero() {
+		ss.CreatedAt = time.Now()
+	}
+	s.saved[key] = ss
+	return nil
+}
+
+func (s *MemoryStore) DeleteSavedSearch(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(name)
+	if _, ok := s.saved[key]; !ok {
+		return fmt.Errorf("could not delete saved search %q: %w", name, ErrSavedSearchNotFound)
+	}
+	delete(s.saved, key)
+	return nil
+}
+
+func (s *MemoryStore) SetDue(ctx context.Context, id int, due *time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.notes[id]
+	if !ok || m.note.DeletedAt != nil {
+		return fmt.Errorf("could not set due date of note %d: %w", id, ErrNoteNotFound)
+	}
+	m.note.DueAt = copyTime(due)
+	m.note.NotifiedAt = nil
+	m.note.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *MemoryStore) Reminders(ctx context.Context, now time.Time) ([]Note, error) {
+	results, err := s.match(ctx, NoteFilter{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := now.Format(TimestampPrefix)
+	var notes []Note
+	for _, r := range results {
+		if r.DueAt != nil && r.NotifiedAt == nil && r.DueAt.Format(TimestampPrefix) <= limit && !r.Closed() {
+			notes = append(notes, r.Note)
+		}
+	}
+	slices.SortFunc(notes, func(a, b Note) int {
+		if c := a.DueAt.Compare(*b.DueAt); c != 0 {
+			return c
+		}
+		return a.ID - b.ID
+	})
+	return notes, nil
+}
+
+func (s *MemoryStore) MarkNotified(ctx context.Context, id int, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.notes[id]
+	if !ok {
+		return fmt.Errorf("could not mark note %d as notified: %w", id, ErrNoteNotFound)
+	}
+	m.note.NotifiedAt = &at
+	return nil
+}
+
+func (s *MemoryStore) SetStatus(ctx context.Context, id int, status string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	m, ok := s.notes[id]
+	if !ok || m.note.DeletedAt != nil {
+		s.mu.Unlock()
+		return 0, fmt.Errorf("could not set status of note %d: %w", id, ErrNoteNotFound)
+	}
+	now := time.Now()
+	m.note.Status = status
+	m.note.UpdatedAt = now
+
+	var next NewNote
+	var repeat bool
+	var err error
+	if status == StatusDone {
+		if next, repeat, err = nextOccurrence(m.note, now); repeat {
+			m.note.Recurrence = ""
+		}
+	}
+	s.mu.Unlock()
+
+	if err != nil || !repeat {
+		return 0, err
+	}
+	return s.Add(ctx, next)
+}
+
+func (s *MemoryStore) SetRecurrence(ctx context.Context, id int, rule string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.notes[id]
+	if !ok || m.note.DeletedAt != nil {
+		return fmt.Errorf("could not set recurrence of note %d: %w", id, ErrNoteNotFound)
+	}
+	m.note.Recurrence = rule
+	return nil
+}
+
+func (s *MemoryStore) JournalEntry(ctx context.Context, date string) (*Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := s.journalEntry(date)
+	if m == nil {
+		return nil, nil
+	}
+	n := copyNote(m.note)
+	return &n, nil
+}
+
+// journalEntry finds the live journal entry for a day. The caller holds s.mu.
+func (s *MemoryStore) journalEntry(date string) *memoryNote {
+	for _, m := range s.notes {
+		if m.note.JournalDate == date && m.note.DeletedAt == nil {
+			return m
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Links(ctx context.Context, id int) ([]Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.notes[id]
+	if !ok {
+		return nil, nil
+	}
+	links := slices.Clone(m.links)
+	for i, l := range links {
+		if target, ok := s.notes[l.TargetID]; !ok || target.note.DeletedAt != nil {
+			links[i].TargetID = 0
+		}
+	}
+	return links, nil
+}
+
+func (s *MemoryStore) Backlinks(ctx context.Context, id int) ([]Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var notes []Note
+	for _, m := range s.notes {
+		if m.note.DeletedAt == nil && slices.ContainsFunc(m.links, func(l Link) bool { return l.TargetID == id }) {
+			notes = append(notes, copyNote(m.note))
+		}
+	}
+	slices.SortFunc(notes, func(a, b Note) int { return a.ID - b.ID })
+	return notes, nil
+}
+
+// setLinks replaces the links a note makes with the ones in its content,
+// then points any broken links that match the note at it, like the SQLite
+// version. The caller holds s.mu.
+func (s *MemoryStore) setLinks(m *memoryNote) {
+	m.links = nil
+	for _, target := range markdown.Links(m.note.Content) {
+		m.links = append(m.links, Link{SourceID: m.note.ID, Target: target, TargetID: s.resolveLink(target)})
+	}
+
+	keys := []string{strconv.Itoa(m.note.ID), m.note.Slug, markdown.LinkKey(m.note.DisplayTitle())}
+	for _, other := range s.notes {
+		for i, l := range other.links {
+			if l.TargetID == 0 && slices.Contains(keys, markdown.LinkKey(l.Target)) {
+				other.links[i].TargetID = m.note.ID
+			}
+		}
+	}
+}
+
+// resolveLink finds the note a link points to, like the SQLite version. The
+// caller holds s.mu.
+func (s *MemoryStore) resolveLink(target string) int {
+	if id, err := strconv.Atoi(target); err == nil {
+		if _, ok := s.notes[id]; ok {
+			return id
+		}
+		return 0
+	}
+
+	key := markdown.LinkKey(target)
+	for id, m := range s.notes {
+		if m.note.Slug == key {
+			return id
+		}
+	}
+	found := 0
+	for id, m := range s.notes {
+		if m.note.DeletedAt == nil && (found == 0 || id < found) && markdown.LinkKey(m.note.DisplayTitle()) == key {
+			found = id
+		}
+	}
+	return found
+}
+
+func (s *MemoryStore) SetTitle(ctx context.Context, id int, title string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.notes[id]
+	if !ok || m.note.DeletedAt != nil {
+		return fmt.Errorf("could not set title of note %d: %w", id, ErrNoteNotFound)
+	}
+	m.note.Title = strings.TrimSpace(title)
+	m.note.Slug, _ = uniqueSlug(m.note.DisplayTitle(), s.slugTaken(id))
+	m.note.UpdatedAt = time.Now()
+	s.setLinks(m)
+	return nil
+}
+
+// slugTaken is the SQLite version's check, over the notes in memory. The
+// caller holds s.mu.
+func (s *MemoryStore) slugTaken(id int) func(string) (bool, error) {
+	return func(slug string) (bool, error) {
+		for other, m := range s.notes {
+			if other != id && m.note.Slug == slug {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+func (s *MemoryStore) SetPriority(ctx context.Context, id int, priority string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.notes[id]
+	if !ok {
+		return fmt.Errorf("could not set priority of note %d: %w", id, ErrNoteNotFound)
+	}
+	if m.note.Priority != priority {
+		m.note.Priority = priority
+		m.note.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *MemoryStore) Tags(ctx context.Context) ([]TagCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, m := range s.notes {
+		live := 0
+		if m.note.DeletedAt == nil {
+			live = 1
+		}
+		for _, tag := range m.note.Tags {
+			counts[tag] += live
+		}
+	}
+	tags := make([]TagCount, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, TagCount{Name: name, Count: count})
+	}
+	slices.SortFunc(tags, func(a, b TagCount) int { return strings.Compare(a.Name, b.Name) })
+	return tags, nil
+}
+
+func (s *MemoryStore) SetTags(ctx context.Context, id int, tags []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.notes[id]
+	if !ok {
+		return fmt.Errorf("could not set tags of note %d: %w", id, ErrNoteNotFound)
+	}
+	m.note.Tags = mergeTags(nil, tags)
+	return nil
+}
+
+// inTagFamily reports whether tag is family or one of its children.
+func inTagFamily(tag, family string) bool {
+	return tag == family || strings.HasPrefix(tag, family+"/")
+}
+
+// hasTagFamily reports whether any note carries a tag or one of its
+// children. The caller holds s.mu.
+func (s *MemoryStore) hasTagFamily(tag string) bool {
+	for _, m := range s.notes {
+		if slices.ContainsFunc(m.note.Tags, func(t string) bool { return inTagFamily(t, tag) }) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) MergeTags(ctx context.Context, sources []string, target string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	target = NormalizeTag(target)
+	if target == "" {
+		return fmt.Errorf("invalid tag name")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Check every source before changing anything, as the SQLite version
+	// rolls back
+	var merge []string
+	for _, source := range topTags(ParseTags(sources)) {
+		if source == target {
+			continue
+		}
+		if strings.HasPrefix(target, source+"/") {
+			return fmt.Errorf("cannot move %q into its own child %q", source, target)
+		}
+		if !s.hasTagFamily(source) {
+			return fmt.Errorf("%w: %s", ErrTagNotFound, source)
+		}
+		merge = append(merge, source)
+	}
+
+	for _, m := range s.notes {
+		tags := make([]string, len(m.note.Tags))
+		for i, tag := range m.note.Tags {
+			tags[i] = tag
+			for _, source := range merge {
+				if inTagFamily(tags[i], source) {
+					tags[i] = target + strings.TrimPrefix(tags[i], source)
+				}
+			}
+		}
+		m.note.Tags = mergeTags(nil, tags)
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteTag(ctx context.Context, name string, deleteNotes bool) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	name = NormalizeTag(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.hasTagFamily(name) {
+		return 0, fmt.Errorf("%w: %s", ErrTagNotFound, name)
+	}
+
+	now := time.Now()
+	count := 0
+	for _, m := range s.notes {
+		tags := slices.DeleteFunc(slices.Clone(m.note.Tags), func(t string) bool { return inTagFamily(t, name) })
+		if len(tags) == len(m.note.Tags) {
+			continue
+		}
+		m.note.Tags = tags
+		if m.note.DeletedAt != nil {
+			continue
+		}
+		count++
+		if deleteNotes {
+			m.note.DeletedAt = &now
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) Trash(ctx context.Context) ([]Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var notes []Note
+	for _, m := range s.notes {
+		if m.note.DeletedAt != nil {
+			notes = append(notes, copyNote(m.note))
+		}
+	}
+	slices.SortFunc(notes, func(a, b Note) int { return b.DeletedAt.Compare(*a.DeletedAt) })
+	return notes, nil
+}
+
+func (s *MemoryStore) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for id, m := range s.notes {
+		if m.note.DeletedAt != nil && (before.IsZero() || m.note.DeletedAt.Before(before)) {
+			delete(s.notes, id)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) Import(ctx context.Context, notes []NewNote, dryRun bool) (ImportResult, error) {
+	var res ImportResult
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Like the SQLite version, check everything before saving anything
+	seen := make(map[string]bool)
+	for _, m := range s.notes {
+		seen[strings.TrimSpace(m.note.Content)] = true
+	}
+	for _, n := range notes {
+		n.Content = strings.TrimSpace(n.Content)
+		if n.Content == "" {
+			res.Empty++
+			continue
+		}
+		if n.Priority == "" {
+			n.Priority = "low"
+		}
+		if seen[n.Content] {
+			res.Duplicates = append(res.Duplicates, n)
+			continue
+		}
+		if n.JournalDate != "" && s.journalEntry(n.JournalDate) != nil {
+			return res, fmt.Errorf("could not import note: there is already a journal entry for %s", n.JournalDate)
+		}
+		seen[n.Content] = true
+		res.Imported = append(res.Imported, n)
+	}
+
+	if dryRun {
+		return res, nil
+	}
+	for _, n := range res.Imported {
+		if _, err := s.add(n); err != nil {
+			return res, fmt.Errorf("could not import note: %v", err)
+		}
+	}
+	return res, nil
+}
+
+func (s *MemoryStore) VaultEntries(ctx context.Context, vault string) (map[int]VaultEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make(map[int]VaultEntry, len(s.vaults[vault]))
+	for id, e := range s.vaults[vault] {
+		entries[id] = e
+	}
+	return entries, nil
+}
+
+func (s *MemoryStore) SaveVaultEntry(ctx context.Context, vault string, e VaultEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.vaults[vault] == nil {
+		s.vaults[vault] = make(map[int]VaultEntry)
+	}
+	e.SyncedAt = time.Now()
+	s.vaults[vault][e.NoteID] = e
+	return nil
+}
+
+func (s *MemoryStore) DeleteVaultEntry(ctx context.Context, vault string, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.vaults[vault], id)
+	return nil
+}
@@ -0,0 +1,264 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrSchemaTooNew is returned when the database was written by a newer
+// version of jotcli than the one currently running.
+var ErrSchemaTooNew = errors.New("database schema is newer than this version of jotcli")
+
+// migration is a single, ordered step in the schema history. The schema
+// version stored in PRAGMA user_version is the number of migrations applied.
+type migration struct {
+	description string
+	up          func(tx *sql.Tx) error
+}
+
+// migrations must only ever be appended to. Never edit or reorder an entry
+// that has shipped, as existing databases have already applied it.
+var migrations = []migration{
+	{
+		description: "create notes table",
+		up: execAll(`CREATE TABLE IF NOT EXISTS notes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			content TEXT NOT NULL,
+			tag TEXT,
+			priority TEXT,
+			created_at DATETIME
+		);`),
+	},
+	{
+		description: "add full-text search index",
+		up: execAll(
+			`CREATE VIRTUAL TABLE IF NOT EXISTS notes_fts USING fts5(
+				content,
+				content='notes',
+				content_rowid='id',
+				tokenize='unicode61 remove_diacritics 2'
+			);`,
+			`CREATE TRIGGER IF NOT EXISTS notes_fts_insert AFTER INSERT ON notes BEGIN
+				INSERT INTO notes_fts(rowid, content) VALUES (new.id, new.content);
+			END;`,
+			`CREATE TRIGGER IF NOT EXISTS notes_fts_delete AFTER DELETE ON notes BEGIN
+				INSERT INTO notes_fts(notes_fts, rowid, content) VALUES ('delete', old.id, old.content);
+			END;`,
+			`CREATE TRIGGER IF NOT EXISTS notes_fts_update AFTER UPDATE OF content ON notes BEGIN
+				INSERT INTO notes_fts(notes_fts, rowid, content) VALUES ('delete', old.id, old.content);
+				INSERT INTO notes_fts(rowid, content) VALUES (new.id, new.content);
+			END;`,
+			`INSERT INTO notes_fts(notes_fts) VALUES ('rebuild');`,
+		),
+	},
+	{
+		description: "replace the tag column with tags and note_tags tables",
+		up: func(tx *sql.Tx) error {
+			err := execAll(
+				`CREATE TABLE tags (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					name TEXT NOT NULL UNIQUE
+				);`,
+				`CREATE TABLE note_tags (
+					note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+					tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+					PRIMARY KEY (note_id, tag_id)
+				);`,
+				`CREATE INDEX note_tags_tag_id ON note_tags(tag_id);`,
+			)(tx)
+			if err != nil {
+				return err
+			}
+			return migrateTagColumn(tx)
+		},
+	},
+	{
+		description: "add deleted_at for the trash",
+		up: execAll(
+			`ALTER TABLE notes ADD COLUMN deleted_at DATETIME;`,
+			`CREATE INDEX notes_deleted_at ON notes(deleted_at);`,
+		),
+	},
+	{
+		description: "add note revision history",
+		up: execAll(
+			`CREATE TABLE note_revisions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+				rev INTEGER NOT NULL,
+				content TEXT NOT NULL,
+				created_at DATETIME NOT NULL,
+				UNIQUE (note_id, rev)
+			);`,
+			// Existing notes start their history with their current content
+			`INSERT INTO note_revisions (note_id, rev, content, created_at)
+				SELECT id, 1, content, created_at FROM notes;`,
+		),
+	},
+	{
+		description: "add updated_at to notes",
+		up: execAll(
+			`ALTER TABLE notes ADD COLUMN updated_at DATETIME;`,
+			`UPDATE notes SET updated_at = COALESCE(
+				(SELECT MAX(r.created_at) FROM note_revisions r WHERE r.note_id = notes.id),
+				created_at
+			);`,
+		),
+	},
+	{
+		// No foreign key on note_id: entries have to outlive purged notes so
+		// the next sync knows to remove their files from the vault.
+		description: "add vault sync state",
+		up: execAll(
+			`CREATE TABLE vault_sync (
+				vault TEXT NOT NULL,
+				note_id INTEGER NOT NULL,
+				path TEXT NOT NULL,
+				note_hash TEXT NOT NULL,
+				file_hash TEXT NOT NULL,
+				synced_at DATETIME NOT NULL,
+				PRIMARY KEY (vault, note_id)
+			);`,
+		),
+	},
+	{
+		description: "add saved searches",
+		up: execAll(
+			`CREATE TABLE saved_searches (
+				name TEXT PRIMARY KEY COLLATE NOCASE,
+				query TEXT NOT NULL,
+				created_at DATETIME NOT NULL
+			);`,
+		),
+	},
+	{
+		description: "add due dates and reminders",
+		up: execAll(
+			`ALTER TABLE notes ADD COLUMN due_at DATETIME;`,
+			`ALTER TABLE notes ADD COLUMN notified_at DATETIME;`,
+			`CREATE INDEX notes_due_at ON notes(due_at);`,
+		),
+	},
+	{
+		description: "add task status",
+		up: execAll(
+			`ALTER TABLE notes ADD COLUMN status TEXT NOT NULL DEFAULT '';`,
+			`CREATE INDEX notes_status ON notes(status);`,
+		),
+	},
+	{
+		description: "add recurring notes",
+		up:          execAll(`ALTER TABLE notes ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';`),
+	},
+	{
+		// One live entry per day; an entry in the trash doesn't count
+		description: "add journal entries",
+		up: execAll(
+			`ALTER TABLE notes ADD COLUMN journal_date TEXT;`,
+			`CREATE UNIQUE INDEX notes_journal_date ON notes(journal_date) WHERE deleted_at IS NULL;`,
+		),
+	},
+	{
+		// target_id is filled in when a note matching the link exists
+		description: "add links between notes",
+		up: func(tx *sql.Tx) error {
+			err := execAll(
+				`CREATE TABLE links (
+					source_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+					target TEXT NOT NULL,
+					target_key TEXT NOT NULL,
+					target_id INTEGER REFERENCES notes(id) ON DELETE SET NULL,
+					PRIMARY KEY (source_id, target_key)
+				);`,
+				`CREATE INDEX links_target_id ON links(target_id);`,
+				`CREATE INDEX links_target_key ON links(target_key);`,
+			)(tx)
+			if err != nil {
+				return err
+			}
+			return migrateLinks(tx)
+		},
+	},
+	{
+		// title is only set when given explicitly; slugs stay put in the trash
+		description: "add titles and slugs",
+		up: func(tx *sql.Tx) error {
+			err := execAll(
+				`ALTER TABLE notes ADD COLUMN title TEXT NOT NULL DEFAULT '';`,
+				`ALTER TABLE notes ADD COLUMN slug TEXT;`,
+			)(tx)
+			if err != nil {
+				return err
+			}
+			if err := migrateSlugs(tx); err != nil {
+				return err
+			}
+			return execAll(`CREATE UNIQUE INDEX notes_slug ON notes(slug);`)(tx)
+		},
+	},
+}
+
+// execAll returns a migration step that runs the given statements in order.
+func execAll(statements ...string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		for _, stmt := range statements {
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// SchemaVersion is the schema version this binary knows how to handle.
+func SchemaVersion() int {
+	return len(migrations)
+}
+
+// UserVersion reads the schema version recorded in the database file.
+func UserVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow(`PRAGMA user_version`).Scan(&version)
+	return version, err
+}
+
+// Migrate brings the database up to SchemaVersion, applying each pending
+// migration in its own transaction. It refuses to touch a database whose
+// schema is newer than this binary understands.
+func Migrate(db *sql.DB) error {
+	current, err := UserVersion(db)
+	if err != nil {
+		return fmt.Errorf("could not read schema version: %v", err)
+	}
+
+	if current > SchemaVersion() {
+		return fmt.Errorf("%w (database is at version %d, jotcli supports up to %d)", ErrSchemaTooNew, current, SchemaVersion())
+	}
+
+	for i := current; i < len(migrations); i++ {
+		if err := applyMigration(db, i+1, migrations[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyMigration(db *sql.DB, version int, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %v", version, m.description, err)
+	}
+
+	// PRAGMA does not accept bound parameters, but version is always an int we control.
+	if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version)); err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %v", version, m.description, err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/markdown"
+	"github.com/flyme2mars/jotcli/internal/recur"
+)
+
+// nextOccurrence returns the note that follows a recurring task once it is
+// done: the same note with its checklist cleared, open again and due at the
+// rule's next date after now. It reports false for notes that don't repeat.
+func nextOccurrence(n Note, now time.Time) (NewNote, bool, error) {
+	if n.Recurrence == "" || n.DueAt == nil {
+		return NewNote{}, false, nil
+	}
+	rule, err := recur.Parse(n.Recurrence)
+	if err != nil {
+		return NewNote{}, false, fmt.Errorf("note %d has an invalid recurrence: %v", n.ID, err)
+	}
+
+	// The next occurrence may be clamped to a short month, so the series
+	// remembers the day it started on
+	rule = rule.Anchor(*n.DueAt)
+	due := rule.NextAfter(*n.DueAt, now)
+	return NewNote{
+		Content:    markdown.ResetChecklist(n.Content),
+		Title:      n.Title,
+		Tags:       n.Tags,
+		Priority:   n.Priority,
+		Status:     StatusOpen,
+		DueAt:      &due,
+		Recurrence: rule.String(),
+	}, true, nil
+}
+
+func (s *SQLiteStore) SetRecurrence(ctx context.Context, id int, rule string) error {
+	query := `UPDATE notes SET recurrence = ? WHERE id = ? AND deleted_at IS NULL`
+	res, err := s.db.ExecContext(ctx, query, rule, id)
+	if err != nil {
+		return fmt.Errorf("could not set recurrence: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("could not set recurrence of note %d: %w", id, ErrNoteNotFound)
+	}
+	return nil
+}
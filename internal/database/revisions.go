@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Revision is one saved version of a note's content. Revisions are numbered
+// from 1, and the highest revision always matches the note's current content.
+type Revision struct {
+	NoteID    int
+	Rev       int
+	Content   string
+	CreatedAt time.Time
+}
+
+func addRevision(tx *sql.Tx, noteID int, content string, at time.Time) error {
+	query := `INSERT INTO note_revisions (note_id, rev, content, created_at)
+		SELECT ?, COALESCE(MAX(rev), 0) + 1, ?, ? FROM note_revisions WHERE note_id = ?`
+	_, err := tx.Exec(query, noteID, content, at, noteID)
+	return err
+}
+
+// GetRevisions returns every revision of a note, oldest first.
+func GetRevisions(noteID int) ([]Revision, error) {
+	return defaultStore().Revisions(context.Background(), noteID)
+}
+
+// GetRevision returns a single revision of a note, or nil if it doesn't exist.
+func GetRevision(noteID, rev int) (*Revision, error) {
+	query := `SELECT note_id, rev, content, created_at FROM note_revisions WHERE note_id = ? AND rev = ?`
+
+	var r Revision
+	err := DB.QueryRow(query, noteID, rev).Scan(&r.NoteID, &r.Rev, &r.Content, &r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// RevertNote restores the content of an earlier revision. The revert is
+// itself saved as a new revision, so history is never rewritten.
+func RevertNote(noteID, rev int) error {
+	return defaultStore().Revert(context.Background(), noteID, rev)
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSavedSearchNotFound is returned when a saved search name doesn't exist.
+var ErrSavedSearchNotFound = errors.New("saved search not found")
+
+// SavedSearch is a query saved under a name, run with `jotcli saved run`,
+// `jotcli list @name` or from the TUI sidebar. Names are case-insensitive.
+type SavedSearch struct {
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *SQLiteStore) SavedSearches(ctx context.Context) ([]SavedSearch, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT name, query, created_at FROM saved_searches ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var saved []SavedSearch
+	for rows.Next() {
+		var ss SavedSearch
+		if err := rows.Scan(&ss.Name, &ss.Query, &ss.CreatedAt); err != nil {
+			return nil, err
+		}
+		saved = append(saved, ss)
+	}
+	return saved, rows.Err()
+}
+
+func (s *SQLiteStore) SavedSearch(ctx context.Context, name string) (*SavedSearch, error) {
+	var ss SavedSearch
+	query := `SELECT name, query, created_at FROM saved_searches WHERE name = ?`
+	err := s.db.QueryRowContext(ctx, query, name).Scan(&ss.Name, &ss.Query, &ss.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ss, nil
+}
+
+func (s *SQLiteStore) SaveSearch(ctx context.Context, ss SavedSearch) error {
+	if ss.CreatedAt.IsZero() {
+		ss.CreatedAt = time.Now()
+	}
+	query := `INSERT INTO saved_searches (name, query, created_at) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET name = excluded.name, query = excluded.query`
+	if _, err := s.db.ExecContext(ctx, query, ss.Name, ss.Query, ss.CreatedAt); err != nil {
+		return fmt.Errorf("could not save search: %v", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) DeleteSavedSearch(ctx context.Context, name string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM saved_searches WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("could not delete saved search: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("could not delete saved search %q: %w", name, ErrSavedSearchNotFound)
+	}
+	return nil
+}
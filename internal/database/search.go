@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// Snippets returned by SearchNotes wrap matched terms in these markers so
+// callers can style them however suits their output.
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// SearchResult is a note matched by a full-text query, along with a short
+// excerpt around the match.
+type SearchResult struct {
+	Note
+	Snippet string  `json:"snippet,omitempty"`
+	Rank    float64 `json:"rank"`
+}
+
+// StripHighlights removes the match markers from a snippet.
+func StripHighlights(snippet string) string {
+	return strings.NewReplacer(HighlightStart, "", HighlightEnd, "").Replace(snippet)
+}
+
+// SearchNotes runs an FTS5 query against note content and returns the
+// matches ordered by BM25 relevance, unless filter asks for another order.
+// The query supports the full FTS5 syntax: "exact phrases", prefix*,
+// NEAR(a b), AND, OR and NOT. If the query isn't valid FTS5 syntax it is
+// retried with every term quoted, so stray punctuation never turns into an
+// error for the user.
+func SearchNotes(query string, filter NoteFilter) ([]SearchResult, error) {
+	return defaultStore().Search(context.Background(), query, filter)
+}
+
+// notesAsResults wraps plain notes as search results without snippets.
+func notesAsResults(notes []Note) []SearchResult {
+	results := make([]SearchResult, len(notes))
+	for i, n := range notes {
+		results[i] = SearchResult{Note: n}
+	}
+	return results
+}
+
+func isFTSSyntaxError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "fts5: syntax error") ||
+		strings.Contains(msg, "unterminated string") ||
+		strings.Contains(msg, "no such column") ||
+		strings.Contains(msg, "unknown special query")
+}
+
+// quoteTerms turns free text into a query of quoted terms that are all
+// required to match, dropping any FTS5 operators the user typed.
+func quoteTerms(query string) string {
+	var terms []string
+	for _, field := range strings.Fields(query) {
+		field = strings.ReplaceAll(field, `"`, "")
+		if field == "" {
+			continue
+		}
+		terms = append(terms, `"`+field+`"`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// PrefixQuery adapts a query being typed interactively so the word under the
+// cursor matches as a prefix, e.g. "app" finds "apple".
+func PrefixQuery(query string) string {
+	trimmed := strings.TrimRightFunc(query, unicode.IsSpace)
+	if trimmed == "" || trimmed != query {
+		return query
+	}
+
+	fields := strings.Fields(trimmed)
+	last := fields[len(fields)-1]
+	switch last {
+	case "AND", "OR", "NOT":
+		return query
+	}
+
+	r := []rune(trimmed)
+	if !unicode.IsLetter(r[len(r)-1]) && !unicode.IsDigit(r[len(r)-1]) {
+		return query
+	}
+	return trimmed + "*"
+}
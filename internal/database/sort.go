@@ -0,0 +1,146 @@
+package database
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Sort fields accepted in NoteFilter.Sort.
+const (
+	SortCreated   = "created"
+	SortUpdated   = "updated"
+	SortPriority  = "priority"
+	SortID        = "id"
+	SortDue       = "due"       // soonest first, notes without a due date first
+	SortRelevance = "relevance" // search results only
+)
+
+// SortFields lists the sort fields available for plain note listings, in
+// the order the TUI cycles through them.
+var SortFields = []string{SortCreated, SortUpdated, SortPriority, SortID}
+
+// priorityRank orders priorities by meaning rather than alphabetically.
+const priorityRank = `CASE lower(n.priority) WHEN 'high' THEN 3 WHEN 'medium' THEN 2 WHEN 'low' THEN 1 ELSE 0 END`
+
+// sortKey is one column of a sort order: the SQL expression for notes
+// aliased as n, the same comparison in Go for MemoryStore, and whether it
+// sorts descending by default.
+type sortKey struct {
+	expr    string
+	compare func(a, b *SearchResult) int
+	desc    bool
+}
+
+var (
+	byCreated   = sortKey{"n.created_at", func(a, b *SearchResult) int { return a.CreatedAt.Compare(b.CreatedAt) }, true}
+	byUpdated   = sortKey{"n.updated_at", func(a, b *SearchResult) int { return a.UpdatedAt.Compare(b.UpdatedAt) }, true}
+	byPriority  = sortKey{priorityRank, func(a, b *SearchResult) int { return cmp.Compare(priorityValue(a.Priority), priorityValue(b.Priority)) }, true}
+	byDue       = sortKey{"n.due_at", func(a, b *SearchResult) int { return compareDue(a.DueAt, b.DueAt) }, false}
+	byID        = sortKey{"n.id", func(a, b *SearchResult) int { return cmp.Compare(a.ID, b.ID) }, false}
+	byRelevance = sortKey{"bm25(notes_fts)", func(a, b *SearchResult) int { return cmp.Compare(a.Rank, b.Rank) }, false}
+)
+
+// sortKeys gives each sort field its natural direction (newest, highest
+// priority or lowest ID first) and a tie-breaker.
+var sortKeys = map[string][]sortKey{
+	SortCreated:   {byCreated, descending(byID)},
+	SortUpdated:   {byUpdated, descending(byID)},
+	SortPriority:  {byPriority, byCreated},
+	SortID:        {byID},
+	SortDue:       {byDue, byID},
+	SortRelevance: {byRelevance, byCreated},
+}
+
+func descending(k sortKey) sortKey {
+	k.desc = true
+	return k
+}
+
+// compareDue orders due dates the way SQLite orders the column, with no due
+// date before any.
+func compareDue(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return a.Compare(*b)
+}
+
+// priorityValue is priorityRank for notes already in memory.
+func priorityValue(priority string) int {
+	switch strings.ToLower(priority) {
+	case "high":
+		return 3
+	case "medium":
+		return 2
+	case "low":
+		return 1
+	}
+	return 0
+}
+
+// lookupSort finds the keys for a sort field. Relevance is only accepted
+// where it is also the fallback, which is for search results; anything else
+// has no rank to sort by.
+func lookupSort(field, fallback string) ([]sortKey, error) {
+	if field == "" {
+		field = fallback
+	}
+	if strings.EqualFold(field, SortRelevance) && fallback != SortRelevance {
+		return nil, errors.New("sorting by relevance only applies to search (use created, updated, priority, due or id)")
+	}
+	keys, ok := sortKeys[strings.ToLower(field)]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q (use created, updated, priority, due or id)", field)
+	}
+	return keys, nil
+}
+
+// orderBy builds the ORDER BY clause for a sort field, which reverse
+// flips. fallback is used when field is empty.
+func orderBy(field string, reverse bool, fallback string) (string, error) {
+	keys, err := lookupSort(field, fallback)
+	if err != nil {
+		return "", err
+	}
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		dir := "ASC"
+		if k.desc != reverse {
+			dir = "DESC"
+		}
+		parts[i] = k.expr + " " + dir
+	}
+	return " ORDER BY " + strings.Join(parts, ", "), nil
+}
+
+// sortResults orders results in memory the way orderBy orders them in SQL.
+func sortResults(results []SearchResult, field string, reverse bool, fallback string) error {
+	keys, err := lookupSort(field, fallback)
+	if err != nil {
+		return err
+	}
+
+	slices.SortStableFunc(results, func(a, b SearchResult) int {
+		for _, k := range keys {
+			c := k.compare(&a, &b)
+			if k.desc != reverse {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return nil
+}
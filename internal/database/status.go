@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Task statuses. Notes without a status are plain notes rather than tasks.
+const (
+	StatusOpen      = "open"
+	StatusDoing     = "doing"
+	StatusDone      = "done"
+	StatusCancelled = "cancelled"
+)
+
+// Statuses lists the task statuses in the order a task usually goes
+// through them.
+var Statuses = []string{StatusOpen, StatusDoing, StatusDone, StatusCancelled}
+
+// StatusMarks are the checkboxes that show a task's status in listings.
+var StatusMarks = map[string]string{
+	StatusOpen:      "[ ]",
+	StatusDoing:     "[~]",
+	StatusDone:      "[x]",
+	StatusCancelled: "[-]",
+}
+
+// ParseStatus checks a status given by the user, ignoring case.
+func ParseStatus(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, status := range Statuses {
+		if s == status {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("invalid status %q (use %s)", s, strings.Join(Statuses, ", "))
+}
+
+// Closed reports whether a note is a task that is done or cancelled.
+func (n Note) Closed() bool {
+	return n.Status == StatusDone || n.Status == StatusCancelled
+}
+
+func (s *SQLiteStore) SetStatus(ctx context.Context, id int, status string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("could not set status: %v", err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, `SELECT `+noteColumns+` FROM notes n WHERE n.id = ? AND n.deleted_at IS NULL`, id)
+	n, err := scanNote(row)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("could not set status of note %d: %w", id, ErrNoteNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("could not set status: %v", err)
+	}
+
+	now := time.Now()
+	query := `UPDATE notes SET status = ?, updated_at = ? WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, status, now, id); err != nil {
+		return 0, fmt.Errorf("could not set status: %v", err)
+	}
+
+	var nextID int
+	if status == StatusDone {
+		next, ok, err := nextOccurrence(n, now)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			if nextID, err = insertNote(tx, next); err != nil {
+				return 0, fmt.Errorf("could not create the next occurrence: %v", err)
+			}
+			// The series carries on from the new note
+			if _, err := tx.ExecContext(ctx, `UPDATE notes SET recurrence = '' WHERE id = ?`, id); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return nextID, tx.Commit()
+}
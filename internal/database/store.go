@@ -0,0 +1,307 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NoteStore is how the commands and the TUI read and write notes. It is
+// implemented by SQLiteStore for real use and by MemoryStore for tests and
+// anything else that shouldn't touch a database file.
+type NoteStore interface {
+	// Add saves a new note, tagging it with n.Tags and any #hashtags in its
+	// content, and returns its ID.
+	Add(ctx context.Context, n NewNote) (int, error)
+	// Get returns a note, or nil if there is no such note or it is in the trash.
+	Get(ctx context.Context, id int) (*Note, error)
+	// Update replaces a note's content and records it as a new revision.
+	// Saving unchanged content is a no-op.
+	Update(ctx context.Context, id int, content string) error
+	// Delete moves a note to the trash.
+	Delete(ctx context.Context, id int) error
+	// Restore takes a note back out of the trash.
+	Restore(ctx context.Context, id int) error
+	// List returns the notes matching filter, newest first by default.
+	List(ctx context.Context, filter NoteFilter) ([]Note, error)
+	// Search returns the notes matching a full-text query, most relevant
+	// first by default. An empty query matches every note.
+	Search(ctx context.Context, query string, filter NoteFilter) ([]SearchResult, error)
+	// Revisions returns every revision of a note, oldest first.
+	Revisions(ctx context.Context, id int) ([]Revision, error)
+	// Revert restores the content of an earlier revision as a new revision.
+	Revert(ctx context.Context, id, rev int) error
+
+	// SetDue sets or, with nil, clears a note's due date. A new due date
+	// gets a new reminder.
+	SetDue(ctx context.Context, id int, due *time.Time) error
+	// Reminders returns the notes due at or before now whose reminder
+	// hasn't gone out yet, earliest first.
+	Reminders(ctx context.Context, now time.Time) ([]Note, error)
+	// MarkNotified records that the reminder for a note went out at a time.
+	MarkNotified(ctx context.Context, id int, at time.Time) error
+	// SetStatus sets a note's task status, or makes it a plain note again
+	// with "". Completing a recurring task creates its next occurrence,
+	// whose ID is returned.
+	SetStatus(ctx context.Context, id int, status string) (int, error)
+	// SetRecurrence makes a note repeat by an RRULE from the recur package,
+	// or stops it repeating with "".
+	SetRecurrence(ctx context.Context, id int, rule string) error
+	// JournalEntry returns the journal entry for a day given as YYYY-MM-DD,
+	// or nil if there isn't one yet.
+	JournalEntry(ctx context.Context, date string) (*Note, error)
+	// Links returns the [[links]] in a note, in the order they appear.
+	Links(ctx context.Context, id int) ([]Link, error)
+	// Backlinks returns the notes that link to a note.
+	Backlinks(ctx context.Context, id int) ([]Note, error)
+	// SetTitle gives a note a title, or goes back to its first line with "",
+	// and makes it a new slug to match.
+	SetTitle(ctx context.Context, id int, title string) error
+	// SetPriority changes a note's priority.
+	SetPriority(ctx context.Context, id int, priority string) error
+
+	// Tags returns every tag with the number of notes carrying it, in name
+	// order so children sort directly below their parents.
+	Tags(ctx context.Context) ([]TagCount, error)
+	// SetTags replaces all of a note's tags.
+	SetTags(ctx context.Context, id int, tags []string) error
+	// MergeTags folds every source tag, and its children, into target.
+	// Renaming a tag is merging it alone into its new name.
+	MergeTags(ctx context.Context, sources []string, target string) error
+	// DeleteTag removes a tag and its children, moving the notes carrying it
+	// to the trash too with deleteNotes. It returns how many notes it affected.
+	DeleteTag(ctx context.Context, name string, deleteNotes bool) (int, error)
+
+	// Trash returns the notes in the trash, most recently deleted first.
+	Trash(ctx context.Context) ([]Note, error)
+	// PurgeTrash permanently deletes the notes put in the trash before a
+	// time, or all of them for the zero time, and returns how many it removed.
+	PurgeTrash(ctx context.Context, before time.Time) (int, error)
+
+	// Import adds many notes at once, skipping empty ones and any whose
+	// content is already saved. With dryRun nothing is saved.
+	Import(ctx context.Context, notes []NewNote, dryRun bool) (ImportResult, error)
+
+	// VaultEntries returns the sync state for an Obsidian vault folder, keyed
+	// by note ID.
+	VaultEntries(ctx context.Context, vault string) (map[int]VaultEntry, error)
+	// SaveVaultEntry stores the sync state of a note.
+	SaveVaultEntry(ctx context.Context, vault string, e VaultEntry) error
+	// DeleteVaultEntry forgets a note's sync state.
+	DeleteVaultEntry(ctx context.Context, vault string, id int) error
+
+	// SavedSearches returns every saved search, ordered by name.
+	SavedSearches(ctx context.Context) ([]SavedSearch, error)
+	// SavedSearch returns the saved search with the given name, or nil.
+	SavedSearch(ctx context.Context, name string) (*SavedSearch, error)
+	// SaveSearch saves a search, replacing the query of one with the same name.
+	SaveSearch(ctx context.Context, s SavedSearch) error
+	// DeleteSavedSearch removes a saved search.
+	DeleteSavedSearch(ctx context.Context, name string) error
+}
+
+// SQLiteStore is the NoteStore for a jot database opened with OpenDB and
+// brought up to date with Migrate.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore returns a store that reads and writes notes in db.
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: db}
+}
+
+// defaultStore is the store behind the package-level note functions.
+func defaultStore() *SQLiteStore {
+	return NewSQLiteStore(DB)
+}
+
+func (s *SQLiteStore) Add(ctx context.Context, n NewNote) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("could not save note: %v", err)
+	}
+	defer tx.Rollback()
+
+	id, err := insertNote(tx, n)
+	if err != nil {
+		return 0, fmt.Errorf("could not save note: %v", err)
+	}
+
+	return id, tx.Commit()
+}
+
+func (s *SQLiteStore) Get(ctx context.Context, id int) (*Note, error) {
+	query := `SELECT ` + noteColumns + ` FROM notes n WHERE n.id = ? AND n.deleted_at IS NULL`
+	row := s.db.QueryRowContext(ctx, query, id)
+
+	n, err := scanNote(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (s *SQLiteStore) Update(ctx context.Context, id int, content string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRow(`SELECT content FROM notes WHERE id = ? AND deleted_at IS NULL`, id).Scan(&current)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("could not update note %d: %w", id, ErrNoteNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	if current == content {
+		return nil
+	}
+
+	now := time.Now()
+	query := `UPDATE notes SET content = ?, updated_at = ? WHERE id = ?`
+	if _, err := tx.Exec(query, content, now, id); err != nil {
+		return err
+	}
+	if err := addRevision(tx, id, content, now); err != nil {
+		return err
+	}
+	if err := addNoteTags(tx, id, ExtractHashtags(content)); err != nil {
+		return err
+	}
+	if err := setLinks(tx, id, content); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) Delete(ctx context.Context, id int) error {
+	query := `UPDATE notes SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`
+	res, err := s.db.ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("could not delete note: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("could not delete note %d: %w", id, ErrNoteNotFound)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Restore(ctx context.Context, id int) error {
+	query := `UPDATE notes SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("could not restore note: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("note %d is not in the trash: %w", id, ErrNoteNotFound)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) List(ctx context.Context, filter NoteFilter) ([]Note, error) {
+	where, args := filter.where()
+	order, err := orderBy(filter.Sort, filter.Reverse, SortCreated)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + noteColumns + ` FROM notes n WHERE ` + where + order
+	return queryNotes(ctx, s.db, query, args...)
+}
+
+// Search runs an FTS5 query against note content and orders the matches by
+// BM25 relevance, unless filter asks for another order. The query supports
+// the full FTS5 syntax: "exact phrases", prefix*, NEAR(a b), AND, OR and
+// NOT. If the query isn't valid FTS5 syntax it is retried with every term
+// quoted, so stray punctuation never turns into an error for the user.
+func (s *SQLiteStore) Search(ctx context.Context, query string, filter NoteFilter) ([]SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		if filter.Sort == SortRelevance {
+			filter.Sort = ""
+		}
+		notes, err := s.List(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		return notesAsResults(notes), nil
+	}
+
+	results, err := s.searchFTS(ctx, query, filter)
+	if err != nil && isFTSSyntaxError(err) {
+		return s.searchFTS(ctx, quoteTerms(query), filter)
+	}
+	return results, err
+}
+
+func (s *SQLiteStore) searchFTS(ctx context.Context, query string, filter NoteFilter) ([]SearchResult, error) {
+	where, args := filter.where()
+	order, err := orderBy(filter.Sort, filter.Reverse, SortRelevance)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlQuery := `SELECT ` + noteColumns + `,
+			snippet(notes_fts, 0, ?, ?, '…', 12), bm25(notes_fts)
+		FROM notes_fts
+		JOIN notes n ON n.id = notes_fts.rowid
+		WHERE notes_fts MATCH ? AND ` + where + order
+	args = append([]any{HighlightStart, HighlightEnd, query}, args...)
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var r SearchResult
+		r.Note, err = scanNote(rows, &r.Snippet, &r.Rank)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+func (s *SQLiteStore) Revisions(ctx context.Context, id int) ([]Revision, error) {
+	query := `SELECT note_id, rev, content, created_at FROM note_revisions WHERE note_id = ? ORDER BY rev`
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []Revision
+	for rows.Next() {
+		var r Revision
+		if err := rows.Scan(&r.NoteID, &r.Rev, &r.Content, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
+
+func (s *SQLiteStore) Revert(ctx context.Context, id, rev int) error {
+	var content string
+	query := `SELECT content FROM note_revisions WHERE note_id = ? AND rev = ?`
+	err := s.db.QueryRowContext(ctx, query, id, rev).Scan(&content)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("note %d has no revision %d", id, rev)
+	}
+	if err != nil {
+		return err
+	}
+	return s.Update(ctx, id, content)
+}
@@ -0,0 +1,380 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrTagNotFound is returned when a tag operation names a tag that doesn't exist.
+var ErrTagNotFound = errors.New("tag not found")
+
+// TagCount is a tag along with how many notes carry it directly.
+type TagCount struct {
+	Name  string
+	Count int
+}
+
+// hashtagPattern matches inline tags like #work or #project/alpha. The # must
+// not follow a word character, slash or another # so that URL fragments,
+// "C#" and Markdown headings are left alone.
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_/#&])#([\p{L}\p{N}_][\p{L}\p{N}_/-]*)`)
+
+// NormalizeTag converts user input into the canonical stored form: no
+// leading #, lower case, and no spaces or commas.
+func NormalizeTag(tag string) string {
+	tag = strings.TrimSpace(tag)
+	tag = strings.TrimLeft(tag, "#")
+	tag = strings.ToLower(tag)
+	tag = strings.Join(strings.Fields(tag), "-")
+	tag = strings.ReplaceAll(tag, ",", "")
+	return strings.Trim(tag, "/")
+}
+
+// ParseTags normalizes a list of tags, splitting comma-separated values and
+// dropping empties and duplicates while keeping the original order.
+func ParseTags(values []string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			tag := NormalizeTag(part)
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ExtractHashtags returns the normalized #hashtags found in content. Purely
+// numeric tags such as #42 are ignored, as they are usually issue references.
+func ExtractHashtags(content string) []string {
+	var found []string
+	for _, m := range hashtagPattern.FindAllStringSubmatch(content, -1) {
+		tag := strings.TrimRight(m[1], "/-")
+		if strings.IndexFunc(tag, unicode.IsLetter) == -1 {
+			continue
+		}
+		found = append(found, tag)
+	}
+	return ParseTags(found)
+}
+
+// addNoteTags attaches tags to a note, creating any that don't exist yet.
+// Tags the note already has are left untouched.
+func addNoteTags(tx *sql.Tx, noteID int, tags []string) error {
+	for _, tag := range ParseTags(tags) {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO tags (name) VALUES (?)`, tag); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`INSERT OR IGNORE INTO note_tags (note_id, tag_id)
+			SELECT ?, id FROM tags WHERE name = ?`, noteID, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetNoteTags replaces all of a note's tags.
+func SetNoteTags(noteID int, tags []string) error {
+	return defaultStore().SetTags(context.Background(), noteID, tags)
+}
+
+func (s *SQLiteStore) SetTags(ctx context.Context, id int, tags []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM note_tags WHERE note_id = ?`, id); err != nil {
+		return err
+	}
+	if err := addNoteTags(tx, id, tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// tagCondition builds a WHERE clause on notes aliased as n that matches any
+// (or, with matchAll, every) one of the given tags. Tags are hierarchical, so
+// filtering on "work" also matches "work/clienta".
+func tagCondition(tags []string, matchAll bool) (string, []any) {
+	var conds []string
+	var args []any
+	for _, tag := range tags {
+		conds = append(conds, `EXISTS (SELECT 1 FROM note_tags nt JOIN tags t ON t.id = nt.tag_id
+			WHERE nt.note_id = n.id AND (t.name = ? OR t.name LIKE ? ESCAPE '\'))`)
+		args = append(args, tag, childPattern(tag))
+	}
+
+	joiner := " OR "
+	if matchAll {
+		joiner = " AND "
+	}
+	return "(" + strings.Join(conds, joiner) + ")", args
+}
+
+// migrateTagColumn moves the legacy single-value notes.tag column into the
+// tags and note_tags tables, then drops it.
+func migrateTagColumn(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, COALESCE(tag, '') FROM notes WHERE COALESCE(tag, '') != ''`)
+	if err != nil {
+		return err
+	}
+
+	legacy := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			rows.Close()
+			return err
+		}
+		legacy[id] = tag
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, tag := range legacy {
+		if err := addNoteTags(tx, id, []string{tag}); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`ALTER TABLE notes DROP COLUMN tag`)
+	return err
+}
+
+// childPattern is a LIKE pattern matching every descendant of tag.
+func childPattern(tag string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(tag)
+	return escaped + "/%"
+}
+
+// ListTags returns every tag in name order, so children sort directly
+// below their parents.
+func ListTags() ([]TagCount, error) {
+	return defaultStore().Tags(context.Background())
+}
+
+func (s *SQLiteStore) Tags(ctx context.Context) ([]TagCount, error) {
+	query := `SELECT t.name, COUNT(n.id) FROM tags t
+		LEFT JOIN note_tags nt ON nt.tag_id = t.id
+		LEFT JOIN notes n ON n.id = nt.note_id AND n.deleted_at IS NULL
+		GROUP BY t.id ORDER BY t.name`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []TagCount
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Name, &tc.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tc)
+	}
+	return tags, rows.Err()
+}
+
+// RenameTag renames a tag along with all of its children. If the new name
+// is already in use, the two tags are merged.
+func RenameTag(oldName, newName string) error {
+	return MergeTags([]string{oldName}, newName)
+}
+
+// MergeTags folds every source tag, and its children, into target. A child
+// such as "a/x" becomes "target/x". Notes end up tagged once even if they
+// carried several of the merged tags.
+func MergeTags(sources []string, target string) error {
+	return defaultStore().MergeTags(context.Background(), sources, target)
+}
+
+func (s *SQLiteStore) MergeTags(ctx context.Context, sources []string, target string) error {
+	target = NormalizeTag(target)
+	if target == "" {
+		return fmt.Errorf("invalid tag name")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, source := range topTags(ParseTags(sources)) {
+		if source == target {
+			continue
+		}
+		if strings.HasPrefix(target, source+"/") {
+			return fmt.Errorf("cannot move %q into its own child %q", source, target)
+		}
+
+		renames, err := tagFamily(tx, source)
+		if err != nil {
+			return err
+		}
+		if len(renames) == 0 {
+			return fmt.Errorf("%w: %s", ErrTagNotFound, source)
+		}
+
+		for id, name := range renames {
+			newName := target + strings.TrimPrefix(name, source)
+			if err := moveTag(tx, id, newName); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// topTags drops the tags that are children of another one in tags, since
+// merging the parent moves them along already.
+func topTags(tags []string) []string {
+	var top []string
+	for _, tag := range tags {
+		if !slices.ContainsFunc(tags, func(parent string) bool { return strings.HasPrefix(tag, parent+"/") }) {
+			top = append(top, tag)
+		}
+	}
+	return top
+}
+
+// tagFamily returns the IDs and names of a tag and all of its children.
+func tagFamily(tx *sql.Tx, tag string) (map[int]string, error) {
+	rows, err := tx.Query(`SELECT id, name FROM tags WHERE name = ? OR name LIKE ? ESCAPE '\'`, tag, childPattern(tag))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	family := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		family[id] = name
+	}
+	return family, rows.Err()
+}
+
+// moveTag gives the tag with the given ID a new name, merging it into an
+// existing tag of that name if there is one.
+func moveTag(tx *sql.Tx, id int, name string) error {
+	var existing int
+	err := tx.QueryRow(`SELECT id FROM tags WHERE name = ?`, name).Scan(&existing)
+	if err == sql.ErrNoRows {
+		_, err = tx.Exec(`UPDATE tags SET name = ? WHERE id = ?`, name, id)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO note_tags (note_id, tag_id)
+		SELECT note_id, ? FROM note_tags WHERE tag_id = ?`, existing, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM note_tags WHERE tag_id = ?`, id); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM tags WHERE id = ?`, id)
+	return err
+}
+
+// DeleteTag removes a tag and its children. By default notes are only
+// untagged; with deleteNotes the notes carrying the tag are also moved to
+// the trash.
+// It returns the number of notes affected.
+func DeleteTag(name string, deleteNotes bool) (int, error) {
+	return defaultStore().DeleteTag(context.Background(), name, deleteNotes)
+}
+
+func (s *SQLiteStore) DeleteTag(ctx context.Context, name string, deleteNotes bool) (int, error) {
+	name = NormalizeTag(name)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	family, err := tagFamily(tx, name)
+	if err != nil {
+		return 0, err
+	}
+	if len(family) == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrTagNotFound, name)
+	}
+
+	ids := make([]any, 0, len(family))
+	for id := range family {
+		ids = append(ids, id)
+	}
+
+	inTags := placeholders(len(ids))
+	noteIDs, err := queryIDs(tx, `SELECT DISTINCT nt.note_id FROM note_tags nt
+		JOIN notes n ON n.id = nt.note_id AND n.deleted_at IS NULL
+		WHERE nt.tag_id IN `+inTags, ids...)
+	if err != nil {
+		return 0, err
+	}
+
+	if deleteNotes && len(noteIDs) > 0 {
+		query := `UPDATE notes SET deleted_at = ? WHERE id IN ` + placeholders(len(noteIDs))
+		if _, err := tx.Exec(query, append([]any{time.Now()}, noteIDs...)...); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM note_tags WHERE tag_id IN `+inTags, ids...); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`DELETE FROM tags WHERE id IN `+inTags, ids...); err != nil {
+		return 0, err
+	}
+
+	return len(noteIDs), tx.Commit()
+}
+
+// placeholders returns "(?, ?, ...)" with n parameters for an IN clause.
+func placeholders(n int) string {
+	return "(" + strings.TrimSuffix(strings.Repeat("?, ", n), ", ") + ")"
+}
+
+// queryIDs runs a query returning a single integer column.
+func queryIDs(tx *sql.Tx, query string, args ...any) ([]any, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []any
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
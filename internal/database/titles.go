@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/markdown"
+)
+
+// maxSlugLen keeps slugs short enough to type.
+const maxSlugLen = 50
+
+// DisplayTitle is a note's title, or its first line if it wasn't given one.
+func (n Note) DisplayTitle() string {
+	return displayTitle(n.Title, n.Content)
+}
+
+func displayTitle(title, content string) string {
+	if title != "" {
+		return title
+	}
+	return markdown.FirstLine(content)
+}
+
+// baseSlug turns a title into a slug before it is made unique. Slugs never
+// look like IDs, so a reference can't be both.
+func baseSlug(title string) string {
+	slug := markdown.Slug(title)
+	if r := []rune(slug); len(r) > maxSlugLen {
+		slug = string(r[:maxSlugLen])
+		// Cut at a word boundary unless that loses too much
+		if i := strings.LastIndex(slug, "-"); i > len(slug)/2 {
+			slug = slug[:i]
+		}
+	}
+	slug = strings.Trim(slug, "-")
+	if slug == "" {
+		return "note"
+	}
+	if _, err := strconv.Atoi(slug); err == nil {
+		return "note-" + slug
+	}
+	return slug
+}
+
+// uniqueSlug makes a slug for title, adding -2, -3 and so on while taken
+// reports it is in use.
+func uniqueSlug(title string, taken func(slug string) (bool, error)) (string, error) {
+	base := baseSlug(title)
+	slug := base
+	for i := 2; ; i++ {
+		used, err := taken(slug)
+		if err != nil {
+			return "", err
+		}
+		if !used {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// slugTaken reports whether a note other than id has a slug. Notes in the
+// trash keep theirs, so restoring one never clashes.
+func slugTaken(tx *sql.Tx, id int) func(string) (bool, error) {
+	return func(slug string) (bool, error) {
+		var n int
+		err := tx.QueryRow(`SELECT count(*) FROM notes WHERE slug = ? AND id != ?`, slug, id).Scan(&n)
+		return n > 0, err
+	}
+}
+
+func (s *SQLiteStore) SetTitle(ctx context.Context, id int, title string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not set title: %v", err)
+	}
+	defer tx.Rollback()
+
+	var content string
+	err = tx.QueryRow(`SELECT content FROM notes WHERE id = ? AND deleted_at IS NULL`, id).Scan(&content)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("could not set title of note %d: %w", id, ErrNoteNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("could not set title: %v", err)
+	}
+
+	title = strings.TrimSpace(title)
+	slug, err := uniqueSlug(displayTitle(title, content), slugTaken(tx, id))
+	if err != nil {
+		return fmt.Errorf("could not set title: %v", err)
+	}
+	query := `UPDATE notes SET title = ?, slug = ?, updated_at = ? WHERE id = ?`
+	if _, err := tx.Exec(query, title, slug, time.Now(), id); err != nil {
+		return fmt.Errorf("could not set title: %v", err)
+	}
+	if err := mendLinks(tx, id); err != nil {
+		return fmt.Errorf("could not set title: %v", err)
+	}
+	return tx.Commit()
+}
+
+// migrateSlugs gives the notes saved before slugs existed one each, oldest
+// first, then resolves the links that can be now.
+func migrateSlugs(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, content FROM notes ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	type titled struct {
+		id    int
+		title string
+	}
+	var notes []titled
+	for rows.Next() {
+		var id int
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		notes = append(notes, titled{id, markdown.FirstLine(content)})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, n := range notes {
+		slug, err := uniqueSlug(n.title, slugTaken(tx, n.id))
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE notes SET slug = ? WHERE id = ?`, slug, n.id); err != nil {
+			return err
+		}
+	}
+	return resolveBrokenLinks(tx)
+}
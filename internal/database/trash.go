@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// GetTrashedNotes returns the notes in the trash, most recently deleted first.
+func GetTrashedNotes() ([]Note, error) {
+	return defaultStore().Trash(context.Background())
+}
+
+func (s *SQLiteStore) Trash(ctx context.Context) ([]Note, error) {
+	query := `SELECT ` + noteColumns + ` FROM notes n WHERE n.deleted_at IS NOT NULL ORDER BY n.deleted_at DESC`
+	return queryNotes(ctx, s.db, query)
+}
+
+// RestoreNote takes a note back out of the trash.
+func RestoreNote(id int) error {
+	return defaultStore().Restore(context.Background(), id)
+}
+
+// EmptyTrash permanently deletes every note in the trash and returns how
+// many were removed.
+func EmptyTrash() (int, error) {
+	return defaultStore().PurgeTrash(context.Background(), time.Time{})
+}
+
+// PurgeTrash permanently deletes notes that have been in the trash for
+// longer than retention. A retention of zero or less keeps them forever.
+func PurgeTrash(retention time.Duration) (int, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+	return defaultStore().PurgeTrash(context.Background(), time.Now().Add(-retention))
+}
+
+func (s *SQLiteStore) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	if before.IsZero() {
+		return s.purgeNotes(ctx, `deleted_at IS NOT NULL`)
+	}
+	return s.purgeNotes(ctx, `deleted_at IS NOT NULL AND deleted_at < ?`, before)
+}
+
+// purgeNotes hard-deletes the notes matching where, along with everything
+// that hangs off them.
+func (s *SQLiteStore) purgeNotes(ctx context.Context, where string, args ...any) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	ids := `SELECT id FROM notes WHERE ` + where
+	for _, table := range []string{"note_tags", "note_revisions"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE note_id IN (`+ids+`)`, args...); err != nil {
+			return 0, fmt.Errorf("could not purge trash: %v", err)
+		}
+	}
+	res, err := tx.Exec(`DELETE FROM notes WHERE `+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("could not purge trash: %v", err)
+	}
+
+	n, _ := res.RowsAffected()
+	return int(n), tx.Commit()
+}
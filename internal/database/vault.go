@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// VaultEntry records the state of one note in an Obsidian vault as of the
+// last sync, so the next sync can tell which side changed.
+type VaultEntry struct {
+	NoteID   int
+	Path     string // relative to the vault folder, with forward slashes
+	NoteHash string
+	FileHash string
+	SyncedAt time.Time
+}
+
+func (s *SQLiteStore) VaultEntries(ctx context.Context, vault string) (map[int]VaultEntry, error) {
+	query := `SELECT note_id, path, note_hash, file_hash, synced_at FROM vault_sync WHERE vault = ?`
+	rows, err := s.db.QueryContext(ctx, query, vault)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make(map[int]VaultEntry)
+	for rows.Next() {
+		var e VaultEntry
+		if err := rows.Scan(&e.NoteID, &e.Path, &e.NoteHash, &e.FileHash, &e.SyncedAt); err != nil {
+			return nil, err
+		}
+		entries[e.NoteID] = e
+	}
+	return entries, rows.Err()
+}
+
+func (s *SQLiteStore) SaveVaultEntry(ctx context.Context, vault string, e VaultEntry) error {
+	query := `INSERT INTO vault_sync (vault, note_id, path, note_hash, file_hash, synced_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (vault, note_id) DO UPDATE SET
+			path = excluded.path, note_hash = excluded.note_hash,
+			file_hash = excluded.file_hash, synced_at = excluded.synced_at`
+	_, err := s.db.ExecContext(ctx, query, vault, e.NoteID, e.Path, e.NoteHash, e.FileHash, time.Now())
+	return err
+}
+
+func (s *SQLiteStore) DeleteVaultEntry(ctx context.Context, vault string, noteID int) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM vault_sync WHERE vault = ? AND note_id = ?`, vault, noteID)
+	return err
+}
@@ -0,0 +1,377 @@
+// Package dates parses the dates accepted by --since, --until, --on, --due
+// and the date fields of queries. Besides ISO dates it understands everyday
+// phrases such as "yesterday", "3d", "last week", "monday" and "friday 5pm".
+package dates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Range is the half-open span of time [Start, End) that a date covers, e.g.
+// a whole day for "yesterday". For an exact time, Start and End are equal.
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Help lists the accepted formats, for error messages and command help.
+const Help = `YYYY-MM-DD, YYYY-MM, YYYY, today, yesterday, 3d, 2w, "3 days ago", monday, "last week" or "this month"`
+
+// Parse parses a date relative to now, in now's time zone.
+func Parse(value string, now time.Time) (Range, error) {
+	s := strings.Join(strings.Fields(strings.ToLower(value)), " ")
+	if s == "" {
+		return Range{}, fmt.Errorf("missing date (use %s)", Help)
+	}
+	today := StartOfDay(now)
+
+	if r, ok := parseISO(strings.Join(strings.Fields(value), " "), now.Location()); ok {
+		return r, nil
+	}
+
+	switch s {
+	case "now":
+		return Range{now, now}, nil
+	case "today":
+		return day(today), nil
+	case "yesterday":
+		return day(today.AddDate(0, 0, -1)), nil
+	case "tomorrow":
+		return day(today.AddDate(0, 0, 1)), nil
+	}
+
+	if weekday, ok := parseWeekday(s); ok {
+		return day(lastWeekday(today, weekday, false)), nil
+	}
+
+	if rest, ok := strings.CutPrefix(s, "last "); ok {
+		if weekday, ok := parseWeekday(rest); ok {
+			return day(lastWeekday(today, weekday, true)), nil
+		}
+		if r, ok := period(rest, today, -1); ok {
+			return r, nil
+		}
+	}
+	if rest, ok := strings.CutPrefix(s, "this "); ok {
+		if r, ok := period(rest, today, 0); ok {
+			return r, nil
+		}
+	}
+	if rest, ok := strings.CutPrefix(s, "next "); ok {
+		if r, ok := period(rest, today, 1); ok {
+			return r, nil
+		}
+	}
+
+	if r, ok := parseAgo(s, now); ok {
+		return r, nil
+	}
+	return Range{}, fmt.Errorf("invalid date %q (use %s)", value, Help)
+}
+
+// StartOfDay returns midnight at the start of t's day.
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func day(t time.Time) Range {
+	return Range{t, t.AddDate(0, 0, 1)}
+}
+
+// parseISO parses dates and times in the formats jotcli prints, plus months
+// and years.
+func parseISO(s string, loc *time.Location) (Range, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return Range{t, t}, true
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return day(t), true
+	}
+	if t, err := time.ParseInLocation("2006-01", s, loc); err == nil {
+		return Range{t, t.AddDate(0, 1, 0)}, true
+	}
+	if t, err := time.ParseInLocation("2006", s, loc); err == nil {
+		return Range{t, t.AddDate(1, 0, 0)}, true
+	}
+	return Range{}, false
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	w, ok := weekdays[s]
+	return w, ok
+}
+
+// lastWeekday returns the most recent given weekday on or before today, or
+// strictly before it if before is set.
+func lastWeekday(today time.Time, weekday time.Weekday, before bool) time.Time {
+	back := (int(today.Weekday()) - int(weekday) + 7) % 7
+	if back == 0 && before {
+		back = 7
+	}
+	return today.AddDate(0, 0, -back)
+}
+
+// startOfWeek returns the Monday starting the week t falls in.
+func startOfWeek(t time.Time) time.Time {
+	d := StartOfDay(t)
+	return d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7))
+}
+
+// period returns the week, month or year offset periods away from today's.
+func period(unit string, today time.Time, offset int) (Range, bool) {
+	switch unit {
+	case "day":
+		return day(today.AddDate(0, 0, offset)), true
+	case "week":
+		start := startOfWeek(today).AddDate(0, 0, 7*offset)
+		return Range{start, start.AddDate(0, 0, 7)}, true
+	case "month":
+		start := time.Date(today.Year(), today.Month()+time.Month(offset), 1, 0, 0, 0, 0, today.Location())
+		return Range{start, start.AddDate(0, 1, 0)}, true
+	case "year":
+		start := time.Date(today.Year()+offset, time.January, 1, 0, 0, 0, 0, today.Location())
+		return Range{start, start.AddDate(1, 0, 0)}, true
+	}
+	return Range{}, false
+}
+
+// parseAgo parses amounts of time in the past, either short like "3d" or
+// spelled out like "3 days ago". Hours and minutes are exact times; longer
+// units mean the whole day that far back.
+func parseAgo(s string, now time.Time) (Range, bool) {
+	s = strings.TrimSuffix(s, " ago")
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i <= 0 {
+		return Range{}, false
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return Range{}, false
+	}
+	unit := strings.TrimSpace(s[i:])
+	today := StartOfDay(now)
+
+	switch unit {
+	case "m", "min", "mins", "minute", "minutes":
+		t := now.Add(-time.Duration(n) * time.Minute)
+		return Range{t, t}, true
+	case "h", "hr", "hrs", "hour", "hours":
+		t := now.Add(-time.Duration(n) * time.Hour)
+		return Range{t, t}, true
+	case "d", "day", "days":
+		return day(today.AddDate(0, 0, -n)), true
+	case "w", "wk", "wks", "week", "weeks":
+		return day(today.AddDate(0, 0, -7*n)), true
+	case "mo", "month", "months":
+		return day(today.AddDate(0, -n, 0)), true
+	case "y", "yr", "yrs", "year", "years":
+		return day(today.AddDate(-n, 0, 0)), true
+	}
+	return Range{}, false
+}
+
+// DefaultHour is the time of day given to a due date without one.
+const DefaultHour = 9
+
+// TimeHelp lists the formats ParseTime accepts.
+const TimeHelp = `"friday 5pm", "tomorrow 9:30", 17:00, "in 2h", "in 3 days", "next week" or 2026-03-20`
+
+// ParseTime parses a point in time relative to now, such as a due date.
+// Unlike Parse it looks ahead: "friday" is the coming Friday, and a time of
+// day that has already passed today means tomorrow. A day without a time of
+// day is at DefaultHour.
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	s := strings.Join(strings.Fields(strings.ToLower(value)), " ")
+	invalid := fmt.Errorf("invalid time %q (use %s)", value, TimeHelp)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("missing time (use %s)", TimeHelp)
+	}
+	if s == "now" {
+		return now, nil
+	}
+	if rest, ok := strings.CutPrefix(s, "in "); ok {
+		if t, ok := parseIn(rest, now); ok {
+			return t, nil
+		}
+		return time.Time{}, invalid
+	}
+	// Layouts are case-sensitive, as in 2026-03-08T09:00:00Z
+	if r, ok := parseISO(strings.Join(strings.Fields(value), " "), now.Location()); ok {
+		if r.Start.Equal(r.End) {
+			return r.Start, nil
+		}
+		return r.Start.Add(DefaultHour * time.Hour), nil
+	}
+
+	// An optional time of day at the end, e.g. "friday 5pm" or "friday at 17:00"
+	dayPart, clock := s, -1
+	if i := strings.LastIndexByte(s, ' '); i >= 0 {
+		if c, ok := parseClock(s[i+1:]); ok {
+			dayPart, clock = strings.TrimSuffix(s[:i], " at"), c
+		}
+	} else if c, ok := parseClock(s); ok {
+		dayPart, clock = "", c
+	}
+
+	today := StartOfDay(now)
+	var day time.Time
+	switch {
+	case dayPart == "" || dayPart == "today" || dayPart == "tonight":
+		day = today
+		if dayPart == "" && clock >= 0 && !atClock(today, clock).After(now) {
+			day = today.AddDate(0, 0, 1)
+		}
+		if dayPart == "tonight" && clock < 0 {
+			clock = 20 * 60
+		}
+	default:
+		d, ok := parseDay(dayPart, today)
+		if !ok {
+			return time.Time{}, invalid
+		}
+		day = d
+	}
+
+	if clock < 0 {
+		clock = DefaultHour * 60
+	}
+	t := atClock(day, clock)
+	if _, ok := parseWeekday(dayPart); ok && !t.After(now) {
+		t = t.AddDate(0, 0, 7) // today's weekday, but that time has gone by
+	}
+	return t, nil
+}
+
+// parseDay parses the day part of ParseTime, looking ahead from today.
+func parseDay(s string, today time.Time) (time.Time, bool) {
+	switch s {
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	case "next week":
+		return startOfWeek(today).AddDate(0, 0, 7), true
+	case "next month":
+		return time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, today.Location()), true
+	}
+	if weekday, ok := parseWeekday(s); ok {
+		return nextWeekday(today, weekday, false), true
+	}
+	if rest, ok := strings.CutPrefix(s, "next "); ok {
+		if weekday, ok := parseWeekday(rest); ok {
+			return nextWeekday(today, weekday, true), true
+		}
+	}
+	if r, ok := parseISO(s, today.Location()); ok && !r.Start.Equal(r.End) {
+		return r.Start, true
+	}
+	return time.Time{}, false
+}
+
+// nextWeekday returns the next given weekday on or after today, or strictly
+// after it if after is set.
+func nextWeekday(today time.Time, weekday time.Weekday, after bool) time.Time {
+	ahead := (int(weekday) - int(today.Weekday()) + 7) % 7
+	if ahead == 0 && after {
+		ahead = 7
+	}
+	return today.AddDate(0, 0, ahead)
+}
+
+// atClock returns the time on day that is clock minutes after midnight by
+// the clock, which isn't the same as adding them on days the clocks change.
+func atClock(day time.Time, clock int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), clock/60, clock%60, 0, 0, day.Location())
+}
+
+// parseClock parses a time of day like 5pm, 5:30pm, 17:00 or noon, returning
+// minutes after midnight.
+func parseClock(s string) (int, bool) {
+	switch s {
+	case "noon":
+		return 12 * 60, true
+	case "midnight":
+		return 0, true
+	}
+
+	suffix := ""
+	if rest, ok := strings.CutSuffix(s, "am"); ok {
+		s, suffix = rest, "am"
+	} else if rest, ok := strings.CutSuffix(s, "pm"); ok {
+		s, suffix = rest, "pm"
+	}
+	hours, minutes, found := strings.Cut(s, ":")
+	if !found && suffix == "" {
+		return 0, false
+	}
+	h, err := strconv.Atoi(hours)
+	if err != nil {
+		return 0, false
+	}
+	m := 0
+	if found {
+		if len(minutes) != 2 {
+			return 0, false
+		}
+		if m, err = strconv.Atoi(minutes); err != nil || m > 59 {
+			return 0, false
+		}
+	}
+
+	switch suffix {
+	case "":
+		if h > 23 {
+			return 0, false
+		}
+	default:
+		if h < 1 || h > 12 {
+			return 0, false
+		}
+		h %= 12
+		if suffix == "pm" {
+			h += 12
+		}
+	}
+	return h*60 + m, true
+}
+
+// parseIn parses an amount of time from now, like "2h" or "3 days".
+func parseIn(s string, now time.Time) (time.Time, bool) {
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i <= 0 {
+		return time.Time{}, false
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	switch strings.TrimSpace(s[i:]) {
+	case "m", "min", "mins", "minute", "minutes":
+		return now.Add(time.Duration(n) * time.Minute), true
+	case "h", "hr", "hrs", "hour", "hours":
+		return now.Add(time.Duration(n) * time.Hour), true
+	case "d", "day", "days":
+		return now.AddDate(0, 0, n), true
+	case "w", "wk", "wks", "week", "weeks":
+		return now.AddDate(0, 0, 7*n), true
+	case "mo", "month", "months":
+		return now.AddDate(0, n, 0), true
+	case "y", "yr", "yrs", "year", "years":
+		return now.AddDate(n, 0, 0), true
+	}
+	return time.Time{}, false
+}
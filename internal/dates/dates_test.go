@@ -0,0 +1,120 @@
+package dates
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// A Wednesday afternoon
+	now := time.Date(2026, time.March, 18, 15, 30, 0, 0, time.UTC)
+	at := func(s string) time.Time {
+		d, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		if err != nil {
+			t.Fatalf("bad test time %q: %v", s, err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		input      string
+		start, end string
+	}{
+		{"2026-03-01", "2026-03-01 00:00", "2026-03-02 00:00"},
+		{"2026-03-01T09:15", "2026-03-01 09:15", "2026-03-01 09:15"},
+		{"2026-02", "2026-02-01 00:00", "2026-03-01 00:00"},
+		{"2025", "2025-01-01 00:00", "2026-01-01 00:00"},
+		{"now", "2026-03-18 15:30", "2026-03-18 15:30"},
+		{"Today", "2026-03-18 00:00", "2026-03-19 00:00"},
+		{"yesterday", "2026-03-17 00:00", "2026-03-18 00:00"},
+		{"monday", "2026-03-16 00:00", "2026-03-17 00:00"},
+		{"wed", "2026-03-18 00:00", "2026-03-19 00:00"},
+		{"last wednesday", "2026-03-11 00:00", "2026-03-12 00:00"},
+		{"last  week", "2026-03-09 00:00", "2026-03-16 00:00"},
+		{"this week", "2026-03-16 00:00", "2026-03-23 00:00"},
+		{"last month", "2026-02-01 00:00", "2026-03-01 00:00"},
+		{"next year", "2027-01-01 00:00", "2028-01-01 00:00"},
+		{"3d", "2026-03-15 00:00", "2026-03-16 00:00"},
+		{"3 days ago", "2026-03-15 00:00", "2026-03-16 00:00"},
+		{"2w", "2026-03-04 00:00", "2026-03-05 00:00"},
+		{"1mo", "2026-02-18 00:00", "2026-02-19 00:00"},
+		{"2h", "2026-03-18 13:30", "2026-03-18 13:30"},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.input, now)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.input, err)
+			continue
+		}
+		if !r.Start.Equal(at(tt.start)) || !r.End.Equal(at(tt.end)) {
+			t.Errorf("Parse(%q) = [%v, %v), want [%s, %s)", tt.input, r.Start, r.End, tt.start, tt.end)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	now := time.Date(2026, time.March, 18, 15, 30, 0, 0, time.UTC)
+	for _, input := range []string{"", "someday", "3 fortnights", "last tuesday-ish", "2026-13-01", "d"} {
+		_, err := Parse(input, now)
+		if err == nil || !strings.Contains(err.Error(), "date") {
+			t.Errorf("Parse(%q) error = %v, want an invalid date error", input, err)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	// A Wednesday afternoon
+	now := time.Date(2026, time.March, 18, 15, 30, 0, 0, time.UTC)
+
+	tests := map[string]string{
+		"friday 5pm":         "2026-03-20 17:00",
+		"Friday at 5:30pm":   "2026-03-20 17:30",
+		"friday":             "2026-03-20 09:00",
+		"wednesday 10am":     "2026-03-25 10:00",
+		"wednesday 4pm":      "2026-03-18 16:00",
+		"next wednesday":     "2026-03-25 09:00",
+		"tomorrow 9:15":      "2026-03-19 09:15",
+		"17:00":              "2026-03-18 17:00",
+		"2pm":                "2026-03-19 14:00",
+		"today noon":         "2026-03-18 12:00",
+		"tonight":            "2026-03-18 20:00",
+		"in 2h":              "2026-03-18 17:30",
+		"in 3 days":          "2026-03-21 15:30",
+		"next week":          "2026-03-23 09:00",
+		"next month":         "2026-04-01 09:00",
+		"2026-04-02":         "2026-04-02 09:00",
+		"2026-04-02 18:45":   "2026-04-02 18:45",
+		"2026-04-02 at 12am": "2026-04-02 00:00",
+	}
+	for input, want := range tests {
+		got, err := ParseTime(input, now)
+		if err != nil {
+			t.Errorf("ParseTime(%q) error = %v", input, err)
+			continue
+		}
+		if s := got.Format("2006-01-02 15:04"); s != want {
+			t.Errorf("ParseTime(%q) = %s, want %s", input, s, want)
+		}
+	}
+
+	for _, input := range []string{"", "friday 25:00", "13pm", "in a while", "someday 5pm", "last week"} {
+		if _, err := ParseTime(input, now); err == nil {
+			t.Errorf("ParseTime(%q) should fail", input)
+		}
+	}
+
+	if got, err := ParseTime("2026-03-08T09:00:00Z", now); err != nil || !got.Equal(time.Date(2026, time.March, 8, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseTime(RFC3339) = %v, %v", got, err)
+	}
+
+	// Clocks go forward overnight; 9am is still 9am
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("No time zone data: %v", err)
+	}
+	saturday := time.Date(2026, time.March, 7, 15, 0, 0, 0, ny)
+	if got, _ := ParseTime("tomorrow 9am", saturday); !got.Equal(time.Date(2026, time.March, 8, 9, 0, 0, 0, ny)) {
+		t.Errorf("ParseTime(tomorrow 9am) across DST = %v, want 9:00", got)
+	}
+}
@@ -0,0 +1,159 @@
+// Package diff produces line-based unified diffs between two texts.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns a unified diff turning a into b, with the given number of
+// context lines around each change. It returns an empty string when the two
+// texts are identical.
+func Unified(a, b, fromName, toName string, context int) string {
+	ops := compute(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	for _, h := range hunks(ops, context) {
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		out.WriteString(h)
+	}
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// compute finds the shortest edit script via the longest common subsequence.
+// Notes are small enough that the quadratic table is not a concern.
+func compute(a, b []string) []op {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{opEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{opDelete, a[i]})
+			i++
+		default:
+			ops = append(ops, op{opInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, op{opDelete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{opInsert, b[j]})
+	}
+	return ops
+}
+
+// hunks groups the edit script into "@@" sections, merging changes whose
+// context would overlap.
+func hunks(ops []op, context int) []string {
+	var result []string
+
+	// Line numbers (0-based) in a and b at the start of each op
+	aLine := make([]int, len(ops)+1)
+	bLine := make([]int, len(ops)+1)
+	for k, o := range ops {
+		aLine[k+1], bLine[k+1] = aLine[k], bLine[k]
+		if o.kind != opInsert {
+			aLine[k+1]++
+		}
+		if o.kind != opDelete {
+			bLine[k+1]++
+		}
+	}
+
+	k := 0
+	for k < len(ops) {
+		if ops[k].kind == opEqual {
+			k++
+			continue
+		}
+
+		start := max(0, k-context)
+		end := k
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			// Run of equal lines: stop if it's long enough to split the hunk
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = run
+		}
+
+		var body strings.Builder
+		for _, o := range ops[start:end] {
+			switch o.kind {
+			case opEqual:
+				body.WriteString(" " + o.line + "\n")
+			case opDelete:
+				body.WriteString("-" + o.line + "\n")
+			case opInsert:
+				body.WriteString("+" + o.line + "\n")
+			}
+		}
+
+		aCount, bCount := aLine[end]-aLine[start], bLine[end]-bLine[start]
+		result = append(result, fmt.Sprintf("@@ -%s +%s @@\n%s",
+			hunkRange(aLine[start], aCount), hunkRange(bLine[start], bCount), body.String()))
+		k = end
+	}
+	return result
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
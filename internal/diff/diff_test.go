@@ -0,0 +1,44 @@
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "Identical",
+			a:    "one\ntwo\n",
+			b:    "one\ntwo\n",
+			want: "",
+		},
+		{
+			name: "Changed line with context",
+			a:    "one\ntwo\nthree\nfour\nfive\n",
+			b:    "one\ntwo\nTHREE\nfour\nfive\n",
+			want: "--- a\n+++ b\n@@ -2,3 +2,3 @@\n two\n-three\n+THREE\n four\n",
+		},
+		{
+			name: "Append to empty",
+			a:    "",
+			b:    "hello\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1 @@\n+hello\n",
+		},
+		{
+			name: "Distant changes get separate hunks",
+			a:    "a\nb\nc\nd\ne\nf\ng\nh\n",
+			b:    "A\nb\nc\nd\ne\nf\ng\nH\n",
+			want: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n-a\n+A\n b\n@@ -7,2 +7,2 @@\n g\n-h\n+H\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified(tt.a, tt.b, "a", "b", 1); got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// Package export writes notes out of the database as a folder of Markdown
+// files with YAML front matter.
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/markdown"
+)
+
+// DefaultFilenameTemplate names files after the note ID and its slug.
+const DefaultFilenameTemplate = "{{.ID}}-{{.Slug}}"
+
+// ManifestName is the file in the export directory that remembers what was
+// written, so re-running an export only touches notes that changed.
+const ManifestName = ".jot-export.json"
+
+// Options control a Markdown export.
+type Options struct {
+	Dir              string
+	FilenameTemplate string // text/template using the fields of FilenameData
+	Prune            bool   // remove previously exported files for notes no longer exported
+}
+
+// FilenameData is available to filename templates.
+type FilenameData struct {
+	ID       int
+	Date     string // creation date, YYYY-MM-DD
+	Slug     string // the note's slug
+	Title    string // the note's title, or its first line
+	Priority string
+}
+
+// Result summarizes what an export did.
+type Result struct {
+	Written   int
+	Unchanged int
+	Removed   int
+}
+
+type manifestEntry struct {
+	File string `json:"file"`
+}
+
+type manifest struct {
+	Notes map[string]manifestEntry `json:"notes"`
+}
+
+// RenderNote produces the Markdown document for a note.
+func RenderNote(n database.Note) ([]byte, error) {
+	return markdown.Render(markdown.FrontMatter{
+		ID:       n.ID,
+		Title:    n.DisplayTitle(),
+		Slug:     n.Slug,
+		Tags:     n.Tags,
+		Priority: n.Priority,
+		Created:  n.CreatedAt,
+		Updated:  n.UpdatedAt,
+	}, n.Content)
+}
+
+// Markdown exports notes into opts.Dir, one file per note. Files whose
+// content would not change are left alone, and files for notes that were
+// renamed by the template are moved.
+func Markdown(notes []database.Note, opts Options) (Result, error) {
+	var res Result
+
+	if opts.FilenameTemplate == "" {
+		opts.FilenameTemplate = DefaultFilenameTemplate
+	}
+	tmpl, err := template.New("filename").Option("missingkey=error").Parse(opts.FilenameTemplate)
+	if err != nil {
+		return res, fmt.Errorf("invalid filename template: %v", err)
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return res, err
+	}
+
+	prev, err := readManifest(opts.Dir)
+	if err != nil {
+		return res, err
+	}
+	next := manifest{Notes: make(map[string]manifestEntry)}
+	used := make(map[string]bool)
+
+	for _, n := range notes {
+		name, err := filename(tmpl, n)
+		if err != nil {
+			return res, err
+		}
+		if used[strings.ToLower(name)] {
+			name = strings.TrimSuffix(name, ".md") + "-" + strconv.Itoa(n.ID) + ".md"
+		}
+		used[strings.ToLower(name)] = true
+
+		doc, err := RenderNote(n)
+		if err != nil {
+			return res, err
+		}
+		key := strconv.Itoa(n.ID)
+		next.Notes[key] = manifestEntry{File: name}
+
+		// A note whose file name changed leaves its old file behind, unless
+		// another note has just been written there
+		if old, ok := prev.Notes[key]; ok && old.File != name && !used[strings.ToLower(old.File)] {
+			if err := removeExported(opts.Dir, old.File); err != nil {
+				return res, err
+			}
+			res.Removed++
+		}
+
+		path := filepath.Join(opts.Dir, name)
+		if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, doc) {
+			res.Unchanged++
+			continue
+		}
+		if err := os.WriteFile(path, doc, 0644); err != nil {
+			return res, err
+		}
+		res.Written++
+	}
+
+	for key, old := range prev.Notes {
+		if _, ok := next.Notes[key]; ok {
+			continue
+		}
+		if !opts.Prune {
+			// Keep tracking it so a later --prune can still clean it up
+			next.Notes[key] = old
+			continue
+		}
+		if used[strings.ToLower(old.File)] {
+			continue
+		}
+		if err := removeExported(opts.Dir, old.File); err != nil {
+			return res, err
+		}
+		res.Removed++
+	}
+
+	return res, writeManifest(opts.Dir, next)
+}
+
+var defaultFilename = template.Must(template.New("filename").Parse(DefaultFilenameTemplate))
+
+// Filename returns the file name DefaultFilenameTemplate gives a note.
+func Filename(n database.Note) string {
+	name, _ := filename(defaultFilename, n)
+	return name
+}
+
+func filename(tmpl *template.Template, n database.Note) (string, error) {
+	title := n.DisplayTitle()
+	slug := n.Slug
+	if slug == "" {
+		slug = markdown.Slug(title)
+	}
+	data := FilenameData{
+		ID:       n.ID,
+		Date:     n.CreatedAt.Format("2006-01-02"),
+		Slug:     slug,
+		Title:    title,
+		Priority: n.Priority,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid filename template: %v", err)
+	}
+
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', '\n', '\r', '\t':
+			return '-'
+		}
+		return r
+	}, buf.String())
+	name = strings.Trim(strings.TrimSuffix(name, ".md"), "-. ")
+	if name == "" {
+		name = strconv.Itoa(n.ID)
+	}
+	return name + ".md", nil
+}
+
+// removeExported deletes a file written by an earlier export, ignoring it if
+// the user already removed it.
+func removeExported(dir, name string) error {
+	err := os.Remove(filepath.Join(dir, name))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func readManifest(dir string) (manifest, error) {
+	m := manifest{Notes: make(map[string]manifestEntry)}
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("could not read %s: %v", ManifestName, err)
+	}
+	if m.Notes == nil {
+		m.Notes = make(map[string]manifestEntry)
+	}
+	return m, nil
+}
+
+func writeManifest(dir string, m manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ManifestName), append(data, '\n'), 0644)
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+)
+
+func TestMarkdownIsIncremental(t *testing.T) {
+	dir := t.TempDir()
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	notes := []database.Note{
+		{ID: 1, Content: "# Standup\nDaily sync", Tags: []string{"work"}, Priority: "low", CreatedAt: created, UpdatedAt: created},
+		{ID: 2, Content: "Groceries", Priority: "high", CreatedAt: created, UpdatedAt: created},
+	}
+	opts := Options{Dir: dir, FilenameTemplate: "{{.Date}}-{{.Slug}}"}
+
+	res, err := Markdown(notes, opts)
+	if err != nil {
+		t.Fatalf("Markdown() error = %v", err)
+	}
+	if res.Written != 2 {
+		t.Errorf("First export wrote %d files, want 2", res.Written)
+	}
+
+	doc, err := os.ReadFile(filepath.Join(dir, "2026-10-01-standup.md"))
+	if err != nil {
+		t.Fatalf("Expected file named from the template: %v", err)
+	}
+	if !strings.HasPrefix(string(doc), "---\nid: 1\ntitle: Standup\ntags:\n  - work\n") || !strings.HasSuffix(string(doc), "# Standup\nDaily sync\n") {
+		t.Errorf("Unexpected document:\n%s", doc)
+	}
+
+	// Nothing changed, nothing rewritten
+	res, _ = Markdown(notes, opts)
+	if res.Written != 0 || res.Unchanged != 2 {
+		t.Errorf("Second export = %+v, want 0 written and 2 unchanged", res)
+	}
+
+	// Editing the first line renames the file
+	notes[0].Content = "# Retro\nWhat went well"
+	res, _ = Markdown(notes, opts)
+	if res.Written != 1 || res.Removed != 1 {
+		t.Errorf("Export after rename = %+v, want 1 written and 1 removed", res)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2026-10-01-standup.md")); !os.IsNotExist(err) {
+		t.Errorf("Old file should be removed after rename")
+	}
+
+	// Dropped notes are only removed with Prune
+	res, _ = Markdown(notes[:1], opts)
+	if res.Removed != 0 {
+		t.Errorf("Export without prune removed %d files", res.Removed)
+	}
+	opts.Prune = true
+	res, _ = Markdown(notes[:1], opts)
+	if res.Removed != 1 {
+		t.Errorf("Export with prune removed %d files, want 1", res.Removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2026-10-01-groceries.md")); !os.IsNotExist(err) {
+		t.Errorf("Pruned file still exists")
+	}
+}
+
+func TestMarkdownUsesTitlesAndSlugs(t *testing.T) {
+	dir := t.TempDir()
+	notes := []database.Note{
+		{ID: 1, Content: "Budget, hiring", Title: "Board meeting", Slug: "board-meeting"},
+		{ID: 2, Content: "Alpha"},
+		{ID: 3, Content: "Beta"},
+	}
+	opts := Options{Dir: dir, FilenameTemplate: "{{.Slug}}"}
+	if _, err := Markdown(notes, opts); err != nil {
+		t.Fatalf("Markdown() error = %v", err)
+	}
+
+	doc, err := os.ReadFile(filepath.Join(dir, "board-meeting.md"))
+	if err != nil {
+		t.Fatalf("Expected a file named after the slug: %v", err)
+	}
+	if !strings.HasPrefix(string(doc), "---\nid: 1\ntitle: Board meeting\nslug: board-meeting\n") {
+		t.Errorf("Unexpected document:\n%s", doc)
+	}
+
+	// Two notes trading names keep both files
+	notes[1].Content, notes[2].Content = "Beta", "Alpha"
+	if _, err := Markdown(notes, opts); err != nil {
+		t.Fatalf("Markdown() error = %v", err)
+	}
+	for name, want := range map[string]string{"beta.md": "id: 2", "alpha.md": "id: 3"} {
+		doc, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || !strings.Contains(string(doc), want) {
+			t.Errorf("%s = %q, %v, want the note with %s", name, doc, err, want)
+		}
+	}
+}
+
+func TestFilenameCollisions(t *testing.T) {
+	dir := t.TempDir()
+	notes := []database.Note{
+		{ID: 1, Content: "Same title"},
+		{ID: 2, Content: "Same title"},
+		{ID: 3, Content: "???"},
+	}
+
+	if _, err := Markdown(notes, Options{Dir: dir, FilenameTemplate: "{{.Slug}}"}); err != nil {
+		t.Fatalf("Markdown() error = %v", err)
+	}
+	for _, name := range []string{"same-title.md", "same-title-2.md", "3.md"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s to exist: %v", name, err)
+		}
+	}
+
+	if _, err := Markdown(notes, Options{Dir: dir, FilenameTemplate: "{{.Nope}}"}); err == nil {
+		t.Errorf("Expected an error for an unknown template field")
+	}
+}
@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+)
+
+// CSV fields that can be mapped to columns.
+var csvFields = []string{"content", "tags", "priority", "created", "updated"}
+
+// DefaultCSVMapping matches the header written by jotcli's --output csv.
+var DefaultCSVMapping = map[string]string{
+	"content":  "content",
+	"tags":     "tags",
+	"priority": "priority",
+	"created":  "created_at",
+	"updated":  "updated_at",
+}
+
+// ParseCSVMapping parses "content=Body,tags=Labels" into a field to column
+// map, starting from DefaultCSVMapping.
+func ParseCSVMapping(spec []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(DefaultCSVMapping))
+	for k, v := range DefaultCSVMapping {
+		mapping[k] = v
+	}
+
+	for _, pair := range spec {
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !ok || column == "" {
+			return nil, fmt.Errorf("invalid mapping %q, expected field=column", pair)
+		}
+		known := false
+		for _, f := range csvFields {
+			known = known || f == field
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown field %q in mapping (use %s)", field, strings.Join(csvFields, ", "))
+		}
+		mapping[field] = strings.TrimSpace(column)
+	}
+	return mapping, nil
+}
+
+// ReadCSV reads a CSV file with a header row, using mapping to find the
+// column for each note field. Column names are matched case-insensitively.
+func ReadCSV(path string, mapping map[string]string) ([]database.NewNote, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	index := make(map[string]int)
+	for i, name := range records[0] {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	column := func(field string) int {
+		if i, ok := index[strings.ToLower(mapping[field])]; ok {
+			return i
+		}
+		return -1
+	}
+	if column("content") == -1 {
+		return nil, fmt.Errorf("%s: no %q column for the note content (map it with --map content=COLUMN)", path, mapping["content"])
+	}
+
+	var notes []database.NewNote
+	for line, record := range records[1:] {
+		get := func(field string) string {
+			if i := column(field); i >= 0 && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+
+		n := database.NewNote{
+			Content:  get("content"),
+			Tags:     splitList(get("tags")),
+			Priority: normalizePriority(get("priority")),
+		}
+		if n.CreatedAt, err = csvTime(get("created")); err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", path, line+2, err)
+		}
+		if n.UpdatedAt, err = csvTime(get("updated")); err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", path, line+2, err)
+		}
+		notes = append(notes, n)
+	}
+	return notes, nil
+}
+
+// csvTime parses an optional date column.
+func csvTime(value string) (time.Time, error) {
+	if strings.TrimSpace(value) == "" {
+		return time.Time{}, nil
+	}
+	return ParseTime(value)
+}
@@ -0,0 +1,119 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/markdown"
+)
+
+// enexNote is a <note> element of an Evernote export.
+type enexNote struct {
+	Title   string   `xml:"title"`
+	Content string   `xml:"content"`
+	Created string   `xml:"created"`
+	Updated string   `xml:"updated"`
+	Tags    []string `xml:"tag"`
+}
+
+// enexTime is the timestamp format used in ENEX files.
+const enexTime = "20060102T150405Z"
+
+// ReadENEX reads an Evernote .enex export, or every .enex file in a
+// directory (Evernote writes one per notebook). Note bodies are converted
+// from ENML to Markdown; attachments are not imported and are left as
+// placeholders.
+func ReadENEX(path string) ([]database.NewNote, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readENEXFile(path)
+	}
+
+	var notes []database.NewNote
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.ToLower(filepath.Ext(p)) != ".enex" {
+			return nil
+		}
+		file, err := readENEXFile(p)
+		notes = append(notes, file...)
+		return err
+	})
+	return notes, err
+}
+
+func readENEXFile(path string) ([]database.NewNote, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var notes []database.NewNote
+	dec := xml.NewDecoder(f)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+
+		var en enexNote
+		if err := dec.DecodeElement(&en, &start); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		n, err := convertENEXNote(en)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q: %v", path, en.Title, err)
+		}
+		notes = append(notes, n)
+	}
+	return notes, nil
+}
+
+func convertENEXNote(en enexNote) (database.NewNote, error) {
+	body, err := enmlToMarkdown(en.Content)
+	if err != nil {
+		return database.NewNote{}, err
+	}
+
+	n := database.NewNote{
+		Content: withTitle(en.Title, body),
+		Tags:    en.Tags,
+	}
+	if t, err := time.Parse(enexTime, strings.TrimSpace(en.Created)); err == nil {
+		n.CreatedAt = t.Local()
+	}
+	if t, err := time.Parse(enexTime, strings.TrimSpace(en.Updated)); err == nil {
+		n.UpdatedAt = t.Local()
+	}
+	return n, nil
+}
+
+// withTitle puts a note's separate title at the top of its body as a
+// heading, unless the body already starts with it.
+func withTitle(title, body string) string {
+	title = strings.TrimSpace(title)
+	if title == "" || markdown.FirstLine(body) == title {
+		return body
+	}
+	return strings.TrimSpace("# " + title + "\n\n" + body)
+}
@@ -0,0 +1,399 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// enmlWriter turns ENML, Evernote's XHTML dialect, into Markdown. It only
+// has to cope with what the Evernote editors produce: div-per-line
+// paragraphs, headings, lists (including checklists), quotes, code blocks,
+// links, simple tables and inline emphasis.
+type enmlWriter struct {
+	buf       []byte
+	lists     []enmlList
+	quote     int
+	pre       int
+	space     bool   // a space is pending before the next text
+	opened    bool   // an inline marker was just written, so drop leading spaces
+	markerEnd int    // end of the current list or checkbox marker
+	divs      []bool // open divs, true for those that are code blocks
+	links     []enmlLink
+	skip      int // depth inside elements whose text is dropped
+	table     enmlTable
+}
+
+type enmlList struct {
+	ordered bool
+	todo    bool
+	n       int
+}
+
+type enmlLink struct {
+	href  string
+	start int
+}
+
+type enmlTable struct {
+	rows  int
+	cells int
+}
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// enmlToMarkdown converts the contents of an ENEX <content> element.
+func enmlToMarkdown(enml string) (string, error) {
+	dec := xml.NewDecoder(strings.NewReader(enml))
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+
+	w := &enmlWriter{markerEnd: -1}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid note content: %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			w.start(t)
+		case xml.EndElement:
+			w.end(t.Name.Local)
+		case xml.CharData:
+			if w.skip == 0 {
+				w.text(string(t))
+			}
+		}
+	}
+
+	md := blankLines.ReplaceAllString(string(w.buf), "\n\n")
+	return strings.TrimSpace(md), nil
+}
+
+func attr(t xml.StartElement, name string) string {
+	for _, a := range t.Attr {
+		if strings.EqualFold(a.Name.Local, name) {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (w *enmlWriter) start(t xml.StartElement) {
+	name := strings.ToLower(t.Name.Local)
+	style := strings.ReplaceAll(attr(t, "style"), " ", "")
+	if w.skip > 0 {
+		if name != "br" && name != "hr" && name != "en-media" && name != "en-todo" {
+			w.skip++
+		}
+		return
+	}
+
+	switch name {
+	case "head", "style", "script", "title":
+		w.skip++
+	case "div":
+		code := strings.Contains(style, "-en-codeblock:true")
+		w.divs = append(w.divs, code)
+		if code {
+			w.startPre()
+		} else {
+			w.breakLine(1)
+		}
+	case "p":
+		w.breakLine(2)
+	case "br":
+		w.lineBreak()
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		w.breakLine(2)
+		w.write(strings.Repeat("#", int(name[1]-'0')) + " ")
+		w.opened = true
+	case "ul", "ol":
+		w.breakLine(1)
+		w.lists = append(w.lists, enmlList{ordered: name == "ol", todo: strings.Contains(style, "--en-todo:true")})
+	case "li":
+		w.breakLine(1)
+		marker := "- "
+		if len(w.lists) > 0 {
+			l := &w.lists[len(w.lists)-1]
+			l.n++
+			switch {
+			case l.todo && strings.Contains(style, "--en-checked:true"):
+				marker = "- [x] "
+			case l.todo:
+				marker = "- [ ] "
+			case l.ordered:
+				marker = fmt.Sprintf("%d. ", l.n)
+			}
+			marker = strings.Repeat("  ", len(w.lists)-1) + marker
+		}
+		w.write(marker)
+		w.markerEnd = len(w.buf)
+	case "en-todo":
+		box := "[ ] "
+		if attr(t, "checked") == "true" {
+			box = "[x] "
+		}
+		switch {
+		case len(w.buf) == w.markerEnd:
+		case w.atLineStart():
+			box = "- " + box
+		default:
+			box = " " + box
+		}
+		w.write(box)
+		w.markerEnd = len(w.buf)
+	case "blockquote":
+		w.breakLine(2)
+		w.quote++
+	case "pre":
+		w.startPre()
+	case "hr":
+		w.breakLine(2)
+		w.write("---")
+		w.breakLine(2)
+	case "b", "strong":
+		w.inline("**")
+	case "i", "em":
+		w.inline("_")
+	case "s", "strike", "del":
+		w.inline("~~")
+	case "code":
+		if w.pre == 0 {
+			w.inline("`")
+		}
+	case "a":
+		w.inline("[")
+		w.links = append(w.links, enmlLink{href: attr(t, "href"), start: len(w.buf) - 1})
+	case "en-media":
+		w.inline(fmt.Sprintf("[attachment: %s]", attr(t, "type")))
+		w.opened = false
+	case "en-crypt":
+		w.inline("[encrypted content]")
+		w.opened = false
+		w.skip++
+	case "table":
+		w.breakLine(2)
+		w.table = enmlTable{}
+	case "tr":
+		w.breakLine(1)
+		w.write("|")
+		w.table.cells = 0
+	case "td", "th":
+		w.write(" ")
+		w.opened = true
+		w.table.cells++
+	}
+}
+
+func (w *enmlWriter) end(name string) {
+	name = strings.ToLower(name)
+	if w.skip > 0 {
+		w.skip--
+		return
+	}
+
+	switch name {
+	case "div":
+		if n := len(w.divs); n > 0 {
+			code := w.divs[n-1]
+			w.divs = w.divs[:n-1]
+			if code {
+				w.endPre()
+				return
+			}
+		}
+		w.breakLine(1)
+	case "p", "h1", "h2", "h3", "h4", "h5", "h6", "table":
+		w.breakLine(2)
+	case "ul", "ol":
+		if len(w.lists) > 0 {
+			w.lists = w.lists[:len(w.lists)-1]
+		}
+		w.breakLine(1)
+	case "li":
+		w.breakLine(1)
+	case "blockquote":
+		if w.quote > 0 {
+			w.quote--
+		}
+		w.breakLine(2)
+	case "pre":
+		w.endPre()
+	case "b", "strong":
+		w.closeInline("**")
+	case "i", "em":
+		w.closeInline("_")
+	case "s", "strike", "del":
+		w.closeInline("~~")
+	case "code":
+		if w.pre == 0 {
+			w.closeInline("`")
+		}
+	case "a":
+		w.closeLink()
+	case "tr":
+		w.table.rows++
+		if w.table.rows == 1 && w.table.cells > 0 {
+			w.breakLine(1)
+			w.write("|" + strings.Repeat(" --- |", w.table.cells))
+		}
+		w.breakLine(1)
+	case "td", "th":
+		w.trimSpaces()
+		w.buf = append(w.buf, " |"...)
+		w.space = false
+	}
+}
+
+// startPre opens a fenced code block; Evernote's code blocks are divs.
+func (w *enmlWriter) startPre() {
+	w.breakLine(2)
+	w.write("```")
+	w.buf = append(w.buf, '\n')
+	w.pre++
+}
+
+func (w *enmlWriter) endPre() {
+	if w.pre == 0 {
+		return
+	}
+	w.pre--
+	w.breakLine(1)
+	w.write("```")
+	w.breakLine(2)
+}
+
+func (w *enmlWriter) text(s string) {
+	if w.pre > 0 {
+		for i, line := range strings.Split(s, "\n") {
+			if i > 0 {
+				w.buf = append(w.buf, '\n')
+			}
+			w.write(line)
+		}
+		return
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" && !w.opened {
+			w.space = true
+		}
+		return
+	}
+	lead := strings.TrimLeft(s, " \t\r\n") != s
+	if (lead || w.space) && !w.opened && !w.atLineStart() {
+		w.buf = append(w.buf, ' ')
+	}
+	w.write(strings.Join(fields, " "))
+	w.space = strings.TrimRight(s, " \t\r\n") != s
+	w.opened = false
+}
+
+// inline writes an opening marker, keeping any pending space outside it.
+func (w *enmlWriter) inline(marker string) {
+	if w.space && !w.atLineStart() {
+		w.buf = append(w.buf, ' ')
+	}
+	w.space = false
+	w.write(marker)
+	w.opened = true
+}
+
+// closeInline writes a closing marker, moving trailing spaces after it. An
+// element with no text in it is dropped altogether.
+func (w *enmlWriter) closeInline(marker string) {
+	if w.opened && strings.HasSuffix(string(w.buf), marker) {
+		w.buf = w.buf[:len(w.buf)-len(marker)]
+		w.opened = false
+		return
+	}
+	w.trimSpaces()
+	w.buf = append(w.buf, marker...)
+	w.opened = false
+}
+
+// closeLink finishes a Markdown link, or writes a bare URL when the link
+// text is the address itself.
+func (w *enmlWriter) closeLink() {
+	if len(w.links) == 0 {
+		return
+	}
+	l := w.links[len(w.links)-1]
+	w.links = w.links[:len(w.links)-1]
+
+	w.trimSpaces()
+	label := string(w.buf[l.start+1:])
+	switch {
+	case l.href == "":
+		w.buf = append(w.buf[:l.start], label...)
+	case label == "" || label == l.href:
+		w.buf = append(w.buf[:l.start], l.href...)
+	default:
+		w.buf = append(w.buf, "]("+l.href+")"...)
+	}
+	w.opened = false
+}
+
+// write appends s, starting new lines with the current quote prefix.
+func (w *enmlWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	if len(w.buf) == 0 || w.buf[len(w.buf)-1] == '\n' {
+		w.buf = append(w.buf, strings.Repeat("> ", w.quote)...)
+	}
+	w.buf = append(w.buf, s...)
+}
+
+func (w *enmlWriter) atLineStart() bool {
+	if len(w.buf) == 0 || w.buf[len(w.buf)-1] == '\n' || len(w.buf) == w.markerEnd {
+		return true
+	}
+	line := w.buf[strings.LastIndexByte(string(w.buf), '\n')+1:]
+	return strings.TrimSpace(strings.ReplaceAll(string(line), ">", "")) == ""
+}
+
+// breakLine ends the current line so that at least n line breaks follow
+// the text written so far. An empty list item keeps its marker line.
+func (w *enmlWriter) breakLine(n int) {
+	w.space = false
+	w.opened = false
+	if len(w.buf) == 0 || len(w.buf) == w.markerEnd {
+		return
+	}
+	w.trimSpaces()
+	have := len(w.buf) - len(strings.TrimRight(string(w.buf), "\n"))
+	for ; have < n; have++ {
+		if have > 0 && w.quote > 0 {
+			w.buf = append(w.buf, strings.TrimSpace(strings.Repeat("> ", w.quote))...)
+		}
+		w.buf = append(w.buf, '\n')
+	}
+}
+
+// lineBreak handles <br>: Evernote writes an empty line as <div><br/></div>.
+func (w *enmlWriter) lineBreak() {
+	w.space = false
+	w.opened = false
+	if len(w.buf) == 0 {
+		return
+	}
+	w.trimSpaces()
+	w.buf = append(w.buf, '\n')
+}
+
+func (w *enmlWriter) trimSpaces() {
+	trimmed := strings.TrimRight(string(w.buf), " \t")
+	if len(trimmed) < w.markerEnd {
+		trimmed = string(w.buf[:w.markerEnd])
+	}
+	w.buf = w.buf[:len(trimmed)]
+}
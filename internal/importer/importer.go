@@ -0,0 +1,95 @@
+// Package importer reads notes from other tools and formats into
+// database.NewNote values ready for database.ImportNotes.
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Source formats understood by Read.
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatENEX     = "enex"
+	FormatKeep     = "keep"
+)
+
+// DetectFormat guesses the source format from a path: Google Takeout
+// folders are Keep, other directories and .md files are Markdown, otherwise
+// the file extension decides.
+func DetectFormat(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		for _, sub := range []string{"Keep", filepath.Join("Takeout", "Keep")} {
+			if info, err := os.Stat(filepath.Join(path, sub)); err == nil && info.IsDir() {
+				return FormatKeep, nil
+			}
+		}
+		return FormatMarkdown, nil
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return FormatMarkdown, nil
+	case ".json", ".ndjson", ".jsonl":
+		return FormatJSON, nil
+	case ".csv":
+		return FormatCSV, nil
+	case ".enex":
+		return FormatENEX, nil
+	}
+	return "", fmt.Errorf("cannot tell the format of %s, pass --from", path)
+}
+
+// timeLayouts are the date formats accepted in front matter and CSV columns.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	"02.01.2006",
+	"Jan 2, 2006",
+	"2 Jan 2006",
+}
+
+// ParseTime accepts the common ways dates are written in notes exports.
+func ParseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
+
+// splitList splits a tag list written as "a, b", "a;b" or "a b #c".
+func splitList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t'
+	})
+}
+
+// normalizePriority maps common spellings onto jotcli's low/medium/high.
+func normalizePriority(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "high", "h", "urgent", "important", "1":
+		return "high"
+	case "medium", "med", "m", "normal", "2":
+		return "medium"
+	case "low", "l", "3":
+		return "low"
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "standup.md"), "---\ntitle: Daily standup\ntags: [work, meeting]\npriority: High\ndate: 2026-09-30\n---\n\nDaily standup\n")
+	writeFile(t, filepath.Join(dir, "sub", "idea.markdown"), "---\ntitle: Big idea\ntag: ideas\n---\nBuild a thing")
+	writeFile(t, filepath.Join(dir, "plain.md"), "No front matter here")
+	writeFile(t, filepath.Join(dir, ".obsidian", "workspace.md"), "ignored")
+	writeFile(t, filepath.Join(dir, "image.png"), "ignored")
+
+	notes, err := ReadMarkdown(dir)
+	if err != nil {
+		t.Fatalf("ReadMarkdown() error = %v", err)
+	}
+	if len(notes) != 3 {
+		t.Fatalf("Expected 3 notes, got %d: %+v", len(notes), notes)
+	}
+
+	byContent := make(map[string]int)
+	for i, n := range notes {
+		byContent[n.Content] = i
+	}
+
+	standup := notes[byContent["Daily standup"]]
+	if strings.Join(standup.Tags, ",") != "work,meeting" || standup.Priority != "high" {
+		t.Errorf("Front matter not applied: %+v", standup)
+	}
+	if want := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC); !standup.CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt = %v, want %v", standup.CreatedAt, want)
+	}
+
+	idea, ok := byContent["# Big idea\n\nBuild a thing"]
+	if !ok || strings.Join(notes[idea].Tags, ",") != "ideas" {
+		t.Errorf("Title and single tag not applied: %+v", notes)
+	}
+	if plain := notes[byContent["No front matter here"]]; plain.CreatedAt.IsZero() {
+		t.Errorf("Files without a date should fall back to their modification time")
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	dir := t.TempDir()
+	array := filepath.Join(dir, "notes.json")
+	writeFile(t, array, `[{"id": 1, "content": "One", "title": "First", "slug": "first", "tags": ["a"], "priority": "medium", "status": "done", "created_at": "2026-01-02T03:04:05Z",
+		"due_at": "2026-01-09T17:00:00Z", "recurrence": "FREQ=WEEKLY"}]`)
+	lines := filepath.Join(dir, "notes.ndjson")
+	writeFile(t, lines, "{\"content\": \"Two\"}\n\n{\"content\": \"Three\", \"priority\": \"high\", \"status\": \"someday\", \"recurrence\": \"FREQ=WEEKLY\"}\n")
+
+	notes, err := ReadJSON(array)
+	if err != nil || len(notes) != 1 || notes[0].Content != "One" || notes[0].Priority != "medium" || notes[0].CreatedAt.Year() != 2026 {
+		t.Fatalf("ReadJSON(array) = %+v, %v", notes, err)
+	}
+	if n := notes[0]; n.DueAt == nil || n.DueAt.Day() != 9 || n.Status != "done" || n.Recurrence != "FREQ=WEEKLY" || n.Title != "First" {
+		t.Errorf("ReadJSON(array) lost the due date, status, recurrence or title: %+v", n)
+	}
+	notes, err = ReadJSON(lines)
+	if err != nil || len(notes) != 2 || notes[1].Priority != "high" || notes[1].Status != "" || notes[1].Recurrence != "" {
+		t.Errorf("ReadJSON(ndjson) = %+v, %v", notes, err)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sheet.csv")
+	writeFile(t, path, "Body,Labels,Date\n\"Multi\nline\",\"x; y\",2026-03-04\nSecond,,\n")
+
+	mapping, err := ParseCSVMapping([]string{"content=Body", "tags=labels", "created=Date"})
+	if err != nil {
+		t.Fatalf("ParseCSVMapping() error = %v", err)
+	}
+	notes, err := ReadCSV(path, mapping)
+	if err != nil {
+		t.Fatalf("ReadCSV() error = %v", err)
+	}
+	if len(notes) != 2 || notes[0].Content != "Multi\nline" || strings.Join(notes[0].Tags, ",") != "x,y" || notes[0].CreatedAt.Day() != 4 {
+		t.Errorf("ReadCSV() = %+v", notes)
+	}
+
+	if _, err := ReadCSV(path, DefaultCSVMapping); err == nil {
+		t.Errorf("Expected an error when the content column is missing")
+	}
+	if _, err := ParseCSVMapping([]string{"colour=Red"}); err == nil {
+		t.Errorf("Expected an error for an unknown field")
+	}
+}
+
+func TestENMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		enml string
+		want string
+	}{
+		{"lines", `<en-note><div>First line</div><div><br/></div><div>After a <b>blank</b> line</div></en-note>`, "First line\n\nAfter a **blank** line"},
+		{"heading and link", `<en-note><h2>Links</h2><div>See <a href="https://go.dev">the Go site</a> or <a href="https://x.org">https://x.org</a></div></en-note>`, "## Links\n\nSee [the Go site](https://go.dev) or https://x.org"},
+		{"lists", `<en-note><ul><li><div>one</div></li><li>two<ol><li>nested</li></ol></li></ul></en-note>`, "- one\n- two\n  1. nested"},
+		{"checklist", `<en-note><div><en-todo checked="true"/>Milk</div><div><en-todo/>Eggs</div><ul style="--en-todo:true;"><li style="--en-checked:false;">Bread</li></ul></en-note>`, "- [x] Milk\n- [ ] Eggs\n- [ ] Bread"},
+		{"entities", `<en-note><div>Fish&nbsp;&amp;&nbsp;chips <i>today</i>.</div></en-note>`, "Fish & chips _today_."},
+		{"code", `<en-note><div style="-en-codeblock: true;"><div>go test ./...</div><div>go vet</div></div></en-note>`, "```\ngo test ./...\ngo vet\n```"},
+		{"attachment", `<en-note><div>Scan: <en-media type="image/png" hash="abc"/></div></en-note>`, "Scan: [attachment: image/png]"},
+	}
+	for _, tt := range tests {
+		got, err := enmlToMarkdown(tt.enml)
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadENEX(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Work.enex")
+	writeFile(t, path, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export export-date="20261001T080000Z" application="Evernote">
+  <note>
+    <title>Standup</title>
+    <content><![CDATA[<?xml version="1.0" encoding="UTF-8"?><!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd"><en-note><div>Daily sync</div></en-note>]]></content>
+    <created>20260102T030405Z</created>
+    <updated>20260103T000000Z</updated>
+    <tag>work</tag>
+    <tag>meeting</tag>
+  </note>
+</en-export>`)
+
+	format, _ := DetectFormat(path)
+	if format != FormatENEX {
+		t.Errorf("DetectFormat() = %q, want %q", format, FormatENEX)
+	}
+	notes, err := ReadENEX(path)
+	if err != nil {
+		t.Fatalf("ReadENEX() error = %v", err)
+	}
+	if len(notes) != 1 {
+		t.Fatalf("ReadENEX() returned %d notes, want 1", len(notes))
+	}
+	n := notes[0]
+	if n.Content != "# Standup\n\nDaily sync" || strings.Join(n.Tags, ",") != "work,meeting" {
+		t.Errorf("Unexpected note: %+v", n)
+	}
+	if !n.CreatedAt.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("CreatedAt = %v", n.CreatedAt)
+	}
+}
+
+func TestReadKeep(t *testing.T) {
+	dir := t.TempDir()
+	keep := filepath.Join(dir, "Takeout", "Keep")
+	writeFile(t, filepath.Join(keep, "Groceries.json"), `{"title": "Groceries", "isPinned": true, "isArchived": false, "isTrashed": false,
+		"createdTimestampUsec": 1767323045000000, "userEditedTimestampUsec": 1767323045000000,
+		"labels": [{"name": "Home"}],
+		"listContent": [{"text": "Milk", "isChecked": true}, {"text": "Eggs", "isChecked": false}]}`)
+	writeFile(t, filepath.Join(keep, "Old.json"), `{"title": "Old", "textContent": "gone", "isTrashed": true, "createdTimestampUsec": 1}`)
+	writeFile(t, filepath.Join(keep, "Idea.json"), `{"textContent": "Read later", "isArchived": true, "createdTimestampUsec": 1767323045000000,
+		"annotations": [{"title": "Article", "url": "https://example.com"}]}`)
+	writeFile(t, filepath.Join(keep, "Labels.txt"), "Home\n")
+
+	format, _ := DetectFormat(dir)
+	if format != FormatKeep {
+		t.Errorf("DetectFormat() = %q, want %q", format, FormatKeep)
+	}
+	notes, err := ReadKeep(dir)
+	if err != nil {
+		t.Fatalf("ReadKeep() error = %v", err)
+	}
+	if len(notes) != 2 {
+		t.Fatalf("ReadKeep() returned %d notes, want 2 (trashed skipped)", len(notes))
+	}
+
+	// Files are read in name order
+	groceries, idea := notes[0], notes[1]
+	if groceries.Content != "# Groceries\n\n- [x] Milk\n- [ ] Eggs" {
+		t.Errorf("Checklist note = %q", groceries.Content)
+	}
+	if groceries.Priority != "high" || strings.Join(groceries.Tags, ",") != "Home,pinned" {
+		t.Errorf("Pinned note = %q %v", groceries.Priority, groceries.Tags)
+	}
+	if groceries.CreatedAt.Year() != 2026 {
+		t.Errorf("CreatedAt = %v", groceries.CreatedAt)
+	}
+	if idea.Content != "Read later\n\n- [Article](https://example.com)" || strings.Join(idea.Tags, ",") != "archived" {
+		t.Errorf("Archived note = %q %v", idea.Content, idea.Tags)
+	}
+}
@@ -0,0 +1,81 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/recur"
+)
+
+// jsonNote mirrors the fields jotcli writes with --output json/ndjson.
+type jsonNote struct {
+	Content    string     `json:"content"`
+	Title      string     `json:"title"` // the slug is made afresh
+	Tags       []string   `json:"tags"`
+	Priority   string     `json:"priority"`
+	Status     string     `json:"status"`
+	Recurrence string     `json:"recurrence"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DueAt      *time.Time `json:"due_at"`
+}
+
+// ReadJSON reads jotcli's own JSON output: either an array of notes
+// (--output json) or one note per line (--output ndjson).
+func ReadJSON(path string) ([]database.NewNote, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []jsonNote
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+			var r jsonNote
+			if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+				return nil, fmt.Errorf("%s line %d: %v", path, line, err)
+			}
+			records = append(records, r)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	notes := make([]database.NewNote, len(records))
+	for i, r := range records {
+		// A status jot doesn't know leaves a plain note
+		status, _ := database.ParseStatus(r.Status)
+		// Like add --every, a task only repeats from a due date
+		var recurrence string
+		if rule, err := recur.Parse(r.Recurrence); err == nil && r.DueAt != nil {
+			recurrence = rule.String()
+		}
+		notes[i] = database.NewNote{
+			Content:    r.Content,
+			Title:      r.Title,
+			Tags:       r.Tags,
+			Priority:   normalizePriority(r.Priority),
+			Status:     status,
+			CreatedAt:  r.CreatedAt,
+			UpdatedAt:  r.UpdatedAt,
+			DueAt:      r.DueAt,
+			Recurrence: recurrence,
+		}
+	}
+	return notes, nil
+}
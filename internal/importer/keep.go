@@ -0,0 +1,137 @@
+package importer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+)
+
+// keepNote is one note of a Google Keep Takeout export.
+type keepNote struct {
+	Title                   string `json:"title"`
+	TextContent             string `json:"textContent"`
+	IsPinned                bool   `json:"isPinned"`
+	IsArchived              bool   `json:"isArchived"`
+	IsTrashed               bool   `json:"isTrashed"`
+	CreatedTimestampUsec    int64  `json:"createdTimestampUsec"`
+	UserEditedTimestampUsec int64  `json:"userEditedTimestampUsec"`
+	Labels                  []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	ListContent []struct {
+		Text      string `json:"text"`
+		IsChecked bool   `json:"isChecked"`
+	} `json:"listContent"`
+	Annotations []struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+	} `json:"annotations"`
+}
+
+// Tags given to Keep notes that were pinned or archived.
+const (
+	KeepPinnedTag   = "pinned"
+	KeepArchivedTag = "archived"
+)
+
+// ReadKeep reads a Google Keep Takeout export: either the Takeout folder,
+// its Keep folder, or a single note's .json file. Labels become tags,
+// checklists become Markdown task lists, pinned notes are high priority and
+// tagged pinned, archived notes are tagged archived, and notes in Keep's
+// trash are skipped.
+func ReadKeep(path string) ([]database.NewNote, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		dir := path
+		for _, sub := range []string{"Keep", filepath.Join("Takeout", "Keep")} {
+			if info, err := os.Stat(filepath.Join(path, sub)); err == nil && info.IsDir() {
+				dir = filepath.Join(path, sub)
+				break
+			}
+		}
+		if files, err = filepath.Glob(filepath.Join(dir, "*.json")); err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	}
+
+	var notes []database.NewNote
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var kn keepNote
+		// Takeout folders hold other JSON too; anything that isn't a note is skipped
+		if err := json.Unmarshal(data, &kn); err != nil || kn.CreatedTimestampUsec == 0 && kn.UserEditedTimestampUsec == 0 {
+			continue
+		}
+		if kn.IsTrashed {
+			continue
+		}
+		notes = append(notes, convertKeepNote(kn))
+	}
+	return notes, nil
+}
+
+func convertKeepNote(kn keepNote) database.NewNote {
+	var body strings.Builder
+	body.WriteString(strings.TrimSpace(kn.TextContent))
+	if len(kn.ListContent) > 0 {
+		if body.Len() > 0 {
+			body.WriteString("\n\n")
+		}
+		for i, item := range kn.ListContent {
+			if i > 0 {
+				body.WriteString("\n")
+			}
+			box := "[ ]"
+			if item.IsChecked {
+				box = "[x]"
+			}
+			body.WriteString("- " + box + " " + strings.TrimSpace(item.Text))
+		}
+	}
+	if len(kn.Annotations) > 0 {
+		body.WriteString("\n")
+		for _, a := range kn.Annotations {
+			if a.URL == "" {
+				continue
+			}
+			if a.Title != "" {
+				body.WriteString("\n- [" + a.Title + "](" + a.URL + ")")
+			} else {
+				body.WriteString("\n- " + a.URL)
+			}
+		}
+	}
+
+	n := database.NewNote{Content: withTitle(kn.Title, strings.TrimSpace(body.String()))}
+	for _, l := range kn.Labels {
+		n.Tags = append(n.Tags, l.Name)
+	}
+	if kn.IsPinned {
+		n.Priority = "high"
+		n.Tags = append(n.Tags, KeepPinnedTag)
+	}
+	if kn.IsArchived {
+		n.Tags = append(n.Tags, KeepArchivedTag)
+	}
+	if kn.CreatedTimestampUsec > 0 {
+		n.CreatedAt = time.UnixMicro(kn.CreatedTimestampUsec)
+	}
+	if kn.UserEditedTimestampUsec > 0 {
+		n.UpdatedAt = time.UnixMicro(kn.UserEditedTimestampUsec)
+	}
+	return n
+}
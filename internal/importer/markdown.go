@@ -0,0 +1,137 @@
+package importer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/export"
+	"github.com/flyme2mars/jotcli/internal/markdown"
+)
+
+// ReadMarkdown reads a single Markdown file, or every Markdown file below a
+// directory. Hidden files and folders (.git, .obsidian, ...) are skipped.
+func ReadMarkdown(path string) ([]database.NewNote, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		n, err := readMarkdownFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return []database.NewNote{n}, nil
+	}
+
+	var notes []database.NewNote
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != path && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || d.Name() == export.ManifestName {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".md", ".markdown":
+		default:
+			return nil
+		}
+
+		n, err := readMarkdownFile(p)
+		if err != nil {
+			return err
+		}
+		notes = append(notes, n)
+		return nil
+	})
+	return notes, err
+}
+
+func readMarkdownFile(path string) (database.NewNote, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return database.NewNote{}, err
+	}
+	n, err := ParseMarkdown(data)
+	if err != nil {
+		return n, fmt.Errorf("%s: %v", path, err)
+	}
+	if n.CreatedAt.IsZero() {
+		if info, err := os.Stat(path); err == nil {
+			n.CreatedAt = info.ModTime()
+		}
+	}
+	return n, nil
+}
+
+// ParseMarkdown reads a note from a Markdown document, taking tags,
+// priority and dates from its front matter. A front matter title becomes a
+// heading unless the body already starts with one.
+func ParseMarkdown(data []byte) (database.NewNote, error) {
+	var n database.NewNote
+
+	meta, body, err := markdown.Parse(data)
+	if err != nil {
+		return n, fmt.Errorf("invalid front matter: %v", err)
+	}
+
+	n.Content = strings.TrimSpace(body)
+	// jot's own exports give the title the note already starts with
+	if title, ok := meta["title"].(string); ok && title != "" && !strings.HasPrefix(n.Content, "#") && markdown.FirstLine(n.Content) != title {
+		n.Content = strings.TrimSpace("# " + title + "\n\n" + n.Content)
+	}
+
+	n.Tags = metaTags(meta)
+	if p, ok := meta["priority"].(string); ok {
+		n.Priority = normalizePriority(p)
+	}
+
+	n.CreatedAt = metaTime(meta, "created", "date", "created_at")
+	n.UpdatedAt = metaTime(meta, "updated", "modified", "updated_at")
+	return n, nil
+}
+
+// metaTags reads tags from "tags" or "tag", written either as a YAML list
+// or as a single string.
+func metaTags(meta map[string]any) []string {
+	var tags []string
+	for _, key := range []string{"tags", "tag"} {
+		switch v := meta[key].(type) {
+		case string:
+			tags = append(tags, splitList(v)...)
+		case []any:
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					tags = append(tags, s)
+				}
+			}
+		}
+	}
+	return tags
+}
+
+// metaTime returns the first of keys that holds a date.
+func metaTime(meta map[string]any, keys ...string) time.Time {
+	for _, key := range keys {
+		switch v := meta[key].(type) {
+		case time.Time:
+			return v
+		case string:
+			if t, err := ParseTime(v); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,77 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// checkboxPattern matches a task list item such as "- [ ] buy milk" or
+// "  * [x] done", capturing everything up to the box, the mark and the text.
+var checkboxPattern = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)])\s+\[)([ xX])\]\s*(.*)$`)
+
+// ChecklistItem is a task list item in a note, on the given line (counting
+// from zero).
+type ChecklistItem struct {
+	Line int
+	Done bool
+	Text string
+}
+
+// Checklist returns the task list items in content, skipping fenced code
+// blocks.
+func Checklist(content string) []ChecklistItem {
+	var items []ChecklistItem
+	fenced := false
+	for i, line := range strings.Split(content, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		if m := checkboxPattern.FindStringSubmatch(line); m != nil {
+			items = append(items, ChecklistItem{Line: i, Done: m[2] != " ", Text: m[3]})
+		}
+	}
+	return items
+}
+
+// Progress counts the checked and total task list items in content.
+func Progress(content string) (done, total int) {
+	for _, item := range Checklist(content) {
+		if item.Done {
+			done++
+		}
+		total++
+	}
+	return done, total
+}
+
+// ToggleItem checks or unchecks the i-th task list item in content. It
+// reports false if there is no such item.
+func ToggleItem(content string, i int) (string, bool) {
+	items := Checklist(content)
+	if i < 0 || i >= len(items) {
+		return content, false
+	}
+
+	lines := strings.Split(content, "\n")
+	item := items[i]
+	mark := "x"
+	if item.Done {
+		mark = " "
+	}
+	lines[item.Line] = checkboxPattern.ReplaceAllString(lines[item.Line], "${1}"+mark+"] $3")
+	return strings.Join(lines, "\n"), true
+}
+
+// ResetChecklist unchecks every task list item in content.
+func ResetChecklist(content string) string {
+	for i, item := range Checklist(content) {
+		if item.Done {
+			content, _ = ToggleItem(content, i)
+		}
+	}
+	return content
+}
@@ -0,0 +1,41 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChecklist(t *testing.T) {
+	content := "# Groceries\n\n- [ ] milk\n- [x] eggs\n  * [X] bread\n1. [ ] tea\n\n```\n- [ ] not a task\n```\n- [] nor this\n"
+
+	items := Checklist(content)
+	want := []ChecklistItem{{2, false, "milk"}, {3, true, "eggs"}, {4, true, "bread"}, {5, false, "tea"}}
+	if len(items) != len(want) {
+		t.Fatalf("Checklist() = %+v, want %+v", items, want)
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("Checklist()[%d] = %+v, want %+v", i, items[i], want[i])
+		}
+	}
+
+	if done, total := Progress(content); done != 2 || total != 4 {
+		t.Errorf("Progress() = %d/%d, want 2/4", done, total)
+	}
+
+	toggled, ok := ToggleItem(content, 0)
+	if !ok || toggled != "# Groceries\n\n- [x] milk\n- [x] eggs\n  * [X] bread\n1. [ ] tea\n\n```\n- [ ] not a task\n```\n- [] nor this\n" {
+		t.Errorf("ToggleItem(0) = %q, %v", toggled, ok)
+	}
+	toggled, _ = ToggleItem(toggled, 2)
+	if items := Checklist(toggled); items[2].Done || items[2].Text != "bread" {
+		t.Errorf("ToggleItem(2) should uncheck bread, got %+v", items[2])
+	}
+	reset := ResetChecklist(content)
+	if done, _ := Progress(reset); done != 0 || !strings.Contains(reset, "  * [ ] bread") {
+		t.Errorf("ResetChecklist() = %q", reset)
+	}
+	if _, ok := ToggleItem(content, 4); ok {
+		t.Error("ToggleItem() past the last item should fail")
+	}
+}
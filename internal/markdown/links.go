@@ -0,0 +1,44 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// linkPattern matches a wiki-style link such as [[12]], [[Sprint planning]],
+// [[12|the plan]] or [[12#goals]], capturing what it points to and any
+// heading.
+var linkPattern = regexp.MustCompile(`\[\[([^\[\]|\n]+)(?:\|[^\[\]\n]*)?\]\]`)
+
+// Links returns what the [[links]] in content point to, in order and without
+// repeats, skipping fenced code blocks.
+func Links(content string) []string {
+	var targets []string
+	seen := map[string]bool{}
+	fenced := false
+	for _, line := range strings.Split(content, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		for _, m := range linkPattern.FindAllStringSubmatch(line, -1) {
+			// A link to a heading, as Obsidian writes them, links to the note
+			target, _, _ := strings.Cut(m[1], "#")
+			target = strings.Join(strings.Fields(target), " ")
+			if key := LinkKey(target); target != "" && !seen[key] {
+				seen[key] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets
+}
+
+// LinkKey is how link targets and note titles are compared: without case
+// or extra spaces.
+func LinkKey(target string) string {
+	return strings.ToLower(strings.Join(strings.Fields(target), " "))
+}
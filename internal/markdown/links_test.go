@@ -0,0 +1,21 @@
+package markdown
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	content := "# Plan\n\nSee [[12]] and [[ Sprint  planning ]], [[12|the notes]] again.\n" +
+		"```\n[[not a link]]\n```\n" +
+		"Also [[sprint planning]], [[]] and [[Retro|last one]].\n" +
+		"Headings: [[4#intro]], [[Retro#Actions|what to do]], [[12 # goals]] and [[#local]]."
+
+	want := []string{"12", "Sprint planning", "Retro", "4"}
+	if got := Links(content); !slices.Equal(got, want) {
+		t.Errorf("Links() = %q, want %q", got, want)
+	}
+	if LinkKey("  Sprint   Planning ") != "sprint planning" {
+		t.Errorf("LinkKey() = %q", LinkKey("  Sprint   Planning "))
+	}
+}
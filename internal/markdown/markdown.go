@@ -0,0 +1,127 @@
+// Package markdown reads and writes notes as Markdown files with YAML front
+// matter, the format shared by export, import and vault sync.
+package markdown
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const delimiter = "---"
+
+// FrontMatter is the metadata jotcli writes at the top of exported notes.
+type FrontMatter struct {
+	ID       int       `yaml:"id,omitempty"`
+	Title    string    `yaml:"title,omitempty"`
+	Slug     string    `yaml:"slug,omitempty"`
+	Tags     []string  `yaml:"tags,omitempty"`
+	Priority string    `yaml:"priority,omitempty"`
+	Created  time.Time `yaml:"created,omitempty"`
+	Updated  time.Time `yaml:"updated,omitempty"`
+}
+
+// Render produces a Markdown document with fm as YAML front matter followed
+// by body.
+func Render(fm FrontMatter, body string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(delimiter + "\n")
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(fm); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(delimiter + "\n\n")
+	buf.WriteString(strings.TrimRight(body, "\n"))
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// Split separates YAML front matter from the body of a Markdown document.
+// The front matter is returned as raw YAML, or nil if the document has none.
+func Split(doc []byte) (frontMatter []byte, body string) {
+	text := strings.ReplaceAll(string(doc), "\r\n", "\n")
+	text = strings.TrimPrefix(text, "\ufeff")
+
+	if !strings.HasPrefix(text, delimiter+"\n") {
+		return nil, text
+	}
+	rest := text[len(delimiter)+1:]
+
+	// The closing delimiter is a line of its own
+	end := strings.Index(rest, "\n"+delimiter+"\n")
+	var after string
+	switch {
+	case strings.HasPrefix(rest, delimiter+"\n"):
+		return nil, strings.TrimLeft(rest[len(delimiter)+1:], "\n")
+	case end >= 0:
+		after = rest[end+len(delimiter)+2:]
+	case strings.HasSuffix(rest, "\n"+delimiter):
+		end = len(rest) - len(delimiter) - 1
+	default:
+		return nil, text
+	}
+
+	return []byte(rest[:end+1]), strings.TrimLeft(after, "\n")
+}
+
+// Parse splits a document and decodes its front matter into a generic map,
+// so callers can accept the many shapes front matter takes in the wild.
+func Parse(doc []byte) (map[string]any, string, error) {
+	raw, body := Split(doc)
+	meta := make(map[string]any)
+	if raw == nil {
+		return meta, body, nil
+	}
+	if err := yaml.Unmarshal(raw, &meta); err != nil {
+		return nil, body, err
+	}
+	if meta == nil {
+		meta = make(map[string]any)
+	}
+	return meta, body, nil
+}
+
+// FirstLine returns the first non-empty line of content, without any
+// leading Markdown heading markers.
+func FirstLine(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+		if line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// Slug turns text into a lower-case, hyphen-separated name that is safe to
+// use in file names and URLs.
+func Slug(text string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+
+	slug := strings.TrimRight(b.String(), "-")
+	if runes := []rune(slug); len(runes) > 50 {
+		slug = strings.TrimRight(string(runes[:50]), "-")
+	}
+	return slug
+}
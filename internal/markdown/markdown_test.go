@@ -0,0 +1,76 @@
+package markdown
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRenderAndParse(t *testing.T) {
+	created := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	doc, err := Render(FrontMatter{ID: 7, Tags: []string{"work", "meeting"}, Priority: "high", Created: created}, "# Standup\n\n- item\n")
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := "---\nid: 7\ntags:\n  - work\n  - meeting\npriority: high\ncreated: 2026-10-01T09:30:00Z\n---\n\n# Standup\n\n- item\n"
+	if string(doc) != want {
+		t.Errorf("Render() =\n%s\nwant:\n%s", doc, want)
+	}
+
+	meta, body, err := Parse(doc)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if body != "# Standup\n\n- item\n" {
+		t.Errorf("Parse() body = %q", body)
+	}
+	if meta["id"] != 7 || meta["priority"] != "high" {
+		t.Errorf("Parse() meta = %v", meta)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		wantMeta string
+		wantBody string
+	}{
+		{"No front matter", "Just text\n", "", "Just text\n"},
+		{"Windows line endings", "---\r\ntitle: x\r\n---\r\nbody\r\n", "title: x\n", "body\n"},
+		{"Empty front matter", "---\n---\nbody", "", "body"},
+		{"Unterminated", "---\ntitle: x\nbody", "", "---\ntitle: x\nbody"},
+		{"Front matter only", "---\ntitle: x\n---", "title: x\n", ""},
+		{"Horizontal rule in body", "---\na: 1\n---\ntext\n---\nmore", "a: 1\n", "text\n---\nmore"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, body := Split([]byte(tt.doc))
+			if string(meta) != tt.wantMeta || body != tt.wantBody {
+				t.Errorf("Split() = %q, %q; want %q, %q", meta, body, tt.wantMeta, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := map[string]string{
+		"Hello, World!":        "hello-world",
+		"  Café  déjà vu  ":    "café-déjà-vu",
+		"Q3 planning: #1 prio": "q3-planning-1-prio",
+		"---":                  "",
+		"A very long title that keeps going and going well past the limit": "a-very-long-title-that-keeps-going-and-going-well",
+	}
+	for in, want := range tests {
+		if got := Slug(in); got != want {
+			t.Errorf("Slug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFirstLine(t *testing.T) {
+	if got := FirstLine("\n\n## Weekly review \nbody"); got != "Weekly review" {
+		t.Errorf("FirstLine() = %q", got)
+	}
+}
@@ -0,0 +1,321 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/dates"
+)
+
+// Fields lists the fields a query can use.
+var Fields = []string{"tag", "priority", "status", "created", "updated", "due", "id"}
+
+// priorities are the priority levels from lowest to highest.
+var priorities = []string{"low", "medium", "high"}
+
+// fieldCheck is a compiled field: SQL for notes aliased as n, and the same
+// test for a note in memory.
+type fieldCheck struct {
+	sql   string
+	args  []any
+	match func(n database.Note) bool
+}
+
+// newField validates a field token and compiles it.
+func newField(tok token) (Node, error) {
+	f := Field{Name: tok.text, Op: tok.op, Value: tok.value}
+	fail := func(msg string) (Node, error) {
+		return nil, errorAt(tok.pos, msg)
+	}
+
+	switch f.Name {
+	case "tag":
+		if f.Op != "" && f.Op != "=" {
+			return fail(fmt.Sprintf("tag: can't be compared with %s", f.Op))
+		}
+		tag := database.NormalizeTag(f.Value)
+		if tag == "" {
+			return fail(fmt.Sprintf("invalid tag %q", f.Value))
+		}
+		f.check = tagCheck(tag)
+
+	case "priority":
+		level := slices.Index(priorities, strings.ToLower(f.Value))
+		if level == -1 {
+			return fail(fmt.Sprintf("invalid priority %q (use low, medium or high)", f.Value))
+		}
+		f.check = priorityCheck(selectLevels(level, f.Op))
+
+	case "status":
+		if f.Op != "" && f.Op != "=" {
+			return fail(fmt.Sprintf("status: can't be compared with %s", f.Op))
+		}
+		status := ""
+		if !strings.EqualFold(f.Value, "none") {
+			s, err := database.ParseStatus(f.Value)
+			if err != nil {
+				return fail(err.Error() + " or none")
+			}
+			status = s
+		}
+		f.check = fieldCheck{
+			sql:   "n.status = ?",
+			args:  []any{status},
+			match: func(n database.Note) bool { return n.Status == status },
+		}
+
+	case "created", "updated", "due":
+		r, err := dates.Parse(f.Value, time.Now())
+		if err != nil {
+			return fail(err.Error())
+		}
+		f.check = dateCheck(f.Name, f.Op, r.Start, r.End)
+
+	case "id":
+		id, err := strconv.Atoi(f.Value)
+		if err != nil {
+			return fail(fmt.Sprintf("invalid note ID %q", f.Value))
+		}
+		f.check = idCheck(f.Op, id)
+
+	default:
+		msg := fmt.Sprintf("unknown field %q (use %s)", f.Name, strings.Join(Fields, ", "))
+		return nil, &Error{Pos: tok.pos, Msg: msg, err: ErrUnknownField}
+	}
+	return f, nil
+}
+
+func tagCheck(tag string) fieldCheck {
+	pattern := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(tag) + "/%"
+	return fieldCheck{
+		sql: `EXISTS (SELECT 1 FROM note_tags nt JOIN tags t ON t.id = nt.tag_id
+			WHERE nt.note_id = n.id AND (t.name = ? OR t.name LIKE ? ESCAPE '\'))`,
+		args: []any{tag, pattern},
+		match: func(n database.Note) bool {
+			return slices.ContainsFunc(n.Tags, func(t string) bool {
+				return t == tag || strings.HasPrefix(t, tag+"/")
+			})
+		},
+	}
+}
+
+// selectLevels returns the priorities that satisfy op compared with level.
+func selectLevels(level int, op string) []string {
+	switch op {
+	case ">":
+		return priorities[level+1:]
+	case ">=":
+		return priorities[level:]
+	case "<":
+		return priorities[:level]
+	case "<=":
+		return priorities[:level+1]
+	}
+	return priorities[level : level+1]
+}
+
+func priorityCheck(levels []string) fieldCheck {
+	if len(levels) == 0 {
+		return fieldCheck{sql: "0", match: func(database.Note) bool { return false }}
+	}
+	args := make([]any, len(levels))
+	for i, l := range levels {
+		args[i] = l
+	}
+	return fieldCheck{
+		sql:  "lower(n.priority) IN (?" + strings.Repeat(", ?", len(levels)-1) + ")",
+		args: args,
+		match: func(n database.Note) bool {
+			return slices.Contains(levels, strings.ToLower(n.Priority))
+		},
+	}
+}
+
+// dateCheck compares a timestamp column with the range [start, end): after
+// means on or after end, before means before start. Notes without a due
+// date never match a due: field.
+func dateCheck(column, op string, start, end time.Time) fieldCheck {
+	value := func(n database.Note) (string, bool) {
+		switch column {
+		case "updated":
+			return n.UpdatedAt.Format(database.TimestampPrefix), true
+		case "due":
+			if n.DueAt == nil {
+				return "", false
+			}
+			return n.DueAt.Format(database.TimestampPrefix), true
+		}
+		return n.CreatedAt.Format(database.TimestampPrefix), true
+	}
+	expr := fmt.Sprintf("substr(n.%s_at, 1, %d)", column, len(database.TimestampPrefix))
+	from, to := start.Format(database.TimestampPrefix), end.Format(database.TimestampPrefix)
+
+	check := func(sql string, args []any, ok func(v string) bool) fieldCheck {
+		if column == "due" {
+			sql = "n.due_at IS NOT NULL AND " + sql
+		}
+		return fieldCheck{sql, args, func(n database.Note) bool {
+			v, has := value(n)
+			return has && ok(v)
+		}}
+	}
+
+	switch op {
+	case ">":
+		return check(expr+" >= ?", []any{to}, func(v string) bool { return v >= to })
+	case ">=":
+		return check(expr+" >= ?", []any{from}, func(v string) bool { return v >= from })
+	case "<":
+		return check(expr+" < ?", []any{from}, func(v string) bool { return v < from })
+	case "<=":
+		return check(expr+" < ?", []any{to}, func(v string) bool { return v < to })
+	}
+	return check(expr+" >= ? AND "+expr+" < ?", []any{from, to}, func(v string) bool {
+		return v >= from && v < to
+	})
+}
+
+func idCheck(op string, id int) fieldCheck {
+	if op == "" {
+		op = "="
+	}
+	return fieldCheck{
+		sql:  "n.id " + op + " ?",
+		args: []any{id},
+		match: func(n database.Note) bool {
+			switch op {
+			case ">":
+				return n.ID > id
+			case ">=":
+				return n.ID >= id
+			case "<":
+				return n.ID < id
+			case "<=":
+				return n.ID <= id
+			}
+			return n.ID == id
+		},
+	}
+}
+
+// SQL compiles the query to a condition on a notes table aliased as n.
+func (q *Query) SQL() (string, []any) {
+	return compile(q.Root)
+}
+
+func compile(n Node) (string, []any) {
+	switch n := n.(type) {
+	case And:
+		return compileList(n.Nodes, " AND ")
+	case Or:
+		return compileList(n.Nodes, " OR ")
+	case Not:
+		sql, args := compile(n.Node)
+		return "NOT " + sql, args
+	case Text:
+		return "n.id IN (SELECT rowid FROM notes_fts WHERE notes_fts MATCH ?)", []any{n.fts()}
+	case Field:
+		return "(" + n.check.sql + ")", n.check.args
+	}
+	panic(fmt.Sprintf("query: unknown node %T", n))
+}
+
+func compileList(nodes []Node, joiner string) (string, []any) {
+	parts := make([]string, len(nodes))
+	var args []any
+	for i, n := range nodes {
+		var nodeArgs []any
+		parts[i], nodeArgs = compile(n)
+		args = append(args, nodeArgs...)
+	}
+	return "(" + strings.Join(parts, joiner) + ")", args
+}
+
+// fts quotes a term for FTS5, keeping a trailing * as a prefix match.
+func (n Text) fts() string {
+	value, prefix := n.Value, false
+	if !n.Phrase {
+		value, prefix = strings.CutSuffix(value, "*")
+		value = strings.TrimRight(value, "*")
+	}
+	quoted := `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+	if prefix {
+		quoted += "*"
+	}
+	return quoted
+}
+
+// Match reports whether a note matches the query. Content is split into
+// words much like the full-text index does, so results agree with SQL.
+func (q *Query) Match(n database.Note) bool {
+	return match(q.Root, n, words(n.Content))
+}
+
+func match(node Node, n database.Note, content []string) bool {
+	switch node := node.(type) {
+	case And:
+		for _, child := range node.Nodes {
+			if !match(child, n, content) {
+				return false
+			}
+		}
+		return true
+	case Or:
+		for _, child := range node.Nodes {
+			if match(child, n, content) {
+				return true
+			}
+		}
+		return false
+	case Not:
+		return !match(node.Node, n, content)
+	case Text:
+		return node.matchWords(content)
+	case Field:
+		return node.check.match(n)
+	}
+	return false
+}
+
+// matchWords reports whether the term's words appear in a row in content.
+func (n Text) matchWords(content []string) bool {
+	value, prefix := n.Value, false
+	if !n.Phrase {
+		value, prefix = strings.CutSuffix(value, "*")
+	}
+	terms := words(value)
+	if len(terms) == 0 {
+		return false
+	}
+
+	for i := 0; i+len(terms) <= len(content); i++ {
+		ok := true
+		for j, term := range terms {
+			word := content[i+j]
+			if prefix && j == len(terms)-1 {
+				ok = strings.HasPrefix(word, term)
+			} else {
+				ok = word == term
+			}
+			if !ok {
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// words splits text into lower-case words of letters and digits.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,182 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokPhrase
+	tokField
+	tokRef
+	tokAnd
+	tokOr
+	tokNot
+	tokMinus
+	tokLParen
+	tokRParen
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokEOF:
+		return "end of query"
+	case tokWord, tokPhrase:
+		return "search term"
+	case tokField:
+		return "field"
+	case tokRef:
+		return "saved search"
+	case tokAnd:
+		return "AND"
+	case tokOr:
+		return "OR"
+	case tokNot:
+		return "NOT"
+	case tokMinus:
+		return `"-"`
+	case tokLParen:
+		return `"("`
+	case tokRParen:
+		return `")"`
+	}
+	return "token"
+}
+
+// token is a lexed piece of a query. For fields, text is the field name and
+// op and value hold the rest, e.g. created:>=2026-01-01. For saved search
+// references, text is the name without the @.
+type token struct {
+	kind  tokenKind
+	pos   int // byte offset in the query
+	text  string
+	op    string
+	value string
+}
+
+// lex splits a query into tokens.
+func lex(input string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(input) {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, pos: i, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, pos: i, text: ")"})
+			i++
+		case r == '-':
+			if next, _ := utf8.DecodeRuneInString(input[i+1:]); i+1 == len(input) || unicode.IsSpace(next) {
+				return nil, errorAt(i, `"-" must come right before the term it excludes`)
+			}
+			tokens = append(tokens, token{kind: tokMinus, pos: i, text: "-"})
+			i++
+		case r == '"':
+			phrase, n, err := lexPhrase(input, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokPhrase, pos: i, text: phrase})
+			i += n
+		default:
+			tok, n, err := lexWord(input, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i += n
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(input)}), nil
+}
+
+// lexPhrase reads a double-quoted phrase starting at input[start], returning
+// its contents and the number of bytes consumed.
+func lexPhrase(input string, start int) (string, int, error) {
+	end := strings.IndexByte(input[start+1:], '"')
+	if end == -1 {
+		return "", 0, errorAt(start, "unterminated quote")
+	}
+	return input[start+1 : start+1+end], end + 2, nil
+}
+
+// lexWord reads a bare word, keyword or name:value field starting at
+// input[start].
+func lexWord(input string, start int) (token, int, error) {
+	end := start
+	for end < len(input) {
+		r, size := utf8.DecodeRuneInString(input[end:])
+		if unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' {
+			break
+		}
+		if r == ':' {
+			return lexField(input, start, end)
+		}
+		end += size
+	}
+
+	word := input[start:end]
+	tok := token{kind: tokWord, pos: start, text: word}
+	if len(word) > 1 && word[0] == '@' {
+		return token{kind: tokRef, pos: start, text: word[1:]}, end - start, nil
+	}
+	switch word {
+	case "AND":
+		tok.kind = tokAnd
+	case "OR":
+		tok.kind = tokOr
+	case "NOT":
+		tok.kind = tokNot
+	}
+	return tok, end - start, nil
+}
+
+// lexField reads the comparison and value of a field whose name runs from
+// start to colon.
+func lexField(input string, start, colon int) (token, int, error) {
+	tok := token{kind: tokField, pos: start, text: strings.ToLower(input[start:colon])}
+	i := colon + 1
+
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(input[i:], op) {
+			tok.op = op
+			i += len(op)
+			break
+		}
+	}
+
+	if i < len(input) && input[i] == '"' {
+		value, n, err := lexPhrase(input, i)
+		if err != nil {
+			return token{}, 0, err
+		}
+		tok.value = value
+		i += n
+	} else {
+		end := i
+		for end < len(input) {
+			r, size := utf8.DecodeRuneInString(input[end:])
+			if unicode.IsSpace(r) || r == '(' || r == ')' {
+				break
+			}
+			end += size
+		}
+		tok.value = input[i:end]
+		i = end
+	}
+
+	if strings.TrimSpace(tok.value) == "" {
+		return token{}, 0, errorAt(start, fmt.Sprintf("%s: needs a value", tok.text))
+	}
+	return tok, i - start, nil
+}
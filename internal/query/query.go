@@ -0,0 +1,401 @@
+// Package query parses the note query language used by list, search, export
+// and the TUI filter bar, e.g.
+//
+//	tag:work priority:>=medium created:>2026-01-01 -tag:done "exact phrase" OR urgent
+//
+// Terms next to each other must all match. OR binds tighter than that, so
+// the example above finds work notes of medium or high priority, created
+// after the 1st of January, not tagged done, that contain either the phrase
+// or the word urgent. Terms can be grouped with parentheses and excluded
+// with a leading "-" or NOT, and @name stands for the query of the saved
+// search called name.
+//
+// A parsed Query is a database.Condition: it compiles to parameterized SQL
+// against the notes schema and can also be matched against notes in memory.
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+)
+
+// ErrUnknownField is reported for a name:value term whose name isn't one
+// of Fields.
+var ErrUnknownField = errors.New("unknown field")
+
+// Error is a problem with a query, at a byte offset into it.
+type Error struct {
+	Pos int
+	Msg string
+
+	err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid query at column %d: %s", e.Pos+1, e.Msg)
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+func errorAt(pos int, msg string) *Error {
+	return &Error{Pos: pos, Msg: msg}
+}
+
+// Node is a node of a parsed query: And, Or, Not, Text or Field.
+type Node interface {
+	String() string
+}
+
+// And matches notes that match every one of Nodes.
+type And struct {
+	Nodes []Node
+}
+
+// Or matches notes that match any of Nodes.
+type Or struct {
+	Nodes []Node
+}
+
+// Not matches notes that don't match Node.
+type Not struct {
+	Node Node
+}
+
+// Text matches notes whose content contains a word, or a sequence of words
+// for phrases. A word ending in * matches any word starting with it.
+type Text struct {
+	Value  string
+	Phrase bool
+}
+
+// Field matches notes on one of their attributes, e.g. tag:work or
+// created:>2026-01-01. Op is empty for plain equality.
+type Field struct {
+	Name  string
+	Op    string
+	Value string
+
+	check fieldCheck
+}
+
+func (n And) String() string { return "(and " + joinNodes(n.Nodes) + ")" }
+func (n Or) String() string  { return "(or " + joinNodes(n.Nodes) + ")" }
+func (n Not) String() string { return "(not " + n.Node.String() + ")" }
+
+func (n Text) String() string {
+	if n.Phrase {
+		return `"` + n.Value + `"`
+	}
+	return n.Value
+}
+
+func (n Field) String() string {
+	value := n.Value
+	if strings.ContainsAny(value, " \t()") {
+		value = `"` + value + `"`
+	}
+	return n.Name + ":" + n.Op + value
+}
+
+func joinNodes(nodes []Node) string {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		parts[i] = n.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// Query is a parsed query.
+type Query struct {
+	Root Node
+
+	saved bool // whether any @name references were expanded
+}
+
+// Resolver returns the query of the saved search called name.
+type Resolver func(name string) (string, error)
+
+// Parse parses a query. Problems are reported as an *Error.
+func Parse(input string) (*Query, error) {
+	return ParseWith(input, nil)
+}
+
+// ParseWith parses a query that may refer to saved searches as @name,
+// looking up their queries with resolve.
+func ParseWith(input string, resolve Resolver) (*Query, error) {
+	var saved bool
+	track := resolve
+	if resolve != nil {
+		track = func(name string) (string, error) {
+			saved = true
+			return resolve(name)
+		}
+	}
+
+	root, err := parse(input, track, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Query{Root: root, saved: saved}, nil
+}
+
+// parse parses input, with using holding the saved searches it is part of.
+func parse(input string, resolve Resolver, using []string) (Node, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, errorAt(0, "the query is empty")
+	}
+
+	p := &parser{tokens: tokens, resolve: resolve, using: using}
+	root, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, errorAt(tok.pos, fmt.Sprintf("unexpected %s", describe(tok)))
+	}
+	return root, nil
+}
+
+func (q *Query) String() string {
+	return q.Root.String()
+}
+
+// UsesFields reports whether input has a term with a known field or a
+// saved search in it, so it was meant as a query rather than as text to
+// search for, like a URL or "foo:bar".
+func UsesFields(input string) bool {
+	for _, word := range strings.Fields(input) {
+		word = strings.TrimLeft(word, "-(")
+		if len(word) > 1 && word[0] == '@' {
+			return true
+		}
+		if name, _, ok := strings.Cut(word, ":"); ok && slices.Contains(Fields, strings.ToLower(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPlainText reports whether the query only searches note content, without
+// fields or saved searches, so it can be handed to the full-text index as
+// typed.
+func (q *Query) IsPlainText() bool {
+	if q.saved {
+		return false
+	}
+	var found bool
+	walk(q.Root, func(n Node) {
+		if _, ok := n.(Field); ok {
+			found = true
+		}
+	})
+	return !found
+}
+
+// Text returns the words and phrases every match must contain, as an FTS5
+// query for ranking results by relevance. It is empty if the query has no
+// such terms, e.g. when every word is part of an OR.
+func (q *Query) Text() string {
+	var terms []Text
+	switch n := q.Root.(type) {
+	case Text:
+		terms = append(terms, n)
+	case And:
+		for _, child := range n.Nodes {
+			if t, ok := child.(Text); ok {
+				terms = append(terms, t)
+			}
+		}
+	}
+
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = t.fts()
+	}
+	return strings.Join(parts, " ")
+}
+
+func walk(n Node, fn func(Node)) {
+	fn(n)
+	switch n := n.(type) {
+	case And:
+		for _, child := range n.Nodes {
+			walk(child, fn)
+		}
+	case Or:
+		for _, child := range n.Nodes {
+			walk(child, fn)
+		}
+	case Not:
+		walk(n.Node, fn)
+	}
+}
+
+// parser is a recursive descent parser for the grammar
+//
+//	and   = or { ["AND"] or }
+//	or    = unary { "OR" unary }
+//	unary = ("-" | "NOT") unary | "(" and ")" | word | phrase | field | @name
+type parser struct {
+	tokens  []token
+	pos     int
+	resolve Resolver
+	using   []string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	var nodes []Node
+	for {
+		tok := p.peek()
+		if tok.kind == tokEOF || tok.kind == tokRParen {
+			break
+		}
+		if tok.kind == tokAnd {
+			p.next()
+			if len(nodes) == 0 {
+				return nil, errorAt(tok.pos, "AND needs a term on each side")
+			}
+			if next := p.peek(); next.kind == tokEOF || next.kind == tokRParen {
+				return nil, errorAt(tok.pos, "AND needs a term on each side")
+			}
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if and, ok := n.(And); ok {
+			nodes = append(nodes, and.Nodes...)
+		} else {
+			nodes = append(nodes, n)
+		}
+	}
+
+	switch len(nodes) {
+	case 0:
+		return nil, errorAt(p.peek().pos, "expected a search term")
+	case 1:
+		return nodes[0], nil
+	}
+	return And{Nodes: nodes}, nil
+}
+
+func (p *parser) parseOr() (Node, error) {
+	n, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{n}
+	for p.peek().kind == tokOr {
+		p.next()
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return Or{Nodes: nodes}, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokMinus, tokNot:
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: n}, nil
+	case tokLParen:
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, errorAt(tok.pos, `unclosed "("`)
+		}
+		return n, nil
+	case tokWord, tokPhrase:
+		if len(words(tok.text)) == 0 {
+			return nil, errorAt(tok.pos, fmt.Sprintf("%q has no words to search for", tok.text))
+		}
+		return Text{Value: tok.text, Phrase: tok.kind == tokPhrase}, nil
+	case tokField:
+		return newField(tok)
+	case tokRef:
+		return p.savedSearch(tok)
+	}
+	return nil, errorAt(tok.pos, fmt.Sprintf("expected a search term, found %s", describe(tok)))
+}
+
+// savedSearch parses the query of the saved search a token refers to.
+func (p *parser) savedSearch(tok token) (Node, error) {
+	if p.resolve == nil {
+		return nil, errorAt(tok.pos, "saved searches can't be used here")
+	}
+	name := strings.ToLower(tok.text)
+	if slices.Contains(p.using, name) {
+		return nil, errorAt(tok.pos, fmt.Sprintf("saved search @%s refers to itself", tok.text))
+	}
+
+	input, err := p.resolve(tok.text)
+	if err != nil {
+		return nil, errorAt(tok.pos, err.Error())
+	}
+	n, err := parse(input, p.resolve, slices.Concat(p.using, []string{name}))
+	if err != nil {
+		var qerr *Error
+		if errors.As(err, &qerr) {
+			return nil, errorAt(tok.pos, fmt.Sprintf("in @%s: %s", tok.text, qerr.Msg))
+		}
+		return nil, err
+	}
+	return n, nil
+}
+
+func describe(tok token) string {
+	if tok.kind == tokWord || tok.kind == tokField {
+		return fmt.Sprintf("%q", tok.text)
+	}
+	return tok.kind.String()
+}
+
+// SavedSearches resolves @name references with the searches saved in store.
+func SavedSearches(ctx context.Context, store database.NoteStore) Resolver {
+	return func(name string) (string, error) {
+		s, err := store.SavedSearch(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		if s == nil {
+			return "", fmt.Errorf("no saved search named %q", name)
+		}
+		return s.Query, nil
+	}
+}
@@ -0,0 +1,249 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"apple", "apple"},
+		{"apple pie", "(and apple pie)"},
+		{"apple AND pie", "(and apple pie)"},
+		{`"apple pie" app*`, `(and "apple pie" app*)`},
+		{"tag:work priority:high", "(and tag:work priority:high)"},
+		{"created:>=2026-01-01 id:<10", "(and created:>=2026-01-01 id:<10)"},
+		{`tag:"road trip"`, `tag:"road trip"`},
+		{"-tag:done NOT urgent", "(and (not tag:done) (not urgent))"},
+		{"a b OR c", "(and a (or b c))"},
+		{"(a b) OR c", "(or (and a b) c)"},
+		{"a OR b OR -c", "(or a b (not c))"},
+		{`tag:work priority:high created:>2026-01-01 -tag:done "exact phrase" OR urgent`,
+			`(and tag:work priority:high created:>2026-01-01 (not tag:done) (or "exact phrase" urgent))`},
+		{"e-mail Tag:Work", "(and e-mail tag:Work)"},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got := q.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{"", 0, "empty"},
+		{`apple "pie`, 6, "unterminated quote"},
+		{"(apple pie", 0, `unclosed "("`},
+		{"apple)", 5, `unexpected ")"`},
+		{"apple OR", 8, "expected a search term"},
+		{"AND apple", 0, "AND needs a term on each side"},
+		{"apple - pie", 6, `"-" must come right before`},
+		{"tag:", 0, "tag: needs a value"},
+		{"color:red", 0, `unknown field "color"`},
+		{"priority:urgent", 0, `invalid priority "urgent"`},
+		{"work created:yesterday-ish", 5, `invalid date "yesterday-ish"`},
+		{"id:abc", 0, `invalid note ID "abc"`},
+		{"tag:>work", 0, "can't be compared"},
+		{"!!", 0, "no words"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		var qerr *Error
+		if !errors.As(err, &qerr) {
+			t.Errorf("Parse(%q) error = %v, want a query error", tt.input, err)
+			continue
+		}
+		if qerr.Pos != tt.pos || !strings.Contains(qerr.Msg, tt.msg) {
+			t.Errorf("Parse(%q) error = %v (at %d), want %q at %d", tt.input, err, qerr.Pos, tt.msg, tt.pos)
+		}
+	}
+}
+
+func TestUsesFields(t *testing.T) {
+	tests := map[string]bool{
+		"tag:work deploy":     true,
+		"-Priority:high":      true,
+		"(a OR created:2026)": true,
+		"@inbox":              true,
+		"https://example.com": false,
+		"foo:bar":             false,
+		"email me@":           false,
+		"apple pie":           false,
+	}
+	for input, want := range tests {
+		if got := UsesFields(input); got != want {
+			t.Errorf("UsesFields(%q) = %v, want %v", input, got, want)
+		}
+	}
+
+	if _, err := Parse("tag:work foo:bar"); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("Parse(foo:bar) error = %v, want ErrUnknownField", err)
+	}
+	if _, err := Parse("priority:urgent"); errors.Is(err, ErrUnknownField) {
+		t.Errorf("Parse(priority:urgent) error = %v, want an invalid value", err)
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := map[string]string{
+		`deploy "release notes" tag:work`: `"deploy" "release notes"`,
+		"app* -pie":                       `"app"*`,
+		"a OR b":                          "",
+		"tag:work":                        "",
+	}
+	for input, want := range tests {
+		q, err := Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", input, err)
+		}
+		if got := q.Text(); got != want {
+			t.Errorf("Parse(%q).Text() = %s, want %s", input, got, want)
+		}
+	}
+}
+
+// TestQueries checks that the compiled SQL and matching in memory agree.
+func TestQueries(t *testing.T) {
+	db, err := database.OpenDB(filepath.Join(t.TempDir(), "jot.db"))
+	if err != nil {
+		t.Fatalf("OpenDB() error = %v", err)
+	}
+	defer db.Close()
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	day := func(s string) time.Time {
+		d, _ := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+		return d
+	}
+	due := day("2026-01-05 17:00")
+	notes := []database.NewNote{
+		{Content: "Plan the release notes", Tags: []string{"work"}, Priority: "high", CreatedAt: day("2025-12-31 23:30")},
+		{Content: "Urgent: fix the build", Tags: []string{"work/ci", "done"}, Priority: "medium", Status: database.StatusDone, CreatedAt: day("2026-01-01 09:00")},
+		{Content: "Apple pie recipe", Tags: []string{"food"}, Priority: "low", CreatedAt: day("2026-01-02 12:00"), DueAt: &due},
+		{Content: "Applications are due", Tags: []string{"work_stuff"}, Priority: "low", CreatedAt: day("2026-02-10 08:00")},
+	}
+
+	tests := map[string][]int{
+		"tag:work":                      {1, 2},
+		"tag:work_stuff":                {4},
+		"-tag:done tag:work":            {1},
+		"priority:>=medium":             {1, 2},
+		"priority:<medium":              {3, 4},
+		"priority:>high":                nil,
+		"created:2026-01-01":            {2},
+		"created:>2026-01-01":           {3, 4},
+		"created:<=2026-01-01":          {1, 2},
+		"created:2026-01":               {2, 3},
+		"created:<2026":                 {1},
+		"id:>2":                         {3, 4},
+		"due:2026-01-05":                {3},
+		"due:<2026-02":                  {3},
+		"-due:2026-01":                  {1, 2, 4},
+		"status:done":                   {2},
+		"status:none tag:work":          {1},
+		"apple":                         {3},
+		"app*":                          {3, 4},
+		`"release notes" OR urgent`:     {1, 2},
+		`"notes release"`:               nil,
+		"(tag:food OR tag:work) -build": {1, 3},
+		`tag:work priority:high created:>2025-01-01 -tag:done "release notes" OR urgent`: {1},
+	}
+
+	stores := map[string]database.NoteStore{
+		"sqlite": database.NewSQLiteStore(db),
+		"memory": database.NewMemoryStore(),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, n := range notes {
+				if _, err := store.Add(ctx, n); err != nil {
+					t.Fatalf("Add() error = %v", err)
+				}
+			}
+
+			for input, want := range tests {
+				q, err := Parse(input)
+				if err != nil {
+					t.Fatalf("Parse(%q) error = %v", input, err)
+				}
+				found, err := store.List(ctx, database.NoteFilter{Where: q, Sort: database.SortID})
+				if err != nil {
+					t.Fatalf("List(%q) error = %v", input, err)
+				}
+				var ids []int
+				for _, n := range found {
+					ids = append(ids, n.ID)
+				}
+				if !slices.Equal(ids, want) {
+					t.Errorf("%q matched notes %v, want %v", input, ids, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSavedSearchReferences(t *testing.T) {
+	saved := map[string]string{
+		"work":   "tag:work -tag:done",
+		"urgent": "priority:high OR urgent",
+		"both":   "@work @urgent",
+		"loop":   "tag:a @loop2",
+		"loop2":  "@LOOP",
+		"broken": "tag:",
+	}
+	resolve := func(name string) (string, error) {
+		q, ok := saved[strings.ToLower(name)]
+		if !ok {
+			return "", errors.New("no saved search named " + name)
+		}
+		return q, nil
+	}
+
+	q, err := ParseWith("@both deploy", resolve)
+	if err != nil {
+		t.Fatalf("ParseWith() error = %v", err)
+	}
+	if want := "(and tag:work (not tag:done) (or priority:high urgent) deploy)"; q.String() != want {
+		t.Errorf("ParseWith(@both deploy) = %s, want %s", q, want)
+	}
+	if q.IsPlainText() || q.Text() != `"deploy"` {
+		t.Errorf("Expected a structured query searching for deploy, got plain=%v text=%s", q.IsPlainText(), q.Text())
+	}
+
+	for input, msg := range map[string]string{
+		"x @missing": "no saved search named missing",
+		"@loop":      "in @loop: in @loop2: saved search @LOOP refers to itself",
+		"@broken":    "in @broken: tag: needs a value",
+	} {
+		_, err := ParseWith(input, resolve)
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("ParseWith(%q) error = %v, want %q", input, err, msg)
+		}
+	}
+
+	if _, err := Parse("@work"); err == nil {
+		t.Error("Parse() without a resolver should reject @name")
+	}
+}
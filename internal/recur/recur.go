@@ -0,0 +1,269 @@
+// Package recur parses recurrence rules for repeating tasks and works out
+// when the next occurrence is due. Rules are either simple words, like
+// "weekday", "2 weeks" or "mon,thu", or a subset of iCalendar RRULE:
+// FREQ (DAILY, WEEKLY, MONTHLY or YEARLY), INTERVAL, BYDAY for weekly
+// rules and BYMONTHDAY for monthly and yearly ones.
+package recur
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies of a Rule.
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// Help lists the accepted rules, for error messages and command help.
+const Help = `day, weekday, week, month, year, "2 weeks", "mon,thu" or an RRULE like FREQ=WEEKLY;INTERVAL=2;BYDAY=MO`
+
+// Rule is a parsed recurrence rule.
+type Rule struct {
+	Freq     string
+	Interval int
+	Days     []time.Weekday // weekly rules only; empty means the due date's weekday
+	MonthDay int            // monthly and yearly rules only; 0 means the due date's day
+}
+
+var dayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+var weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// Parse parses a rule in either of its forms.
+func Parse(s string) (Rule, error) {
+	value := strings.TrimSpace(s)
+	if value == "" {
+		return Rule{}, fmt.Errorf("missing recurrence (use %s)", Help)
+	}
+	if strings.Contains(value, "=") {
+		return parseRRule(value)
+	}
+
+	words := strings.Fields(strings.ToLower(value))
+	if len(words) > 0 && words[0] == "every" {
+		words = words[1:]
+	}
+	interval := 1
+	if len(words) == 2 {
+		n, err := strconv.Atoi(words[0])
+		if err != nil || n < 1 {
+			return Rule{}, fmt.Errorf("invalid recurrence %q (use %s)", s, Help)
+		}
+		interval, words = n, words[1:]
+	}
+	if len(words) != 1 {
+		return Rule{}, fmt.Errorf("invalid recurrence %q (use %s)", s, Help)
+	}
+
+	switch strings.TrimSuffix(words[0], "s") {
+	case "day", "daily":
+		return Rule{Freq: Daily, Interval: interval}, nil
+	case "weekday":
+		return Rule{Freq: Weekly, Interval: interval, Days: weekdays}, nil
+	case "week", "weekly":
+		return Rule{Freq: Weekly, Interval: interval}, nil
+	case "month", "monthly":
+		return Rule{Freq: Monthly, Interval: interval}, nil
+	case "year", "yearly":
+		return Rule{Freq: Yearly, Interval: interval}, nil
+	}
+
+	var days []time.Weekday
+	for _, name := range strings.Split(words[0], ",") {
+		d, ok := dayNames[name]
+		if !ok {
+			return Rule{}, fmt.Errorf("invalid recurrence %q (use %s)", s, Help)
+		}
+		days = append(days, d)
+	}
+	return Rule{Freq: Weekly, Interval: interval, Days: sortDays(days)}, nil
+}
+
+func parseRRule(s string) (Rule, error) {
+	r := Rule{Interval: 1}
+	fail := func(msg string) (Rule, error) {
+		return Rule{}, fmt.Errorf("invalid RRULE %q: %s", s, msg)
+	}
+
+	for _, part := range strings.Split(strings.TrimPrefix(strings.ToUpper(s), "RRULE:"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return fail(fmt.Sprintf("%q is not KEY=VALUE", part))
+		}
+		switch key {
+		case "FREQ":
+			switch value {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Freq = value
+			default:
+				return fail("FREQ must be DAILY, WEEKLY, MONTHLY or YEARLY")
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fail("INTERVAL must be a positive number")
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				i := slices.Index(dayCodes, code)
+				if i < 0 {
+					return fail(fmt.Sprintf("unknown day %q", code))
+				}
+				r.Days = append(r.Days, time.Weekday(i))
+			}
+		case "BYMONTHDAY":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 31 {
+				return fail("BYMONTHDAY must be a day from 1 to 31")
+			}
+			r.MonthDay = n
+		default:
+			return fail(fmt.Sprintf("%s isn't supported", key))
+		}
+	}
+
+	if r.Freq == "" {
+		return fail("FREQ is missing")
+	}
+	if len(r.Days) > 0 && r.Freq != Weekly {
+		return fail("BYDAY only works with FREQ=WEEKLY")
+	}
+	if r.MonthDay > 0 && r.Freq != Monthly && r.Freq != Yearly {
+		return fail("BYMONTHDAY only works with FREQ=MONTHLY or FREQ=YEARLY")
+	}
+	r.Days = sortDays(r.Days)
+	return r, nil
+}
+
+// sortDays orders weekdays Monday first and drops duplicates.
+func sortDays(days []time.Weekday) []time.Weekday {
+	slices.SortFunc(days, func(a, b time.Weekday) int {
+		return (int(a)+6)%7 - (int(b)+6)%7
+	})
+	return slices.Compact(days)
+}
+
+// String returns the rule as an RRULE, the form it is stored in.
+func (r Rule) String() string {
+	s := "FREQ=" + r.Freq
+	if r.Interval > 1 {
+		s += ";INTERVAL=" + strconv.Itoa(r.Interval)
+	}
+	if len(r.Days) > 0 {
+		codes := make([]string, len(r.Days))
+		for i, d := range r.Days {
+			codes[i] = dayCodes[d]
+		}
+		s += ";BYDAY=" + strings.Join(codes, ",")
+	}
+	if r.MonthDay > 0 {
+		s += ";BYMONTHDAY=" + strconv.Itoa(r.MonthDay)
+	}
+	return s
+}
+
+// Describe returns the rule in words, e.g. "every 2 weeks on Mon, Thu".
+func (r Rule) Describe() string {
+	if r.Freq == Weekly && slices.Equal(r.Days, weekdays) && r.Interval == 1 {
+		return "every weekday"
+	}
+
+	unit := map[string]string{Daily: "day", Weekly: "week", Monthly: "month", Yearly: "year"}[r.Freq]
+	s := "every " + unit
+	if r.Interval > 1 {
+		s = fmt.Sprintf("every %d %ss", r.Interval, unit)
+	}
+	if len(r.Days) > 0 {
+		names := make([]string, len(r.Days))
+		for i, d := range r.Days {
+			names[i] = d.String()[:3]
+		}
+		s += " on " + strings.Join(names, ", ")
+	}
+	if r.MonthDay > 0 {
+		s += fmt.Sprintf(" on day %d", r.MonthDay)
+	}
+	return s
+}
+
+// Next returns the first occurrence after t, keeping t's time of day.
+func (r Rule) Next(t time.Time) time.Time {
+	switch r.Freq {
+	case Daily:
+		return t.AddDate(0, 0, r.Interval)
+	case Weekly:
+		if len(r.Days) == 0 {
+			return t.AddDate(0, 0, 7*r.Interval)
+		}
+		// The next listed day in t's week, or the first one Interval weeks on
+		for d := t.AddDate(0, 0, 1); ; d = d.AddDate(0, 0, 1) {
+			if d.Weekday() == time.Monday {
+				d = d.AddDate(0, 0, 7*(r.Interval-1))
+			}
+			if slices.Contains(r.Days, d.Weekday()) {
+				return d
+			}
+		}
+	case Monthly:
+		return addMonths(t, r.Interval, r.day(t))
+	case Yearly:
+		return addMonths(t, 12*r.Interval, r.day(t))
+	}
+	return t
+}
+
+// NextAfter returns the first occurrence after due that is also after now,
+// so a task completed late isn't followed by ones already overdue.
+func (r Rule) NextAfter(due, now time.Time) time.Time {
+	r = r.Anchor(due)
+	next := r.Next(due)
+	for !next.After(now) {
+		next = r.Next(next)
+	}
+	return next
+}
+
+// Anchor pins a monthly or yearly rule to due's day of the month, so an
+// occurrence moved to the end of a short month doesn't move the ones after
+// it: the 31st stays the 31st after February. Rules that already name a day,
+// and days every month has, are returned as they are.
+func (r Rule) Anchor(due time.Time) Rule {
+	if (r.Freq == Monthly || r.Freq == Yearly) && r.MonthDay == 0 && due.Day() > 28 {
+		r.MonthDay = due.Day()
+	}
+	return r
+}
+
+// day is the day of the month an occurrence after t falls on.
+func (r Rule) day(t time.Time) int {
+	if r.MonthDay > 0 {
+		return r.MonthDay
+	}
+	return t.Day()
+}
+
+// addMonths moves t months on to the given day, keeping to the last day of
+// shorter months rather than spilling over into the next one.
+func addMonths(t time.Time, months, day int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, last)-1)
+}
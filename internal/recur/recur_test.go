@@ -0,0 +1,105 @@
+package recur
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input, rrule, describe string
+	}{
+		{"day", "FREQ=DAILY", "every day"},
+		{"every weekday", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", "every weekday"},
+		{"Week", "FREQ=WEEKLY", "every week"},
+		{"2 weeks", "FREQ=WEEKLY;INTERVAL=2", "every 2 weeks"},
+		{"monthly", "FREQ=MONTHLY", "every month"},
+		{"3 years", "FREQ=YEARLY;INTERVAL=3", "every 3 years"},
+		{"thu,mon", "FREQ=WEEKLY;BYDAY=MO,TH", "every week on Mon, Thu"},
+		{"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", "every 2 weeks on Fri"},
+		{"freq=daily;interval=3", "FREQ=DAILY;INTERVAL=3", "every 3 days"},
+		{"FREQ=MONTHLY;BYMONTHDAY=31", "FREQ=MONTHLY;BYMONTHDAY=31", "every month on day 31"},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.input, err)
+			continue
+		}
+		if r.String() != tt.rrule || r.Describe() != tt.describe {
+			t.Errorf("Parse(%q) = %s (%s), want %s (%s)", tt.input, r, r.Describe(), tt.rrule, tt.describe)
+		}
+		if again, err := Parse(r.String()); err != nil || again.String() != r.String() {
+			t.Errorf("Parse(%q) doesn't round-trip: %v, %v", r.String(), again, err)
+		}
+	}
+
+	for _, input := range []string{"", "fortnight", "0 days", "FREQ=HOURLY", "FREQ=DAILY;BYDAY=MO", "FREQ=WEEKLY;COUNT=3", "INTERVAL=2", "FREQ=WEEKLY;BYMONTHDAY=3", "FREQ=MONTHLY;BYMONTHDAY=32"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) should fail", input)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	at := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatalf("bad test time %q: %v", s, err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		rule, from, want string
+	}{
+		{"day", "2026-03-18 09:00", "2026-03-19 09:00"},
+		{"weekday", "2026-03-20 09:00", "2026-03-23 09:00"}, // Friday to Monday
+		{"weekday", "2026-03-18 09:00", "2026-03-19 09:00"},
+		{"week", "2026-03-18 16:00", "2026-03-25 16:00"},
+		{"mon,thu", "2026-03-17 10:00", "2026-03-19 10:00"},
+		{"mon,thu", "2026-03-19 10:00", "2026-03-23 10:00"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", "2026-03-20 10:00", "2026-03-30 10:00"},
+		{"month", "2026-01-31 09:00", "2026-02-28 09:00"},
+		{"month", "2026-03-15 09:00", "2026-04-15 09:00"},
+		{"year", "2028-02-29 09:00", "2029-02-28 09:00"},
+		{"FREQ=MONTHLY;BYMONTHDAY=31", "2026-02-28 09:00", "2026-03-31 09:00"},
+		{"FREQ=MONTHLY;BYMONTHDAY=31", "2026-03-31 09:00", "2026-04-30 09:00"},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.rule, err)
+		}
+		if got := r.Next(at(tt.from)).Format("2006-01-02 15:04"); got != tt.want {
+			t.Errorf("%s: Next(%s) = %s, want %s", tt.rule, tt.from, got, tt.want)
+		}
+	}
+
+	daily, _ := Parse("day")
+	if got := daily.NextAfter(at("2026-03-10 09:00"), at("2026-03-18 12:00")); !got.Equal(at("2026-03-19 09:00")) {
+		t.Errorf("NextAfter() = %v, want the first occurrence after now", got)
+	}
+
+	// A rule anchored on the 31st comes back to it after shorter months
+	monthly, _ := Parse("month")
+	anchored := monthly.Anchor(at("2026-01-31 09:00"))
+	if anchored.String() != "FREQ=MONTHLY;BYMONTHDAY=31" {
+		t.Errorf("Anchor() = %s, want the 31st", anchored)
+	}
+	var got []string
+	for d := at("2026-01-31 09:00"); len(got) < 3; {
+		d = anchored.Next(d)
+		got = append(got, d.Format("2006-01-02"))
+	}
+	if want := "2026-02-28 2026-03-31 2026-04-30"; strings.Join(got, " ") != want {
+		t.Errorf("Occurrences = %v, want %s", got, want)
+	}
+	if next := monthly.NextAfter(at("2026-01-31 09:00"), at("2026-04-01 00:00")); !next.Equal(at("2026-04-30 09:00")) {
+		t.Errorf("NextAfter() = %v, want the 30th of April", next)
+	}
+	if mid := monthly.Anchor(at("2026-01-15 09:00")); mid.MonthDay != 0 {
+		t.Errorf("Anchor() on the 15th = %s, want no day", mid)
+	}
+}
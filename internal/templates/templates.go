@@ -0,0 +1,110 @@
+// Package templates renders note skeletons, such as meeting notes or bug
+// reports, from Go text/template files in a directory.
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Vars are the values a template can use, e.g. {{.Title}} or
+// {{.Now.Format "Jan 2"}}.
+type Vars struct {
+	Title  string
+	Date   string // 2006-01-02
+	Time   string // 15:04
+	Branch string // the current git branch, or "" outside a repository
+	Now    time.Time
+}
+
+// NewVars returns the variables for a note with title created at now.
+func NewVars(title string, now time.Time) Vars {
+	return Vars{
+		Title:  title,
+		Date:   now.Format("2006-01-02"),
+		Time:   now.Format("15:04"),
+		Branch: GitBranch(),
+		Now:    now,
+	}
+}
+
+// GitBranch returns the branch checked out in the working directory, or ""
+// if it isn't in a git repository.
+func GitBranch() string {
+	out, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// List returns the names of the templates in dir: its files without their
+// extensions. A missing directory has none.
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read templates: %v", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())))
+	}
+	slices.Sort(names)
+	return slices.Compact(names), nil
+}
+
+// Load returns the text of the template called name in dir, whatever its
+// extension.
+func Load(dir, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid template name %q", name)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("could not read templates: %v", err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() && strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())) == name {
+			data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+			if err != nil {
+				return "", fmt.Errorf("could not read template %q: %v", name, err)
+			}
+			return string(data), nil
+		}
+	}
+	return "", fmt.Errorf("no template named %q in %s", name, dir)
+}
+
+// UsesTitle reports whether a template needs a title, so it is only asked
+// for when it will be used.
+func UsesTitle(text string) bool {
+	return strings.Contains(text, ".Title")
+}
+
+// Render fills in a template's variables.
+func Render(name, text string, vars Vars) (string, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("could not parse template %q: %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("could not render template %q: %v", name, err)
+	}
+	return buf.String(), nil
+}
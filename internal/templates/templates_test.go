@@ -0,0 +1,63 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	for name, text := range map[string]string{
+		"meeting.md":  "# {{.Title}}",
+		"bug.tmpl":    "## Steps",
+		".hidden.md":  "secret",
+		"standup.txt": "Yesterday:",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := List(dir)
+	if err != nil || !slices.Equal(names, []string{"bug", "meeting", "standup"}) {
+		t.Errorf("List() = %v, %v", names, err)
+	}
+	if names, err := List(filepath.Join(dir, "missing")); err != nil || names != nil {
+		t.Errorf("List(missing dir) = %v, %v, want none", names, err)
+	}
+
+	if text, err := Load(dir, "bug"); err != nil || text != "## Steps" {
+		t.Errorf("Load(bug) = %q, %v", text, err)
+	}
+	for _, name := range []string{"retro", "../meeting", ".hidden", ""} {
+		if _, err := Load(dir, name); err == nil {
+			t.Errorf("Load(%q) should fail", name)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	now := time.Date(2026, time.March, 18, 15, 30, 0, 0, time.UTC)
+	vars := Vars{Title: "Sprint planning", Date: "2026-03-18", Time: "15:30", Branch: "main", Now: now}
+
+	got, err := Render("meeting", `# {{.Title}}
+{{.Date}} {{.Time}} on {{.Now.Format "Monday"}}{{if .Branch}} ({{.Branch}}){{end}}`, vars)
+	want := "# Sprint planning\n2026-03-18 15:30 on Wednesday (main)"
+	if err != nil || got != want {
+		t.Errorf("Render() = %q, %v, want %q", got, err, want)
+	}
+
+	for _, text := range []string{"{{.Title", "{{.Author}}"} {
+		if _, err := Render("bad", text, vars); err == nil || !strings.Contains(err.Error(), `template "bad"`) {
+			t.Errorf("Render(%q) error = %v", text, err)
+		}
+	}
+
+	if !UsesTitle("# {{ .Title }}") || UsesTitle("# {{.Date}}") {
+		t.Error("UsesTitle() should only spot templates with a title")
+	}
+}
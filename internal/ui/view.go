@@ -1,18 +1,25 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
-	"github.com/flyme2mars/jotcli/internal/config"
-	"github.com/flyme2mars/jotcli/internal/database"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/flyme2mars/jotcli/internal/config"
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/markdown"
+	"github.com/flyme2mars/jotcli/internal/query"
+	"github.com/flyme2mars/jotcli/internal/recur"
+	"github.com/flyme2mars/jotcli/internal/templates"
 )
 
 var (
@@ -22,7 +29,9 @@ var (
 	normalStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
 	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
 	previewStyle  = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1).BorderForeground(lipgloss.Color("240"))
-	
+	sidebarStyle  = lipgloss.NewStyle().Border(lipgloss.NormalBorder(), false, true, false, false).BorderForeground(lipgloss.Color("240")).PaddingRight(1).MarginRight(1)
+	matchStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+
 	// Textarea styling
 	textAreaStyle = lipgloss.NewStyle().
 			Border(lipgloss.NormalBorder()).
@@ -34,7 +43,7 @@ var (
 			Foreground(lipgloss.AdaptiveColor{Light: "#343433", Dark: "#C1C6B2"}).
 			Background(lipgloss.AdaptiveColor{Light: "#D9DCCF", Dark: "#353533"}).
 			MarginTop(1)
-	
+
 	statusKey = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#FFFDF5")).
 			Background(lipgloss.Color("#FF5F87")).
@@ -42,6 +51,10 @@ var (
 			MarginRight(1)
 )
 
+// sortModes are cycled with "s". The empty mode means newest first, or best
+// match first while searching.
+var sortModes = append([]string{""}, database.SortFields...)
+
 type editFinishedMsg struct{ err error }
 
 type mode int
@@ -53,21 +66,57 @@ const (
 )
 
 type model struct {
+	ctx   context.Context
+	store database.NoteStore
+
 	notes       []database.Note
 	cursor      int
 	err         error
 	quitting    bool
 	editingFile string
 	editingID   int
-	
+
 	mode        mode
 	textArea    textarea.Model
 	searchInput textinput.Model
+	snippets    map[int]string // note ID -> highlighted search excerpt
+	deleted     []int          // IDs moved to the trash this session, for undo
+	status      string
+
+	sortIndex int // index into sortModes
+	reverse   bool
+
+	// Revision browsing for the selected note; revIndex is -1 when the
+	// preview shows the current content.
+	revisions []database.Revision
+	revIndex  int
+
+	// item is the checklist item of the selected note that space toggles.
+	item int
+
+	// link is the [[link]] in the selected note that enter follows; trail
+	// holds the notes followed from, for going back.
+	link  int
+	trail []int
+
+	// The template ctrl+t last filled the new note with, -1 for none, and the
+	// title typed before picking it.
+	tmplIndex int
+	tmplTitle string
+
+	// Saved searches in the sidebar; active is -1 while showing all notes.
+	saved  []database.SavedSearch
+	active int
 }
 
-func InitialModel() model {
-	notes, err := database.GetNotes("")
-	
+// NewModel returns the interactive view over the notes in store.
+func NewModel(ctx context.Context, store database.NoteStore) model {
+	notes, err := store.List(ctx, database.NoteFilter{})
+	var saved []database.SavedSearch
+	if err == nil {
+		saved, err = store.SavedSearches(ctx)
+	}
+
 	ta := textarea.New()
 	ta.Placeholder = "What's on your mind?..."
 	ta.SetWidth(60)
@@ -77,17 +126,231 @@ func InitialModel() model {
 	ta.ShowLineNumbers = false
 
 	si := textinput.New()
-	si.Placeholder = "Search notes..."
+	si.Placeholder = "Search notes, e.g. meeting tag:work -tag:done..."
 	si.Prompt = " / "
 	si.Focus()
 
 	return model{
+		ctx:         ctx,
+		store:       store,
 		notes:       notes,
 		cursor:      0,
 		err:         err,
 		mode:        modeList,
 		textArea:    ta,
 		searchInput: si,
+		revIndex:    -1,
+		tmplIndex:   -1,
+		saved:       saved,
+		active:      -1,
+	}
+}
+
+// RenderSnippet styles the matched terms in a search snippet.
+func RenderSnippet(snippet string) string {
+	var s strings.Builder
+	for {
+		start := strings.Index(snippet, database.HighlightStart)
+		if start == -1 {
+			break
+		}
+		end := strings.Index(snippet[start:], database.HighlightEnd)
+		if end == -1 {
+			break
+		}
+		end += start
+		s.WriteString(snippet[:start])
+		s.WriteString(matchStyle.Render(snippet[start+len(database.HighlightStart) : end]))
+		snippet = snippet[end+len(database.HighlightEnd):]
+	}
+	s.WriteString(snippet)
+	return strings.NewReplacer(database.HighlightStart, "", database.HighlightEnd, "").Replace(s.String())
+}
+
+// refresh reloads the note list, applying the active saved search and the
+// current search query if any.
+func (m *model) refresh() {
+	m.resetRevisions()
+
+	input := m.searchInput.Value()
+	filter := database.NoteFilter{Sort: sortModes[m.sortIndex], Reverse: m.reverse}
+
+	var base *query.Query
+	if m.active >= 0 {
+		var err error
+		base, err = query.ParseWith("@"+m.saved[m.active].Name, query.SavedSearches(m.ctx, m.store))
+		if err != nil {
+			m.status = err.Error()
+			return
+		}
+		filter.Where = base
+	}
+
+	if strings.TrimSpace(input) == "" {
+		m.notes, m.err = m.store.List(m.ctx, filter)
+		m.snippets = nil
+	} else {
+		// Plain searches match the word being typed as a prefix; queries
+		// with fields go through the query language
+		text := database.PrefixQuery(input)
+		q, err := query.ParseWith(input, query.SavedSearches(m.ctx, m.store))
+		if err != nil && (errors.Is(err, query.ErrUnknownField) || !query.UsesFields(input)) {
+			// Not a query after all, e.g. a URL: search for the text as typed
+			q, err = nil, nil
+		}
+		if err != nil {
+			m.status = err.Error()
+			return
+		}
+		if q != nil && !q.IsPlainText() {
+			text = q.Text()
+			if base != nil {
+				q = &query.Query{Root: query.And{Nodes: []query.Node{base.Root, q.Root}}}
+			}
+			filter.Where = q
+		}
+
+		results, err := m.store.Search(m.ctx, text, filter)
+		m.err = err
+		m.notes = make([]database.Note, len(results))
+		m.snippets = make(map[int]string, len(results))
+		for i, r := range results {
+			m.notes[i] = r.Note
+			if r.Snippet != "" {
+				m.snippets[r.ID] = r.Snippet
+			}
+		}
+	}
+
+	if m.cursor >= len(m.notes) {
+		m.cursor = len(m.notes) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// selectSaved shows the notes of a saved search, or all notes for -1.
+func (m *model) selectSaved(i int) {
+	m.active = i
+	m.cursor = 0
+	if i < 0 {
+		m.status = "All notes"
+	} else {
+		m.status = "@" + m.saved[i].Name + ": " + m.saved[i].Query
+	}
+	m.refresh()
+}
+
+// nextTemplate fills the new note with the next template, rendered with
+// what was typed before picking the first one as its title.
+func (m *model) nextTemplate() {
+	dir := config.GetTemplatesDir()
+	names, err := templates.List(dir)
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	if len(names) == 0 {
+		m.status = "No templates in " + dir
+		return
+	}
+
+	if m.tmplIndex == -1 {
+		m.tmplTitle = markdown.FirstLine(m.textArea.Value())
+	}
+	m.tmplIndex = (m.tmplIndex + 1) % len(names)
+	name := names[m.tmplIndex]
+	text, err := templates.Load(dir, name)
+	if err == nil {
+		text, err = templates.Render(name, text, templates.NewVars(m.tmplTitle, time.Now()))
+	}
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.textArea.SetValue(text)
+	m.status = fmt.Sprintf("Template %s (%d/%d) · ctrl+t: Next • ctrl+s: Save • esc: Cancel", name, m.tmplIndex+1, len(names))
+}
+
+// followLink shows the note the selected [[link]] leads to, remembering
+// the current one for going back.
+func (m *model) followLink() {
+	note := m.notes[m.cursor]
+	links, err := m.store.Links(m.ctx, note.ID)
+	if err != nil {
+		m.err = err
+		return
+	}
+	if len(links) == 0 {
+		m.status = "No links in this note"
+		return
+	}
+	l := links[min(m.link, len(links)-1)]
+	if l.TargetID == 0 {
+		m.status = "[[" + l.Target + "]] doesn't lead to a note"
+		return
+	}
+	m.trail = append(m.trail, note.ID)
+	m.showNote(l.TargetID)
+}
+
+// showNote selects a note, clearing the search and saved search if they
+// hide it.
+func (m *model) showNote(id int) {
+	find := func() bool {
+		for i, n := range m.notes {
+			if n.ID == id {
+				m.cursor = i
+				return true
+			}
+		}
+		return false
+	}
+	if !find() {
+		m.searchInput.SetValue("")
+		m.active = -1
+		m.refresh()
+		if !find() {
+			m.status = fmt.Sprintf("Note %d is no longer there", id)
+			return
+		}
+	}
+	m.resetRevisions()
+	m.item, m.link = 0, 0
+	if len(m.trail) > 0 {
+		m.status = fmt.Sprintf("Note %d · b: back", id)
+	}
+}
+
+// resetRevisions returns the preview to the selected note's current content.
+func (m *model) resetRevisions() {
+	m.revisions = nil
+	m.revIndex = -1
+}
+
+// stepRevision moves the preview delta revisions back (negative) or forward
+// (positive) through the selected note's history.
+func (m *model) stepRevision(delta int) {
+	if len(m.notes) == 0 {
+		return
+	}
+	if m.revisions == nil {
+		revisions, err := m.store.Revisions(m.ctx, m.notes[m.cursor].ID)
+		if err != nil {
+			m.err = err
+			return
+		}
+		m.revisions = revisions
+		m.revIndex = len(revisions) - 1
+	}
+	if len(m.revisions) == 0 {
+		return
+	}
+
+	m.revIndex = max(0, min(m.revIndex+delta, len(m.revisions)-1))
+	if m.revIndex == len(m.revisions)-1 {
+		m.revIndex = -1
 	}
 }
 
@@ -104,15 +367,22 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "ctrl+s":
 				content := strings.TrimSpace(m.textArea.Value())
 				if content != "" {
-					database.AddNote(content, "inbox", "low")
-					m.notes, m.err = database.GetNotes(m.searchInput.Value())
+					m.store.Add(m.ctx, database.NewNote{Content: content, Tags: []string{"inbox"}, Priority: "low"})
+					m.refresh()
 				}
 				m.mode = modeList
 				m.textArea.Reset()
+				m.tmplIndex, m.tmplTitle = -1, ""
+				m.status = ""
 				return m, nil
 			case "esc":
 				m.mode = modeList
 				m.textArea.Reset()
+				m.tmplIndex, m.tmplTitle = -1, ""
+				m.status = ""
+				return m, nil
+			case "ctrl+t":
+				m.nextTemplate()
 				return m, nil
 			}
 		}
@@ -135,8 +405,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var cmd tea.Cmd
 		m.searchInput, cmd = m.searchInput.Update(msg)
 		// Perform search on every keystroke
-		m.notes, m.err = database.GetNotesBySearch(m.searchInput.Value())
 		m.cursor = 0 // Reset cursor when searching
+		m.status = ""
+		m.refresh()
 		return m, cmd
 	}
 
@@ -154,15 +425,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		content := strings.TrimSpace(string(updatedContent))
 		if content != "" {
-			database.UpdateNote(m.editingID, content)
+			m.store.Update(m.ctx, m.editingID, content)
 		}
 		os.Remove(m.editingFile)
 		m.editingFile = ""
 		m.editingID = 0
-		m.notes, m.err = database.GetNotes(m.searchInput.Value())
+		m.refresh()
 		return m, nil
 
 	case tea.KeyMsg:
+		m.status = ""
 		switch msg.String() {
 		case "ctrl+c", "q":
 			m.quitting = true
@@ -170,10 +442,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "up", "k":
 			if m.cursor > 0 {
 				m.cursor--
+				m.resetRevisions()
+				m.item, m.link = 0, 0
 			}
 		case "down", "j":
 			if m.cursor < len(m.notes)-1 {
 				m.cursor++
+				m.resetRevisions()
+				m.item, m.link = 0, 0
 			}
 		case "/":
 			m.mode = modeSearch
@@ -201,19 +477,119 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return editFinishedMsg{err}
 				})
 			}
-		case "delete", "x", "backspace":
+		case "delete", "x":
 			if len(m.notes) > 0 {
 				note := m.notes[m.cursor]
-				err := database.DeleteNote(note.ID)
+				err := m.store.Delete(m.ctx, note.ID)
 				if err != nil {
 					m.err = err
 					return m, nil
 				}
-				m.notes, m.err = database.GetNotesBySearch(m.searchInput.Value())
-				if m.cursor >= len(m.notes) && m.cursor > 0 {
-					m.cursor = len(m.notes) - 1
+				m.deleted = append(m.deleted, note.ID)
+				m.status = fmt.Sprintf("Note %d moved to trash (u to undo)", note.ID)
+				m.refresh()
+			}
+		case "s":
+			m.sortIndex = (m.sortIndex + 1) % len(sortModes)
+			if sortModes[m.sortIndex] == "" {
+				m.status = "Default sort order"
+			} else {
+				m.status = "Sorted by " + sortModes[m.sortIndex]
+			}
+			m.refresh()
+		case "S":
+			m.reverse = !m.reverse
+			m.status = "Sort order reversed"
+			m.refresh()
+		case "tab":
+			if len(m.saved) > 0 {
+				m.selectSaved((m.active+2)%(len(m.saved)+1) - 1)
+			}
+		case "0", "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			if i := int(msg.String()[0]-'0') - 1; len(m.saved) > 0 && i < len(m.saved) {
+				m.selectSaved(i)
+			}
+		case "c":
+			if len(m.notes) > 0 {
+				if items := markdown.Checklist(m.notes[m.cursor].Content); len(items) > 0 {
+					m.item = (m.item + 1) % len(items)
 				}
 			}
+		case "l":
+			if len(m.notes) > 0 {
+				if links := markdown.Links(m.notes[m.cursor].Content); len(links) > 0 {
+					m.link = (m.link + 1) % len(links)
+				}
+			}
+		case "enter":
+			if len(m.notes) > 0 && m.revIndex < 0 {
+				m.followLink()
+			}
+		case "backspace", "b":
+			if len(m.trail) > 0 {
+				id := m.trail[len(m.trail)-1]
+				m.trail = m.trail[:len(m.trail)-1]
+				m.showNote(id)
+			}
+		case " ":
+			if len(m.notes) > 0 && m.revIndex < 0 {
+				note := m.notes[m.cursor]
+				items := markdown.Checklist(note.Content)
+				if len(items) == 0 {
+					m.status = "No checklist items in this note"
+					return m, nil
+				}
+				m.item = min(m.item, len(items)-1)
+				content, _ := markdown.ToggleItem(note.Content, m.item)
+				if err := m.store.Update(m.ctx, note.ID, content); err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.refresh()
+			}
+		case "d":
+			if len(m.notes) > 0 {
+				note := m.notes[m.cursor]
+				status := database.StatusDone
+				if note.Status == database.StatusDone {
+					status = database.StatusOpen
+				}
+				next, err := m.store.SetStatus(m.ctx, note.ID, status)
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.status = fmt.Sprintf("Note %d is %s", note.ID, status)
+				if next > 0 {
+					m.status += fmt.Sprintf(" · next one is note %d", next)
+				}
+				m.refresh()
+			}
+		case "[":
+			m.stepRevision(-1)
+		case "]":
+			m.stepRevision(1)
+		case "R":
+			if m.revIndex >= 0 {
+				r := m.revisions[m.revIndex]
+				if err := m.store.Revert(m.ctx, r.NoteID, r.Rev); err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.status = fmt.Sprintf("Note %d reverted to revision %d", r.NoteID, r.Rev)
+				m.refresh()
+			}
+		case "u":
+			if len(m.deleted) > 0 {
+				id := m.deleted[len(m.deleted)-1]
+				m.deleted = m.deleted[:len(m.deleted)-1]
+				if err := m.store.Restore(m.ctx, id); err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.status = fmt.Sprintf("Note %d restored", id)
+				m.refresh()
+			}
 		}
 	}
 	return m, nil
@@ -235,17 +611,19 @@ func (m model) View() string {
 			"%s\n\n%s\n\n%s",
 			titleStyle.Render("--- New Entry ---"),
 			textAreaStyle.Render(m.textArea.View()),
-			"(esc to cancel • ctrl+s to save)",
+			"(esc to cancel • ctrl+s to save • ctrl+t for a template)",
 		)
 	} else {
 		var s strings.Builder
-		
+
 		// Header area: Title or Search
 		if m.mode == modeSearch {
 			s.WriteString(titleStyle.Render("--- Searching ---") + "\n\n")
 			s.WriteString(m.searchInput.View() + "\n\n")
 		} else if m.searchInput.Value() != "" {
 			s.WriteString(titleStyle.Render("--- Filtering: "+m.searchInput.Value()+" ---") + "\n\n")
+		} else if m.active >= 0 {
+			s.WriteString(titleStyle.Render("--- @"+m.saved[m.active].Name+" ---") + "\n\n")
 		} else {
 			s.WriteString(titleStyle.Render("--- Your Notes ---") + "\n\n")
 		}
@@ -260,6 +638,15 @@ func (m model) View() string {
 				if len(displayContent) > 60 {
 					displayContent = displayContent[:57] + "..."
 				}
+				if snippet, ok := m.snippets[note.ID]; ok {
+					displayContent = RenderSnippet(strings.ReplaceAll(snippet, "\n", " "))
+				}
+				if mark := database.StatusMarks[note.Status]; mark != "" {
+					displayContent = mark + " " + displayContent
+				}
+				if done, total := markdown.Progress(note.Content); total > 0 {
+					displayContent += fmt.Sprintf(" (%d/%d)", done, total)
+				}
 
 				if m.cursor == i {
 					cursor = "> "
@@ -271,24 +658,80 @@ func (m model) View() string {
 
 			selectedNote := m.notes[m.cursor]
 			previewContent := strings.ReplaceAll(selectedNote.Content, "\\n", "\n")
+			if due := selectedNote.DueAt; due != nil {
+				label := "Due " + due.Format("Mon 2 Jan 2006 15:04")
+				if due.Before(time.Now()) {
+					label += " (overdue)"
+				}
+				if rule, err := recur.Parse(selectedNote.Recurrence); err == nil {
+					label += " · repeats " + rule.Describe()
+				}
+				s.WriteString("\n" + label)
+			}
+			if items := markdown.Checklist(selectedNote.Content); len(items) > 0 && m.revIndex < 0 {
+				done, _ := markdown.Progress(selectedNote.Content)
+				item := items[min(m.item, len(items)-1)]
+				s.WriteString(fmt.Sprintf("\nChecklist %d/%d · ▸ %s · c: next item · space: toggle", done, len(items), item.Text))
+			}
+			if links := markdown.Links(selectedNote.Content); len(links) > 0 && m.revIndex < 0 {
+				s.WriteString(fmt.Sprintf("\nLinks · ▸ [[%s]] · l: next link · enter: follow", links[min(m.link, len(links)-1)]))
+			}
+			if m.revIndex >= 0 {
+				r := m.revisions[m.revIndex]
+				previewContent = strings.ReplaceAll(r.Content, "\\n", "\n")
+				s.WriteString(fmt.Sprintf("\nRevision %d of %d · saved %s · R to revert", r.Rev, len(m.revisions), r.CreatedAt.Format("2006-01-02 15:04")))
+			}
 			rendered, _ := glamour.Render(previewContent, "dark")
 			s.WriteString("\n" + previewStyle.Render(rendered))
 		}
-		
+
 		content = s.String()
+		if len(m.saved) > 0 {
+			content = lipgloss.JoinHorizontal(lipgloss.Top, m.sidebarView(), content)
+		}
 	}
 
 	// Status Bar
 	var help string
 	if m.mode == modeInput {
-		help = "ENTER: New Line • CTRL+S: Save • ESC: Cancel"
+		help = "ENTER: New Line • CTRL+T: Template • CTRL+S: Save • ESC: Cancel"
 	} else if m.mode == modeSearch {
 		help = "TYPE: Search • ENTER/ESC: Done"
 	} else {
-		help = "n: New • /: Search • e: Edit • x: Delete • j/k: Nav • q: Quit"
+		help = "n: New • /: Search • e: Edit • x: Delete • u: Undo • d: Done • s/S: Sort • [/]: History • j/k: Nav • q: Quit"
+		if len(m.saved) > 0 {
+			help = "tab/0-9: Saved • " + help
+		}
 	}
-	
+	if m.status != "" {
+		help = m.status
+	}
+
 	statusBar := statusBarStyle.Render(statusKey.Render(" JOTCLI ") + help)
 
 	return content + "\n" + statusBar
 }
+
+// sidebarView lists the saved searches, numbered for their shortcut keys.
+func (m model) sidebarView() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Saved") + "\n\n")
+
+	entries := []string{"All notes"}
+	for _, ss := range m.saved {
+		entries = append(entries, "@"+ss.Name)
+	}
+	for i, entry := range entries {
+		key := " "
+		if i < 10 {
+			key = fmt.Sprintf("%d", i)
+		}
+		line := fmt.Sprintf("%s %s", key, entry)
+		if i-1 == m.active {
+			s.WriteString(selectedStyle.Render(line) + "\n")
+		} else {
+			s.WriteString(normalStyle.Render(line) + "\n")
+		}
+	}
+	return sidebarStyle.Render(s.String())
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"context"
+	"testing"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+)
+
+func TestFilterSearchesTextThatLooksLikeFields(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	for _, content := range []string{"Read https://example.com later", "Set foo:bar in the config", "Unrelated"} {
+		if _, err := store.Add(ctx, database.NewNote{Content: content, Priority: "low"}); err != nil {
+			t.Fatalf("Add(%q) error = %v", content, err)
+		}
+	}
+
+	m := NewModel(ctx, store)
+	for input, want := range map[string]int{
+		"https://example.com": 1,
+		"foo:bar":             2,
+		"tag:work foo:bar":    0,
+	} {
+		m.status = ""
+		m.searchInput.SetValue(input)
+		m.refresh()
+		if m.status != "" {
+			t.Errorf("Filtering by %q reported %q", input, m.status)
+		}
+		if want == 0 {
+			if len(m.notes) != 0 {
+				t.Errorf("Filtering by %q found %d notes, want none", input, len(m.notes))
+			}
+			continue
+		}
+		if len(m.notes) != 1 || m.notes[0].ID != want {
+			t.Errorf("Filtering by %q found %+v, want note %d", input, m.notes, want)
+		}
+	}
+
+	// A mistake in a real field is still reported
+	m.searchInput.SetValue("priority:urgent")
+	m.refresh()
+	if m.status == "" {
+		t.Error("Expected an error for an invalid priority")
+	}
+}
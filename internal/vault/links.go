@@ -0,0 +1,47 @@
+package vault
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// jotLinkPattern matches jot's note links, [[12]], optionally followed by a
+// heading (#...) or alias (|...) the way Obsidian writes them.
+var jotLinkPattern = regexp.MustCompile(`\[\[(\d+)([#|][^\]]*)?\]\]`)
+
+// wikilinkPattern matches any Obsidian wikilink, [[name]], [[folder/name]],
+// [[name#heading]] or [[name|alias]].
+var wikilinkPattern = regexp.MustCompile(`\[\[([^\[\]|#]+)([#|][^\]]*)?\]\]`)
+
+// toVault rewrites jot links to the file names of the linked notes, leaving
+// links to notes that aren't in the vault untouched.
+func toVault(content string, stems map[int]string) string {
+	return jotLinkPattern.ReplaceAllStringFunc(content, func(link string) string {
+		m := jotLinkPattern.FindStringSubmatch(link)
+		id, _ := strconv.Atoi(m[1])
+		stem, ok := stems[id]
+		if !ok {
+			return link
+		}
+		return "[[" + stem + m[2] + "]]"
+	})
+}
+
+// fromVault rewrites wikilinks to files in the vault folder as jot links.
+// ids maps lower-case file names, without the .md extension, to note IDs.
+func fromVault(content string, ids map[string]int) string {
+	return wikilinkPattern.ReplaceAllStringFunc(content, func(link string) string {
+		m := wikilinkPattern.FindStringSubmatch(link)
+		name := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(m[1]), ".md"))
+		id, ok := ids[name]
+		if !ok {
+			id, ok = ids[path.Base(name)]
+		}
+		if !ok {
+			return link
+		}
+		return "[[" + strconv.Itoa(id) + m[2] + "]]"
+	})
+}
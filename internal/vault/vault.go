@@ -0,0 +1,541 @@
+// Package vault keeps jot's notes in sync with a folder of an Obsidian
+// vault, in both directions.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+	"github.com/flyme2mars/jotcli/internal/export"
+	"github.com/flyme2mars/jotcli/internal/importer"
+	"github.com/flyme2mars/jotcli/internal/markdown"
+)
+
+// DefaultFolder is the vault subfolder notes are mirrored into.
+const DefaultFolder = "jot"
+
+// Conflict resolutions accepted in Options.Prefer.
+const (
+	PreferJot   = "jot"
+	PreferVault = "vault"
+)
+
+// Options control a sync.
+type Options struct {
+	Folder string // subfolder of the vault, DefaultFolder if empty
+	Prefer string // resolve conflicts with PreferJot or PreferVault; empty only reports them
+}
+
+// Conflict is a note that changed on both sides since the last sync.
+type Conflict struct {
+	NoteID int
+	Path   string // relative to the vault folder
+	Reason string
+}
+
+// Report summarizes what a sync did.
+type Report struct {
+	Exported  int // files created for notes new in jot
+	Imported  int // notes created for files new in the vault
+	Pushed    int // files updated from jot
+	Pulled    int // notes updated from the vault
+	Trashed   int // notes moved to the trash because their file was deleted
+	Removed   int // files deleted because their note was deleted
+	Resolved  int // conflicts settled by Options.Prefer
+	Conflicts []Conflict
+}
+
+// vaultFile is a Markdown file found in the vault folder.
+type vaultFile struct {
+	rel  string // slash-separated path relative to the folder
+	data []byte
+}
+
+type syncer struct {
+	ctx     context.Context
+	store   database.NoteStore
+	dir     string
+	opts    Options
+	entries map[int]database.VaultEntry
+	notes   map[int]database.Note
+	files   map[int]vaultFile // files claiming a note through their front matter id
+	stems   map[int]string    // link target for each note, the file name without .md
+	ids     map[string]int    // lower-case link target to note ID, -1 if ambiguous
+	report  Report
+}
+
+// Sync mirrors every note in store into a folder of the vault at vaultDir
+// and reads back edits made there. Each side's changes are detected by
+// comparing content hashes with those recorded at the last sync; a note
+// changed on both sides is reported as a conflict and left alone unless
+// opts.Prefer says which side wins.
+func Sync(ctx context.Context, store database.NoteStore, vaultDir string, opts Options) (Report, error) {
+	if opts.Folder == "" {
+		opts.Folder = DefaultFolder
+	}
+	switch opts.Prefer {
+	case "", PreferJot, PreferVault:
+	default:
+		return Report{}, fmt.Errorf("unknown conflict resolution %q (use %s or %s)", opts.Prefer, PreferJot, PreferVault)
+	}
+
+	info, err := os.Stat(vaultDir)
+	if err != nil {
+		return Report{}, err
+	}
+	if !info.IsDir() {
+		return Report{}, fmt.Errorf("%s is not a directory", vaultDir)
+	}
+	dir, err := filepath.Abs(filepath.Join(vaultDir, opts.Folder))
+	if err != nil {
+		return Report{}, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Report{}, err
+	}
+
+	s := &syncer{
+		ctx:   ctx,
+		store: store,
+		dir:   dir,
+		opts:  opts,
+		files: make(map[int]vaultFile),
+		stems: make(map[int]string),
+		ids:   make(map[string]int),
+	}
+	if err := s.run(); err != nil {
+		return s.report, err
+	}
+	return s.report, nil
+}
+
+func (s *syncer) run() error {
+	var err error
+	if s.entries, err = s.store.VaultEntries(s.ctx, s.dir); err != nil {
+		return fmt.Errorf("could not read sync state: %v", err)
+	}
+	notes, err := s.store.List(s.ctx, database.NoteFilter{Sort: database.SortID})
+	if err != nil {
+		return err
+	}
+	s.notes = make(map[int]database.Note, len(notes))
+	for _, n := range notes {
+		s.notes[n.ID] = n
+	}
+
+	newFiles, err := s.scan()
+	if err != nil {
+		return err
+	}
+	s.assignStems()
+	if err := s.importFiles(newFiles); err != nil {
+		return err
+	}
+
+	ids := make(map[int]bool)
+	for id := range s.entries {
+		ids[id] = true
+	}
+	for id := range s.notes {
+		ids[id] = true
+	}
+	sorted := make([]int, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Ints(sorted)
+
+	for _, id := range sorted {
+		if err := s.syncNote(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scan reads the vault folder. Files whose front matter names a note jot
+// knows about are matched to it; the rest are returned as new.
+func (s *syncer) scan() ([]vaultFile, error) {
+	var newFiles []vaultFile
+	err := filepath.WalkDir(s.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != s.dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || strings.ToLower(filepath.Ext(p)) != ".md" {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+		f := vaultFile{rel: filepath.ToSlash(rel), data: data}
+
+		id := frontMatterID(data)
+		_, tracked := s.entries[id]
+		_, live := s.notes[id]
+		_, claimed := s.files[id]
+		// A copy of a synced file keeps its id, so only the first claims the note
+		if id > 0 && (tracked || live) && !claimed {
+			s.files[id] = f
+		} else {
+			newFiles = append(newFiles, f)
+		}
+		return nil
+	})
+	return newFiles, err
+}
+
+// assignStems decides which file each note lives in: the one it already has,
+// or a fresh name for notes that haven't been synced yet.
+func (s *syncer) assignStems() {
+	taken := make(map[string]bool)
+	for _, f := range s.files {
+		taken[strings.ToLower(f.rel)] = true
+	}
+	for id, e := range s.entries {
+		if f, ok := s.files[id]; ok {
+			s.addStem(id, f.rel)
+		} else {
+			s.addStem(id, e.Path)
+		}
+		taken[strings.ToLower(e.Path)] = true
+	}
+
+	ids := make([]int, 0, len(s.notes))
+	for id := range s.notes {
+		if _, ok := s.stems[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		if f, ok := s.files[id]; ok {
+			s.addStem(id, f.rel)
+			continue
+		}
+		name := export.Filename(s.notes[id])
+		if taken[strings.ToLower(name)] {
+			name = strings.TrimSuffix(name, ".md") + "-" + strconv.Itoa(id) + ".md"
+		}
+		taken[strings.ToLower(name)] = true
+		s.addStem(id, name)
+	}
+}
+
+// addStem records where a note's file is, so links can be converted both ways.
+// Obsidian links by bare file name when it is unique and by path otherwise.
+func (s *syncer) addStem(id int, rel string) {
+	stem := strings.TrimSuffix(rel, path.Ext(rel))
+	s.stems[id] = stem
+	s.ids[strings.ToLower(stem)] = id
+
+	base := strings.ToLower(path.Base(stem))
+	if base == strings.ToLower(stem) {
+		return
+	}
+	other, ok := s.ids[base]
+	switch {
+	case !ok:
+		s.ids[base] = id
+	case other != id && strings.ToLower(s.stems[other]) != base:
+		s.ids[base] = -1
+	}
+}
+
+func (s *syncer) linkIDs() map[string]int {
+	ids := make(map[string]int, len(s.ids))
+	for name, id := range s.ids {
+		if id > 0 {
+			ids[name] = id
+		}
+	}
+	return ids
+}
+
+// importFiles creates notes for files written in Obsidian. Links between
+// two new files can only be resolved once both have an ID, hence two passes.
+func (s *syncer) importFiles(files []vaultFile) error {
+	created := make([]int, 0, len(files))
+	for _, f := range files {
+		n, err := importer.ParseMarkdown(f.data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", f.rel, err)
+		}
+		if n.Content == "" {
+			continue
+		}
+		if n.CreatedAt.IsZero() {
+			if info, err := os.Stat(s.path(f.rel)); err == nil {
+				n.CreatedAt = info.ModTime()
+			}
+		}
+		n.Content = fromVault(n.Content, s.linkIDs())
+
+		id, err := s.store.Add(s.ctx, n)
+		if err != nil {
+			return err
+		}
+		s.files[id] = f
+		s.addStem(id, f.rel)
+		created = append(created, id)
+		s.report.Imported++
+	}
+
+	for _, id := range created {
+		n, err := s.store.Get(s.ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.store.Update(s.ctx, id, fromVault(n.Content, s.linkIDs())); err != nil {
+			return err
+		}
+		if n, err = s.store.Get(s.ctx, id); err != nil {
+			return err
+		}
+		s.notes[id] = *n
+		// The file gets its id written into the front matter below
+		if err := s.push(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncNote compares one note with its file and the state of the last sync.
+func (s *syncer) syncNote(id int) error {
+	note, live := s.notes[id]
+	entry, tracked := s.entries[id]
+	file, exists := s.files[id]
+
+	if !tracked {
+		switch {
+		case !live:
+			return nil
+		case !exists:
+			s.report.Exported++
+			return s.push(id)
+		}
+		// A file for a note with no sync state, e.g. after the state was lost
+		doc, err := s.render(note)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(doc, file.data) {
+			return s.save(id, doc)
+		}
+		return s.conflict(id, "note and file differ and were never synced", s.push, s.pull)
+	}
+
+	noteChanged := !live || noteHash(note) != entry.NoteHash
+	fileChanged := !exists || hash(file.data) != entry.FileHash
+
+	switch {
+	case live && exists:
+		switch {
+		case noteChanged && fileChanged:
+			return s.conflict(id, "edited in both jot and the vault", s.push, s.pull)
+		case noteChanged:
+			s.report.Pushed++
+			return s.push(id)
+		case fileChanged:
+			s.report.Pulled++
+			return s.pull(id)
+		}
+		// Nothing changed, but a linked note may have been renamed
+		return s.push(id)
+
+	case live:
+		if noteChanged {
+			return s.conflict(id, "deleted in the vault but edited in jot", s.push, s.trash)
+		}
+		return s.trash(id)
+
+	case exists:
+		if fileChanged {
+			return s.conflict(id, "deleted in jot but edited in the vault", s.remove, s.restore)
+		}
+		return s.remove(id)
+	}
+
+	return s.store.DeleteVaultEntry(s.ctx, s.dir, id)
+}
+
+// conflict reports a note changed on both sides, or resolves it with
+// useJot or useVault if the options say so.
+func (s *syncer) conflict(id int, reason string, useJot, useVault func(int) error) error {
+	switch s.opts.Prefer {
+	case PreferJot:
+		s.report.Resolved++
+		return useJot(id)
+	case PreferVault:
+		s.report.Resolved++
+		return useVault(id)
+	}
+	s.report.Conflicts = append(s.report.Conflicts, Conflict{NoteID: id, Path: s.stems[id] + ".md", Reason: reason})
+	return nil
+}
+
+// push writes the note to its file if that changes anything.
+func (s *syncer) push(id int) error {
+	doc, err := s.render(s.notes[id])
+	if err != nil {
+		return err
+	}
+	rel := s.stems[id] + ".md"
+	if f, ok := s.files[id]; !ok || !bytes.Equal(f.data, doc) {
+		p := s.path(rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, doc, 0644); err != nil {
+			return err
+		}
+		s.files[id] = vaultFile{rel: rel, data: doc}
+	}
+	return s.save(id, doc)
+}
+
+// pull updates the note from its file, then writes the file back so it
+// picks up jot's normalized tags and timestamps.
+func (s *syncer) pull(id int) error {
+	file := s.files[id]
+	n, err := importer.ParseMarkdown(file.data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", file.rel, err)
+	}
+	content := fromVault(n.Content, s.linkIDs())
+
+	if err := s.store.Update(s.ctx, id, content); err != nil {
+		return err
+	}
+	if err := s.store.SetTags(s.ctx, id, append(n.Tags, database.ExtractHashtags(content)...)); err != nil {
+		return err
+	}
+	if n.Priority != "" {
+		if err := s.store.SetPriority(s.ctx, id, n.Priority); err != nil {
+			return err
+		}
+	}
+
+	note, err := s.store.Get(s.ctx, id)
+	if err != nil {
+		return err
+	}
+	s.notes[id] = *note
+	return s.push(id)
+}
+
+// trash moves a note whose file was deleted in the vault to jot's trash.
+func (s *syncer) trash(id int) error {
+	if err := s.store.Delete(s.ctx, id); err != nil {
+		return err
+	}
+	delete(s.notes, id)
+	s.report.Trashed++
+	return s.store.DeleteVaultEntry(s.ctx, s.dir, id)
+}
+
+// remove deletes the file of a note that was deleted in jot.
+func (s *syncer) remove(id int) error {
+	err := os.Remove(s.path(s.files[id].rel))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	delete(s.files, id)
+	s.report.Removed++
+	return s.store.DeleteVaultEntry(s.ctx, s.dir, id)
+}
+
+// restore brings back a note deleted in jot whose file was edited since.
+func (s *syncer) restore(id int) error {
+	if err := s.store.Restore(s.ctx, id); err != nil {
+		if !errors.Is(err, database.ErrNoteNotFound) {
+			return err
+		}
+		// Already purged: the file becomes a new note
+		if err := s.store.DeleteVaultEntry(s.ctx, s.dir, id); err != nil {
+			return err
+		}
+		file := s.files[id]
+		delete(s.files, id)
+		return s.importFiles([]vaultFile{file})
+	}
+	note, err := s.store.Get(s.ctx, id)
+	if err != nil {
+		return err
+	}
+	s.notes[id] = *note
+	return s.pull(id)
+}
+
+func (s *syncer) save(id int, doc []byte) error {
+	e := database.VaultEntry{
+		NoteID:   id,
+		Path:     s.stems[id] + ".md",
+		NoteHash: noteHash(s.notes[id]),
+		FileHash: hash(doc),
+	}
+	if err := s.store.SaveVaultEntry(s.ctx, s.dir, e); err != nil {
+		return err
+	}
+	s.entries[id] = e
+	return nil
+}
+
+func (s *syncer) render(n database.Note) ([]byte, error) {
+	return markdown.Render(markdown.FrontMatter{
+		ID:       n.ID,
+		Tags:     n.Tags,
+		Priority: n.Priority,
+		Created:  n.CreatedAt,
+		Updated:  n.UpdatedAt,
+	}, toVault(n.Content, s.stems))
+}
+
+func (s *syncer) path(rel string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(rel))
+}
+
+// frontMatterID returns the note ID recorded in a file's front matter, or 0.
+func frontMatterID(data []byte) int {
+	meta, _, err := markdown.Parse(data)
+	if err != nil {
+		return 0
+	}
+	id, _ := meta["id"].(int)
+	return id
+}
+
+// noteHash covers everything about a note that ends up in its file, except
+// the timestamps, which change whenever one of the others does.
+func noteHash(n database.Note) string {
+	return hash([]byte(n.Content + "\x00" + strings.Join(n.Tags, ",") + "\x00" + n.Priority))
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,146 @@
+package vault
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flyme2mars/jotcli/internal/database"
+)
+
+func setupTestDB(t *testing.T) database.NoteStore {
+	t.Helper()
+	db, err := database.OpenDB(filepath.Join(t.TempDir(), "jot.db"))
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	database.DB = db
+	t.Cleanup(func() { db.Close() })
+	return database.NewSQLiteStore(db)
+}
+
+func TestLinkConversion(t *testing.T) {
+	stems := map[int]string{1: "1-standup", 2: "projects/2-plan"}
+	ids := map[string]int{"1-standup": 1, "projects/2-plan": 2, "2-plan": 2}
+
+	content := "See [[1]], [[2|the plan]], [[2#Goals]] and [[9]]"
+	want := "See [[1-standup]], [[projects/2-plan|the plan]], [[projects/2-plan#Goals]] and [[9]]"
+	if got := toVault(content, stems); got != want {
+		t.Errorf("toVault() = %q, want %q", got, want)
+	}
+
+	back := "See [[1-Standup]], [[2-plan|the plan]], [[projects/2-plan#Goals]] and [[Elsewhere]]"
+	want = "See [[1]], [[2|the plan]], [[2#Goals]] and [[Elsewhere]]"
+	if got := fromVault(back, ids); got != want {
+		t.Errorf("fromVault() = %q, want %q", got, want)
+	}
+}
+
+func TestSyncBothWays(t *testing.T) {
+	store := setupTestDB(t)
+	vaultDir := t.TempDir()
+	dir := filepath.Join(vaultDir, DefaultFolder)
+
+	if err := database.AddNote("# Standup\nDaily sync", []string{"work"}, "low"); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.AddNote("Plan, see [[1]]", nil, "high"); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Sync(context.Background(), store, vaultDir, Options{})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if res.Exported != 2 {
+		t.Errorf("First sync = %+v, want 2 exported", res)
+	}
+	plan, err := os.ReadFile(filepath.Join(dir, "2-plan-see-1.md"))
+	if err != nil {
+		t.Fatalf("Expected the note's file in the vault: %v", err)
+	}
+	if !strings.Contains(string(plan), "Plan, see [[1-standup]]") {
+		t.Errorf("Link not converted for Obsidian:\n%s", plan)
+	}
+
+	// A second sync has nothing to do
+	res, _ = Sync(context.Background(), store, vaultDir, Options{})
+	if res.Pushed+res.Pulled+res.Exported+res.Imported != 0 || len(res.Conflicts) != 0 {
+		t.Errorf("Second sync = %+v, want no changes", res)
+	}
+
+	// Edit in Obsidian, and create a new note there
+	edited := strings.Replace(string(plan), "Plan, see [[1-standup]]", "Plan, see [[1-standup]] #q4", 1)
+	if err := os.WriteFile(filepath.Join(dir, "2-plan-see-1.md"), []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Ideas.md"), []byte("---\ntags: [inbox]\n---\nLinks back to [[2-plan-see-1]]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = Sync(context.Background(), store, vaultDir, Options{})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if res.Pulled != 1 || res.Imported != 1 {
+		t.Errorf("Sync after vault edits = %+v, want 1 pulled and 1 imported", res)
+	}
+	n, _ := database.GetNoteByID(2)
+	if n.Content != "Plan, see [[1]] #q4" || strings.Join(n.Tags, ",") != "q4" {
+		t.Errorf("Pulled note = %q %v, want link converted back and #q4 tagged", n.Content, n.Tags)
+	}
+	n, _ = database.GetNoteByID(3)
+	if n == nil || n.Content != "Links back to [[2]]" || strings.Join(n.Tags, ",") != "inbox" {
+		t.Fatalf("Imported note = %+v", n)
+	}
+	ideas, _ := os.ReadFile(filepath.Join(dir, "Ideas.md"))
+	if !strings.HasPrefix(string(ideas), "---\nid: 3\n") {
+		t.Errorf("Imported file should get its id written back:\n%s", ideas)
+	}
+
+	// Changing both sides is a conflict, left alone until resolved
+	if err := database.UpdateNote(1, "# Standup\nFrom jot"); err != nil {
+		t.Fatal(err)
+	}
+	standup := filepath.Join(dir, "1-standup.md")
+	data, _ := os.ReadFile(standup)
+	fromVault := strings.Replace(string(data), "Daily sync", "From Obsidian", 1)
+	os.WriteFile(standup, []byte(fromVault), 0644)
+
+	res, _ = Sync(context.Background(), store, vaultDir, Options{})
+	if len(res.Conflicts) != 1 || res.Conflicts[0].NoteID != 1 {
+		t.Fatalf("Expected a conflict on note 1, got %+v", res)
+	}
+	if data, _ := os.ReadFile(standup); string(data) != fromVault {
+		t.Errorf("Conflicting file should be left alone")
+	}
+
+	res, _ = Sync(context.Background(), store, vaultDir, Options{Prefer: PreferVault})
+	if res.Resolved != 1 || len(res.Conflicts) != 0 {
+		t.Errorf("Sync preferring the vault = %+v, want 1 resolved", res)
+	}
+	if n, _ := database.GetNoteByID(1); n.Content != "# Standup\nFrom Obsidian" {
+		t.Errorf("Note after resolving = %q", n.Content)
+	}
+
+	// Deletions go both ways
+	os.Remove(filepath.Join(dir, "Ideas.md"))
+	if err := database.DeleteNote(2); err != nil {
+		t.Fatal(err)
+	}
+	res, _ = Sync(context.Background(), store, vaultDir, Options{})
+	if res.Trashed != 1 || res.Removed != 1 {
+		t.Errorf("Sync after deletions = %+v, want 1 trashed and 1 removed", res)
+	}
+	if n, _ := database.GetNoteByID(3); n != nil {
+		t.Errorf("Note whose file was deleted should be in the trash")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2-plan-see-1.md")); !os.IsNotExist(err) {
+		t.Errorf("File of a deleted note should be removed")
+	}
+}